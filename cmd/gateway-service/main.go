@@ -2,15 +2,21 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
+	gatewayv1 "micro-holtye/gen/gateway/v1"
 	"micro-holtye/gen/gateway/v1/gatewayv1connect"
+	"micro-holtye/internal/pkg/config"
 	"micro-holtye/internal/pkg/logger"
+	"micro-holtye/internal/pkg/middleware"
+	"micro-holtye/internal/pkg/observability"
 	"micro-holtye/internal/service/gateway"
 
 	"connectrpc.com/connect"
@@ -18,31 +24,14 @@ import (
 )
 
 func main() {
-	// 初始化统一日志组件，启用追踪功能
-	loggerConfig := logger.LoggerConfig{
-		Level:       logger.InfoLevel,
-		Format:      "json",
-		ServiceName: "gateway-service",
-		Version:     os.Getenv("SERVICE_VERSION"),
-		Environment: getEnvironment(),
-		Output: logger.OutputConfig{
-			Console: logger.ConsoleOutputConfig{Enabled: true},
-			File: logger.FileOutputConfig{
-				Enabled:    true,
-				Path:       "logs/gateway-service.log",
-				MaxSize:    100, // 100MB
-				MaxBackups: 3,
-				MaxAge:     7, // 7天
-				Compress:   true,
-			},
-		},
-		Tracing: logger.TracingConfig{
-			Enabled: true, // 启用追踪功能
-		},
+	// 按 ENVIRONMENT 取日志预设，网关没有配置文件，覆盖项走环境变量
+	logCfg := config.LogConfig{
+		Level:  os.Getenv("LOG_LEVEL"),
+		Format: os.Getenv("LOG_FORMAT"),
 	}
+	loggerConfig := logCfg.ToLoggerConfig("gateway-service")
 
-	// 创建带追踪功能的日志器
-	appLogger, err := logger.CreateTracedLogger(loggerConfig)
+	appLogger, err := logger.CreateLogger(loggerConfig)
 	if err != nil {
 		log.Fatalf("Failed to create logger: %v", err)
 	}
@@ -79,7 +68,38 @@ func main() {
 	)
 
 	// 创建服务组件
-	store := gateway.NewStore(userServiceURL, orderServiceURL)
+	// 下游为幂等只读调用启用带退避的重试，抵御瞬时抖动
+	storeOpts := []gateway.StoreOption{
+		gateway.WithRetryPolicy(gateway.DefaultRetryPolicy()),
+	}
+
+	// 可选的下游熔断：持续故障时快速失败，避免每个请求都等到超时
+	breakerEnabled := os.Getenv("CIRCUIT_BREAKER_ENABLED") == "true"
+	if breakerEnabled {
+		breakerConfig := gateway.DefaultBreakerConfig()
+		if threshold, err := strconv.ParseFloat(os.Getenv("CIRCUIT_BREAKER_FAILURE_THRESHOLD"), 64); err == nil && threshold > 0 {
+			breakerConfig.FailureThreshold = threshold
+		}
+		if coolDown, err := time.ParseDuration(os.Getenv("CIRCUIT_BREAKER_COOL_DOWN")); err == nil && coolDown > 0 {
+			breakerConfig.CoolDown = coolDown
+		}
+		storeOpts = append(storeOpts, gateway.WithCircuitBreaker(breakerConfig))
+		appLogger.Info("Downstream circuit breaker enabled",
+			zap.Float64("failure_threshold", breakerConfig.FailureThreshold),
+			zap.Duration("cool_down", breakerConfig.CoolDown),
+		)
+	}
+
+	store := gateway.NewStore(userServiceURL, orderServiceURL, storeOpts...)
+
+	// 可选的订单缓存预热：聚合查询后短时间内的 GetOrder 直接命中缓存
+	if os.Getenv("ORDER_CACHE_ENABLED") == "true" {
+		store.EnableOrderCache(30*time.Second, 1000)
+		appLogger.Info("Order cache warming enabled",
+			zap.Duration("ttl", 30*time.Second),
+			zap.Int("max_entries", 1000),
+		)
+	}
 	service := gateway.NewService(store, appLogger) // 传入日志器
 	handler := gateway.NewConnectHandler(service, appLogger)
 
@@ -90,33 +110,115 @@ func main() {
 		LogHeaders:    false, // 网关通常不记录头部信息以减少日志量
 		SlowThreshold: 2000,  // 网关的慢请求阈值设为2秒
 		SensitiveFields: []string{
-			"authorization", "cookie", "x-api-key", 
+			"authorization", "cookie", "x-api-key",
 			"password", "token", "secret",
 		},
 	}
-	
+
 	loggingInterceptor := logger.NewConnectLoggingInterceptor(appLogger, middlewareConfig)
 
+	// 创建指标拦截器，记录每个 RPC 的请求数、错误数和延迟
+	metricsInterceptor := observability.NewConnectMetricsInterceptor(observability.MetricsConfig{
+		Subsystem: "gateway_service",
+	})
+
+	// 把各下游熔断器状态暴露为抓取时采样的指标
+	if breakerEnabled {
+		for name := range store.BreakerStates() {
+			downstream := name
+			metricsInterceptor.RegisterGaugeFunc("circuit_breaker_state",
+				"Circuit breaker state by downstream (0=closed, 1=open, 2=half-open).",
+				map[string]string{"downstream": downstream},
+				func() float64 { return store.BreakerStates()[downstream] },
+			)
+		}
+	}
+
+	// 组装拦截器链，按配置加上并发限制
+	interceptors := []connect.Interceptor{loggingInterceptor, metricsInterceptor}
+	if os.Getenv("TRACING_ENABLED") == "true" {
+		interceptors = append(interceptors, observability.NewConnectTracingInterceptor(observability.TracingInterceptorConfig{
+			ServiceName: "gateway-service",
+		}))
+	}
+	// 请求超时：处理器和 errgroup 子任务继承带截止时间的上下文，避免下游挂死拖住请求
+	if timeout, err := time.ParseDuration(os.Getenv("REQUEST_TIMEOUT")); err == nil && timeout > 0 {
+		interceptors = append(interceptors, middleware.NewTimeoutInterceptor(timeout, nil))
+		appLogger.Info("Per-RPC timeout enabled",
+			zap.Duration("default_timeout", timeout),
+		)
+	}
+	if maxConcurrent, err := strconv.Atoi(os.Getenv("MAX_CONCURRENT_REQUESTS")); err == nil && maxConcurrent > 0 {
+		interceptors = append(interceptors, middleware.NewConcurrencyLimitInterceptor(maxConcurrent))
+		appLogger.Info("Concurrency limiting enabled",
+			zap.Int("max_concurrent_requests", maxConcurrent),
+		)
+	}
+
 	// 创建带中间件的处理器
 	mux := http.NewServeMux()
 	path, h := gatewayv1connect.NewGatewayServiceHandler(
 		handler,
-		connect.WithInterceptors(loggingInterceptor),
+		connect.WithInterceptors(interceptors...),
 	)
 	mux.Handle(path, h)
 
-	// 添加健康检查端点
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		ctx := r.Context()
-		appLogger.InfoContext(ctx, "Health check requested",
-			logger.RequestID(r.Header.Get("X-Request-ID")),
-			logger.Component("health-check"),
-		)
-		
+	// 添加健康检查端点（带访问日志）
+	mux.Handle("/health", middleware.AccessLogHandler(appLogger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte(`{"status":"healthy","service":"gateway-service"}`))
-	})
+	})))
+
+	// 深度健康检查：探测各下游服务并返回聚合结果，任一下游不健康时返回 503
+	mux.Handle("/health/deep", middleware.AccessLogHandler(appLogger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		health, err := service.SystemHealth(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		type serviceStatus struct {
+			Status       string `json:"status"`
+			LatencyMs    int64  `json:"latency_ms"`
+			Error        string `json:"error,omitempty"`
+			BreakerState string `json:"breaker_state,omitempty"`
+		}
+		body := struct {
+			Status   string                   `json:"status"`
+			Services map[string]serviceStatus `json:"services"`
+		}{
+			Status:   "healthy",
+			Services: make(map[string]serviceStatus, len(health.Services)),
+		}
+		if !health.Healthy {
+			body.Status = "unhealthy"
+		}
+		for name, svc := range health.Services {
+			status := "healthy"
+			if svc.Status != gatewayv1.HealthStatus_HEALTH_STATUS_HEALTHY {
+				status = "unhealthy"
+			}
+			body.Services[name] = serviceStatus{
+				Status:       status,
+				LatencyMs:    svc.LatencyMs,
+				Error:        svc.Error,
+				BreakerState: svc.BreakerState,
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if !health.Healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(body)
+	})))
+
+	// Prometheus 抓取端点
+	mux.Handle("/metrics", metricsInterceptor.Handler())
+
+	// 运行时日志级别端点：GET 查询 / PUT 修改，无需重启
+	mux.Handle("/admin/loglevel", logger.LevelHandler())
 
 	// 创建HTTP服务器
 	server := &http.Server{
@@ -126,10 +228,10 @@ func main() {
 
 	// 启动服务器
 	go func() {
-		appLogger.Info("Starting gateway service", 
+		appLogger.Info("Starting gateway service",
 			zap.String("address", serverAddress),
 		)
-		
+
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			appLogger.Fatal("Failed to start server", zap.Error(err))
 		}
@@ -149,12 +251,12 @@ func main() {
 		appLogger.Error("Server forced to shutdown", zap.Error(err))
 	}
 
-	// 同步日志
-	if err := appLogger.Sync(); err != nil {
-		log.Printf("Failed to sync logger: %v", err)
-	}
-
 	appLogger.Info("Gateway service stopped")
+
+	// 关闭日志：排空异步缓冲并释放远程连接
+	if err := appLogger.Close(); err != nil {
+		log.Printf("Failed to close logger: %v", err)
+	}
 }
 
 // getEnvironment 获取运行环境
@@ -4,42 +4,29 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"micro-holtye/gen/order/v1/orderv1connect"
+	"micro-holtye/internal/pkg/audit"
 	"micro-holtye/internal/pkg/config"
 	"micro-holtye/internal/pkg/database"
+	"micro-holtye/internal/pkg/health"
+	"micro-holtye/internal/pkg/logger"
+	"micro-holtye/internal/pkg/metrics"
+	"micro-holtye/internal/pkg/middleware"
 	"micro-holtye/internal/pkg/observability"
 	"micro-holtye/internal/service/order"
+	"micro-holtye/migrations"
 
 	"connectrpc.com/connect"
 	"go.uber.org/zap"
 )
 
-// loggingInterceptor implements connect.Interceptor
-type loggingInterceptor struct {
-	logger *zap.Logger
-}
-
-func (i *loggingInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
-	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
-		i.logger.Info("Request",
-			zap.String("procedure", req.Spec().Procedure),
-			zap.String("protocol", req.Peer().Protocol),
-		)
-		return next(ctx, req)
-	}
-}
-
-func (i *loggingInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
-	return next
-}
-
-func (i *loggingInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
-	return next
-}
-
 func main() {
 	var configPath string
 	flag.StringVar(&configPath, "config", "configs/order-service.yaml", "path to config file")
@@ -51,19 +38,53 @@ func main() {
 		os.Exit(1)
 	}
 
-	logger, err := observability.NewLogger(cfg.Log.Level, cfg.Log.Format)
+	// 按 ENVIRONMENT 取日志预设，再把 YAML 中的覆盖项合并上去
+	loggerConfig := cfg.Log.ToLoggerConfig("order-service")
+
+	appLogger, err := logger.CreateLogger(loggerConfig)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
 		os.Exit(1)
 	}
-	defer logger.Sync()
 
-	db, err := database.NewConnection(cfg.Database.DSN())
+	// 设置为全局日志器，运行时日志级别端点通过全局日志器生效
+	logger.SetGlobalLogger(appLogger)
+
+	// 启用独立的审计日志输出，事件不经过采样和级别过滤
+	var auditLogger *audit.AuditLogger
+	if cfg.Log.Audit.Enabled {
+		auditLogger, err = audit.New(auditConfigFromLog(&cfg.Log.Audit))
+		if err != nil {
+			appLogger.Fatal("Failed to create audit logger", zap.Error(err))
+		}
+		audit.SetDefault(auditLogger)
+		appLogger.Info("Audit logging enabled",
+			zap.String("file", cfg.Log.Audit.File.Path),
+		)
+	}
+
+	db, err := database.NewConnectionWithPool(cfg.Database.DSN(), database.PoolConfig{
+		MaxOpenConns:    cfg.Database.Pool.MaxOpenConns,
+		MaxIdleConns:    cfg.Database.Pool.MaxIdleConns,
+		ConnMaxLifetime: cfg.Database.Pool.ConnMaxLifetime,
+		ConnMaxIdleTime: cfg.Database.Pool.ConnMaxIdleTime,
+	})
 	if err != nil {
-		logger.Fatal("Failed to connect to database", zap.Error(err))
+		appLogger.Fatal("Failed to connect to database", zap.Error(err))
 	}
 	defer db.Close()
 
+	// 可选的启动迁移：默认关闭，生产环境单独执行迁移
+	if cfg.Database.AutoMigrate {
+		applied, err := database.RunMigrations(db.DB, migrations.FS)
+		if err != nil {
+			appLogger.Fatal("Failed to run database migrations", zap.Error(err))
+		}
+		for _, version := range applied {
+			appLogger.Info("Applied database migration", zap.Int64("version", version))
+		}
+	}
+
 	redisClient, err := database.NewRedisClient(
 		cfg.Redis.Host,
 		cfg.Redis.Port,
@@ -71,26 +92,153 @@ func main() {
 		cfg.Redis.DB,
 	)
 	if err != nil {
-		logger.Fatal("Failed to connect to Redis", zap.Error(err))
+		appLogger.Fatal("Failed to connect to Redis", zap.Error(err))
 	}
 	defer redisClient.Close()
 
-	store := order.NewStore(db.DB)
-	service := order.NewService(store)
+	// 周期性输出 DB 查询计数快照，观察各查询的调用量和错误率
+	stopMetricsFlush := metrics.Default().StartFlush(60*time.Second, func(snapshots []metrics.CounterSnapshot) {
+		for _, snapshot := range snapshots {
+			appLogger.Debug("Metric snapshot",
+				zap.String("metric", snapshot.Name),
+				zap.Any("labels", snapshot.Labels),
+				zap.Uint64("value", snapshot.Value),
+			)
+		}
+	})
+	defer stopMetricsFlush()
+
+	store := order.NewStoreWithRedis(db.DB, redisClient)
+	service := order.NewService(store, appLogger)
 	handler := order.NewConnectHandler(service)
 
+	// 幂等键后台清理：按配置的 TTL 和间隔删除过期记录
+	service.SetStreamBatchSize(cfg.Server.StreamBatchSize)
+	stopIdempotencyJanitor := service.StartIdempotencyJanitor(
+		cfg.Server.Idempotency.CleanupInterval,
+		cfg.Server.Idempotency.KeyTTL,
+	)
+	defer stopIdempotencyJanitor()
+
 	mux := http.NewServeMux()
 
-	interceptor := &loggingInterceptor{logger: logger}
-	interceptors := connect.WithInterceptors(interceptor)
+	// 使用共享的日志拦截器，与 user-service/gateway-service 保持一致的请求日志
+	middlewareConfig := cfg.Log.Middleware.ToLoggerConfig()
+	loggingInterceptor := logger.NewConnectLoggingInterceptor(appLogger, middlewareConfig)
+
+	stack, err := middleware.StandardInterceptors(&cfg.Server, appLogger)
+	if err != nil {
+		appLogger.Fatal("Invalid interceptor configuration", zap.Error(err))
+	}
+	// 创建指标拦截器，记录每个 RPC 的请求数、错误数和延迟
+	metricsInterceptor := observability.NewConnectMetricsInterceptor(observability.MetricsConfig{
+		Subsystem: "order_service",
+	})
+
+	// 传播拦截器放在最前面，网关透传的追踪头部先进入上下文
+	chain := []connect.Interceptor{logger.NewConnectPropagationInterceptor(), loggingInterceptor, metricsInterceptor}
+	if cfg.Log.Tracing.Enabled {
+		chain = append(chain, observability.NewConnectTracingInterceptor(observability.TracingInterceptorConfig{
+			ServiceName: "order-service",
+		}))
+	}
+	chain = append(chain, stack.Interceptors...)
+	interceptors := connect.WithInterceptors(chain...)
 
 	path, orderHandler := orderv1connect.NewOrderServiceHandler(handler, interceptors)
 	mux.Handle(path, orderHandler)
 
+	// 添加健康检查端点（纯存活检查）
+	mux.Handle("/health", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status":"healthy","service":"order-service"}`))
+	}))
+
+	// 就绪探针：探测数据库和 Redis 连通性，供 Kubernetes readiness 使用
+	mux.Handle("/readyz", health.ReadyzHandler(2*time.Second,
+		health.DatabaseCheck(db.DB),
+		health.RedisCheck(redisClient),
+	))
+
+	// Prometheus 抓取端点
+	mux.Handle("/metrics", metricsInterceptor.Handler())
+
+	// 运行时日志级别端点：GET 查询 / PUT 修改，无需重启
+	mux.Handle("/admin/loglevel", logger.LevelHandler())
+
+	// 创建HTTP服务器
 	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
-	logger.Info("Starting order service", zap.String("address", addr))
+	server := &http.Server{
+		Addr:    addr,
+		Handler: mux,
+	}
+
+	// 启动服务器
+	go func() {
+		appLogger.Info("Starting order service", zap.String("address", addr))
 
-	if err := http.ListenAndServe(addr, mux); err != nil {
-		logger.Fatal("Failed to start server", zap.Error(err))
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			appLogger.Fatal("Failed to start server", zap.Error(err))
+		}
+	}()
+
+	// 优雅关闭
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	appLogger.Info("Shutting down order service...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		appLogger.Error("Server forced to shutdown", zap.Error(err))
+	}
+
+	appLogger.Info("Order service stopped")
+
+	// 关闭日志：排空异步缓冲并释放远程连接
+	if auditLogger != nil {
+		if err := auditLogger.Sync(); err != nil {
+			log.Printf("Failed to sync audit logger: %v", err)
+		}
+	}
+	if err := appLogger.Close(); err != nil {
+		log.Printf("Failed to close logger: %v", err)
+	}
+}
+
+// getEnvironment 获取运行环境
+func getEnvironment() string {
+	env := os.Getenv("ENVIRONMENT")
+	if env == "" {
+		env = "development"
+	}
+	return env
+}
+
+// auditConfigFromLog 把 YAML 审计配置转换为 audit 包的输出配置
+func auditConfigFromLog(cfg *config.AuditConfig) audit.Config {
+	var auditConfig audit.Config
+	if cfg.File.Enabled {
+		auditConfig.File = logger.InternalFileOutputConfig{
+			Filename:   cfg.File.Path,
+			MaxSizeMB:  cfg.File.MaxSize,
+			MaxBackups: cfg.File.MaxBackups,
+			MaxAgeDays: cfg.File.MaxAge,
+			Compress:   cfg.File.Compress,
+		}
+	}
+	if cfg.Remote.Enabled {
+		auditConfig.Remote = logger.InternalRemoteOutputConfig{
+			Type:      cfg.Remote.Protocol,
+			Endpoint:  cfg.Remote.Endpoint,
+			Timeout:   cfg.Remote.Timeout,
+			TLS:       cfg.Remote.TLS,
+			BatchSize: cfg.Remote.BatchSize,
+		}
 	}
+	return auditConfig
 }
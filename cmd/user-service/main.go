@@ -12,10 +12,17 @@ import (
 	"time"
 
 	"micro-holtye/gen/user/v1/userv1connect"
+	"micro-holtye/internal/pkg/audit"
+	"micro-holtye/internal/pkg/cache"
 	"micro-holtye/internal/pkg/config"
 	"micro-holtye/internal/pkg/database"
+	"micro-holtye/internal/pkg/health"
 	"micro-holtye/internal/pkg/logger"
+	"micro-holtye/internal/pkg/metrics"
+	"micro-holtye/internal/pkg/middleware"
+	"micro-holtye/internal/pkg/observability"
 	"micro-holtye/internal/service/user"
+	"micro-holtye/migrations"
 
 	"connectrpc.com/connect"
 	"go.uber.org/zap"
@@ -32,31 +39,10 @@ func main() {
 		os.Exit(1)
 	}
 
-	// 初始化统一日志组件，启用追踪功能
-	loggerConfig := logger.LoggerConfig{
-		Level:       logger.InfoLevel,
-		Format:      "json",
-		ServiceName: "user-service",
-		Version:     os.Getenv("SERVICE_VERSION"),
-		Environment: getEnvironment(),
-		Output: logger.OutputConfig{
-			Console: logger.ConsoleOutputConfig{Enabled: true},
-			File: logger.FileOutputConfig{
-				Enabled:    true,
-				Path:       "logs/user-service.log",
-				MaxSize:    100, // 100MB
-				MaxBackups: 3,
-				MaxAge:     7, // 7天
-				Compress:   true,
-			},
-		},
-		Tracing: logger.TracingConfig{
-			Enabled: true, // 启用追踪功能
-		},
-	}
-
-	// 创建带追踪功能的日志器
-	appLogger, err := logger.CreateTracedLogger(loggerConfig)
+	// 按 ENVIRONMENT 取日志预设，再把 YAML 中的覆盖项合并上去
+	loggerConfig := cfg.Log.ToLoggerConfig("user-service")
+
+	appLogger, err := logger.CreateLogger(loggerConfig)
 	if err != nil {
 		log.Fatalf("Failed to create logger: %v", err)
 	}
@@ -64,6 +50,19 @@ func main() {
 	// 设置为全局日志器
 	logger.SetGlobalLogger(appLogger)
 
+	// 启用独立的审计日志输出，事件不经过采样和级别过滤
+	var auditLogger *audit.AuditLogger
+	if cfg.Log.Audit.Enabled {
+		auditLogger, err = audit.New(auditConfigFromLog(&cfg.Log.Audit))
+		if err != nil {
+			appLogger.Fatal("Failed to create audit logger", zap.Error(err))
+		}
+		audit.SetDefault(auditLogger)
+		appLogger.Info("Audit logging enabled",
+			zap.String("file", cfg.Log.Audit.File.Path),
+		)
+	}
+
 	// 使用新的日志器记录启动信息
 	appLogger.Info("Initializing user service",
 		zap.String("service", "user-service"),
@@ -71,12 +70,28 @@ func main() {
 		zap.String("environment", getEnvironment()),
 	)
 
-	db, err := database.NewConnection(cfg.Database.DSN())
+	db, err := database.NewConnectionWithPool(cfg.Database.DSN(), database.PoolConfig{
+		MaxOpenConns:    cfg.Database.Pool.MaxOpenConns,
+		MaxIdleConns:    cfg.Database.Pool.MaxIdleConns,
+		ConnMaxLifetime: cfg.Database.Pool.ConnMaxLifetime,
+		ConnMaxIdleTime: cfg.Database.Pool.ConnMaxIdleTime,
+	})
 	if err != nil {
 		appLogger.Fatal("Failed to connect to database", zap.Error(err))
 	}
 	defer db.Close()
 
+	// 可选的启动迁移：默认关闭，生产环境单独执行迁移
+	if cfg.Database.AutoMigrate {
+		applied, err := database.RunMigrations(db.DB, migrations.FS)
+		if err != nil {
+			appLogger.Fatal("Failed to run database migrations", zap.Error(err))
+		}
+		for _, version := range applied {
+			appLogger.Info("Applied database migration", zap.Int64("version", version))
+		}
+	}
+
 	redisClient, err := database.NewRedisClient(
 		cfg.Redis.Host,
 		cfg.Redis.Port,
@@ -88,44 +103,89 @@ func main() {
 	}
 	defer redisClient.Close()
 
-	store := user.NewStore(db.DB)
+	// 周期性输出 DB 查询计数快照，观察各查询的调用量和错误率
+	stopMetricsFlush := metrics.Default().StartFlush(60*time.Second, func(snapshots []metrics.CounterSnapshot) {
+		for _, snapshot := range snapshots {
+			appLogger.Debug("Metric snapshot",
+				zap.String("metric", snapshot.Name),
+				zap.Any("labels", snapshot.Labels),
+				zap.Uint64("value", snapshot.Value),
+			)
+		}
+	})
+	defer stopMetricsFlush()
+
+	store := user.NewStoreWithCache(db.DB, cache.New(redisClient, "user:"))
 	service := user.NewService(store, appLogger)
+	service.SetValidationConfig(user.UserValidationConfig{
+		MinUsernameLen: cfg.Server.UserValidation.MinUsernameLen,
+		MaxUsernameLen: cfg.Server.UserValidation.MaxUsernameLen,
+		MinPasswordLen: cfg.Server.UserValidation.MinPasswordLen,
+	})
 	handler := user.NewConnectHandler(service)
 
 	// 创建日志中间件
-	middlewareConfig := logger.MiddlewareConfig{
-		LogRequests:   true,
-		LogResponses:  true,
-		LogHeaders:    false, // 减少日志量
-		SlowThreshold: 1000,  // 1秒慢请求阈值
-		SensitiveFields: []string{
-			"authorization", "cookie", "x-api-key", 
-			"password", "token", "secret",
-		},
-	}
-	
+	middlewareConfig := cfg.Log.Middleware.ToLoggerConfig()
+
 	loggingInterceptor := logger.NewConnectLoggingInterceptor(appLogger, middlewareConfig)
 
+	// 创建指标拦截器，记录每个 RPC 的请求数、错误数和延迟
+	metricsInterceptor := observability.NewConnectMetricsInterceptor(observability.MetricsConfig{
+		Subsystem: "user_service",
+	})
+
+	// 组装拦截器链，标准部分由配置驱动
+	stack, err := middleware.StandardInterceptors(&cfg.Server, appLogger)
+	if err != nil {
+		appLogger.Fatal("Invalid interceptor configuration", zap.Error(err))
+	}
+	// 传播拦截器放在最前面，网关透传的追踪头部先进入上下文
+	interceptors := []connect.Interceptor{logger.NewConnectPropagationInterceptor(), loggingInterceptor, metricsInterceptor}
+	if cfg.Log.Tracing.Enabled {
+		interceptors = append(interceptors, observability.NewConnectTracingInterceptor(observability.TracingInterceptorConfig{
+			ServiceName: "user-service",
+		}))
+	}
+	interceptors = append(interceptors, stack.Interceptors...)
+
+	if rateLimiter := stack.RateLimiter; rateLimiter != nil {
+		// 配置文件变更时热更新租户配额
+		if err := config.Watch(configPath, func(newCfg *config.Config) {
+			rateLimiter.UpdateQuotas(middleware.TenantQuotasFromConfig(&newCfg.Server.RateLimit))
+			appLogger.Info("Rate limit quotas reloaded",
+				zap.Int("tenant_count", len(newCfg.Server.RateLimit.Tenants)),
+			)
+		}); err != nil {
+			appLogger.Warn("Failed to watch config for rate limit updates", zap.Error(err))
+		}
+	}
+
 	// 创建带中间件的处理器
 	mux := http.NewServeMux()
 	path, userHandler := userv1connect.NewUserServiceHandler(
 		handler,
-		connect.WithInterceptors(loggingInterceptor),
+		connect.WithInterceptors(interceptors...),
 	)
 	mux.Handle(path, userHandler)
 
-	// 添加健康检查端点
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		ctx := r.Context()
-		appLogger.InfoContext(ctx, "Health check requested",
-			logger.RequestID(r.Header.Get("X-Request-ID")),
-			logger.Component("health-check"),
-		)
-		
+	// 添加健康检查端点（带访问日志）
+	mux.Handle("/health", middleware.AccessLogHandler(appLogger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte(`{"status":"healthy","service":"user-service"}`))
-	})
+	})))
+
+	// 就绪探针：探测数据库和 Redis 连通性，供 Kubernetes readiness 使用
+	mux.Handle("/readyz", health.ReadyzHandler(2*time.Second,
+		health.DatabaseCheck(db.DB),
+		health.RedisCheck(redisClient),
+	))
+
+	// Prometheus 抓取端点
+	mux.Handle("/metrics", metricsInterceptor.Handler())
+
+	// 运行时日志级别端点：GET 查询 / PUT 修改，无需重启
+	mux.Handle("/admin/loglevel", logger.LevelHandler())
 
 	// 创建HTTP服务器
 	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
@@ -136,10 +196,10 @@ func main() {
 
 	// 启动服务器
 	go func() {
-		appLogger.Info("Starting user service", 
+		appLogger.Info("Starting user service",
 			zap.String("address", addr),
 		)
-		
+
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			appLogger.Fatal("Failed to start server", zap.Error(err))
 		}
@@ -159,12 +219,17 @@ func main() {
 		appLogger.Error("Server forced to shutdown", zap.Error(err))
 	}
 
-	// 同步日志
-	if err := appLogger.Sync(); err != nil {
-		log.Printf("Failed to sync logger: %v", err)
-	}
-
 	appLogger.Info("User service stopped")
+
+	// 关闭日志：排空异步缓冲并释放远程连接
+	if auditLogger != nil {
+		if err := auditLogger.Sync(); err != nil {
+			log.Printf("Failed to sync audit logger: %v", err)
+		}
+	}
+	if err := appLogger.Close(); err != nil {
+		log.Printf("Failed to close logger: %v", err)
+	}
 }
 
 // getEnvironment 获取运行环境
@@ -175,3 +240,27 @@ func getEnvironment() string {
 	}
 	return env
 }
+
+// auditConfigFromLog 把 YAML 审计配置转换为 audit 包的输出配置
+func auditConfigFromLog(cfg *config.AuditConfig) audit.Config {
+	var auditConfig audit.Config
+	if cfg.File.Enabled {
+		auditConfig.File = logger.InternalFileOutputConfig{
+			Filename:   cfg.File.Path,
+			MaxSizeMB:  cfg.File.MaxSize,
+			MaxBackups: cfg.File.MaxBackups,
+			MaxAgeDays: cfg.File.MaxAge,
+			Compress:   cfg.File.Compress,
+		}
+	}
+	if cfg.Remote.Enabled {
+		auditConfig.Remote = logger.InternalRemoteOutputConfig{
+			Type:      cfg.Remote.Protocol,
+			Endpoint:  cfg.Remote.Endpoint,
+			Timeout:   cfg.Remote.Timeout,
+			TLS:       cfg.Remote.TLS,
+			BatchSize: cfg.Remote.BatchSize,
+		}
+	}
+	return auditConfig
+}
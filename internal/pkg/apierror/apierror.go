@@ -0,0 +1,73 @@
+package apierror
+
+import (
+	commonv1 "micro-holtye/gen/common/v1"
+
+	"connectrpc.com/connect"
+)
+
+// 稳定的机器可读错误码，与日志中的 ErrorCode 字段保持一致
+const (
+	CodeEmailExists       = "EMAIL_EXISTS"
+	CodeUsernameExists    = "USERNAME_EXISTS"
+	CodeUserNotFound      = "USER_NOT_FOUND"
+	CodeUserUnavailable   = "USER_UNAVAILABLE"
+	CodeOrderNotFound     = "ORDER_NOT_FOUND"
+	CodeOrderUnavailable  = "ORDER_UNAVAILABLE"
+	CodeOrdersUnavailable = "ORDERS_UNAVAILABLE"
+	CodeInvalidArgument   = "INVALID_ARGUMENT"
+	CodeInternal          = "INTERNAL"
+)
+
+// New 创建带结构化详情的 Connect 错误，客户端可通过 ErrorDetail 获取稳定的错误码
+func New(connectCode connect.Code, err error, code string) *connect.Error {
+	return NewWithField(connectCode, err, code, "")
+}
+
+// NewWithField 创建带结构化详情的 Connect 错误，并标注出错的字段
+func NewWithField(connectCode connect.Code, err error, code, field string) *connect.Error {
+	cerr := connect.NewError(connectCode, err)
+	detail, detailErr := connect.NewErrorDetail(&commonv1.ErrorDetail{
+		Code:      code,
+		Field:     field,
+		Retryable: retryable(connectCode),
+	})
+	if detailErr == nil {
+		cerr.AddDetail(detail)
+	}
+	return cerr
+}
+
+// Violation 单个字段的校验违规，Field 使用路径形式（如 items[0].quantity）
+type Violation struct {
+	Field       string
+	Description string
+}
+
+// NewWithViolations 创建聚合多条校验违规的 Connect 错误
+// 每条违规作为独立的 ErrorDetail 附加，客户端可一次拿到全部问题
+func NewWithViolations(connectCode connect.Code, err error, code string, violations []Violation) *connect.Error {
+	cerr := connect.NewError(connectCode, err)
+	for _, v := range violations {
+		detail, detailErr := connect.NewErrorDetail(&commonv1.ErrorDetail{
+			Code:        code,
+			Field:       v.Field,
+			Retryable:   retryable(connectCode),
+			Description: v.Description,
+		})
+		if detailErr == nil {
+			cerr.AddDetail(detail)
+		}
+	}
+	return cerr
+}
+
+// retryable 根据 Connect 错误码判断请求是否值得重试
+func retryable(code connect.Code) bool {
+	switch code {
+	case connect.CodeUnavailable, connect.CodeResourceExhausted, connect.CodeDeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,129 @@
+// Package audit 提供独立于应用日志的结构化审计日志。
+// 审计事件（用户创建/删除、密码修改、订单取消等）使用固定 JSON 模式，
+// 写入与主日志分开配置的文件或远程输出，不经过采样和级别过滤。
+package audit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"micro-holtye/internal/pkg/logger"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// 审计动作的稳定标识，与日志中的 action 字段保持一致
+const (
+	ActionUserCreated     = "user.created"
+	ActionUserDeleted     = "user.deleted"
+	ActionUserRestored    = "user.restored"
+	ActionPasswordChanged = "user.password_changed"
+	ActionOrderCancelled  = "order.cancelled"
+)
+
+// Event 单条审计事件，时间戳和 request_id 由 Record 补齐
+type Event struct {
+	// Actor 触发操作的主体，为空时从上下文取认证身份，无身份信息时记为 "system"
+	Actor string
+	// Action 审计动作标识，使用上方常量
+	Action string
+	// Target 操作对象，如用户 ID 或订单 ID
+	Target string
+	// RequestID 为空时从上下文补齐
+	RequestID string
+}
+
+// Config 审计输出配置，独立于主日志器
+type Config struct {
+	// File 追加写入的审计文件，Filename 为空时不启用文件输出
+	File logger.InternalFileOutputConfig
+	// Remote 远程输出，Endpoint 为空时不启用
+	Remote logger.InternalRemoteOutputConfig
+}
+
+// AuditLogger 审计日志器
+// 复用 OutputManager 的输出目标，但独立成核：不共享主日志的级别开关，
+// 也不参与采样，事件一定会写入
+type AuditLogger struct {
+	zap *zap.Logger
+}
+
+// New 创建审计日志器
+// 文件和远程输出均未配置时回退到标准输出，保证事件不会静默丢失
+func New(config Config) (*AuditLogger, error) {
+	manager := logger.NewOutputManager()
+	encoder := logger.CreateZapEncoder("json")
+
+	// 审计核使用 DebugLevel 使能器，任何级别的事件都不会被过滤
+	if config.File.Filename != "" {
+		if err := manager.AddFileOutput(config.File, zapcore.DebugLevel, encoder); err != nil {
+			return nil, err
+		}
+	}
+	if config.Remote.Endpoint != "" {
+		if err := manager.AddRemoteOutput(config.Remote, zapcore.DebugLevel, encoder.Clone()); err != nil {
+			return nil, err
+		}
+	}
+
+	return &AuditLogger{
+		// 审计日志不需要调用方信息和堆栈，保持每条记录的模式固定
+		zap: zap.New(manager.CreateTeeCore()),
+	}, nil
+}
+
+// Record 写入一条审计事件
+// actor 和 request_id 缺省时从上下文补齐，时间戳统一使用 UTC
+func (l *AuditLogger) Record(ctx context.Context, event Event) {
+	if event.RequestID == "" {
+		event.RequestID = logger.RequestIDFromContext(ctx)
+	}
+	if event.Actor == "" {
+		// 认证拦截器把调用方身份注入上下文；没有身份信息时记为 system
+		if actor := logger.UserIDFromContext(ctx); actor != "" {
+			event.Actor = actor
+		} else {
+			event.Actor = "system"
+		}
+	}
+
+	l.zap.Info("audit",
+		zap.String("actor", event.Actor),
+		zap.String("action", event.Action),
+		zap.String("target", event.Target),
+		zap.Time("timestamp", time.Now().UTC()),
+		zap.String("request_id", event.RequestID),
+	)
+}
+
+// Sync 刷新所有输出缓冲
+func (l *AuditLogger) Sync() error {
+	return l.zap.Sync()
+}
+
+// 包级默认审计日志器，服务入口在启动时注入
+var (
+	defaultMu     sync.RWMutex
+	defaultLogger *AuditLogger
+)
+
+// SetDefault 设置包级默认审计日志器
+func SetDefault(l *AuditLogger) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultLogger = l
+}
+
+// Record 使用包级默认审计日志器写入事件，未配置时静默忽略
+func Record(ctx context.Context, event Event) {
+	defaultMu.RLock()
+	l := defaultLogger
+	defaultMu.RUnlock()
+
+	if l == nil {
+		return
+	}
+	l.Record(ctx, event)
+}
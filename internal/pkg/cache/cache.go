@@ -0,0 +1,58 @@
+// Package cache 提供基于 Redis 的轻量缓存封装。
+// 所有操作都接受上下文，并区分缓存未命中与传输层错误，
+// 调用方可据此决定回源加载还是降级。
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrCacheMiss 表示键不存在，调用方应回源加载
+var ErrCacheMiss = errors.New("cache miss")
+
+// Cache 带键前缀的 Redis 缓存封装
+type Cache struct {
+	client *redis.Client
+	prefix string
+}
+
+// New 创建缓存封装，prefix 用于隔离不同服务的键空间（如 "user:"）
+func New(client *redis.Client, prefix string) *Cache {
+	return &Cache{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+// Get 读取键的值；键不存在返回 ErrCacheMiss，其余错误为传输层错误
+func (c *Cache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.client.Get(ctx, c.prefix+key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrCacheMiss
+		}
+		return nil, fmt.Errorf("cache get %q: %w", key, err)
+	}
+	return value, nil
+}
+
+// Set 写入键值并设置过期时间，ttl <= 0 表示不过期
+func (c *Cache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := c.client.Set(ctx, c.prefix+key, value, ttl).Err(); err != nil {
+		return fmt.Errorf("cache set %q: %w", key, err)
+	}
+	return nil
+}
+
+// Delete 删除键，键不存在不视为错误
+func (c *Cache) Delete(ctx context.Context, key string) error {
+	if err := c.client.Del(ctx, c.prefix+key).Err(); err != nil {
+		return fmt.Errorf("cache delete %q: %w", key, err)
+	}
+	return nil
+}
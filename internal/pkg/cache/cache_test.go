@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestCache 用 miniredis 支撑的缓存封装
+func newTestCache(t *testing.T) (*Cache, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return New(client, "test:"), server
+}
+
+func TestCacheMissReturnsTypedError(t *testing.T) {
+	c, _ := newTestCache(t)
+
+	_, err := c.Get(context.Background(), "absent")
+	if !errors.Is(err, ErrCacheMiss) {
+		t.Fatalf("Get on absent key: %v, want ErrCacheMiss", err)
+	}
+}
+
+func TestCacheSetGetDeleteRoundTrip(t *testing.T) {
+	c, server := newTestCache(t)
+	ctx := context.Background()
+
+	if err := c.Set(ctx, "user-1", []byte(`{"id":"user-1"}`), time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	value, err := c.Get(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("Get after Set: %v", err)
+	}
+	if string(value) != `{"id":"user-1"}` {
+		t.Errorf("Get = %s, want stored value", value)
+	}
+	// 键带上服务前缀写入，隔离不同服务的键空间
+	if !server.Exists("test:user-1") {
+		t.Error("stored key is not prefixed")
+	}
+
+	if err := c.Delete(ctx, "user-1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := c.Get(ctx, "user-1"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Get after Delete: %v, want ErrCacheMiss", err)
+	}
+}
+
+func TestCacheEntryExpiresAfterTTL(t *testing.T) {
+	c, server := newTestCache(t)
+	ctx := context.Background()
+
+	if err := c.Set(ctx, "user-1", []byte("v"), 30*time.Second); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	server.FastForward(time.Minute)
+
+	if _, err := c.Get(ctx, "user-1"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Get after TTL elapsed: %v, want ErrCacheMiss", err)
+	}
+}
@@ -2,8 +2,15 @@ package config
 
 import (
 	"fmt"
+	"net/url"
+	"os"
+	"sort"
 	"strings"
+	"time"
 
+	"micro-holtye/internal/pkg/logger"
+
+	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/viper"
 )
 
@@ -17,6 +24,71 @@ type Config struct {
 type ServerConfig struct {
 	Host string `mapstructure:"host"`
 	Port int    `mapstructure:"port"`
+
+	// MaxConcurrentRequests 同时处理的最大请求数，0 表示不限制
+	MaxConcurrentRequests int `mapstructure:"max_concurrent_requests"`
+
+	// MaxResponseBytes 单个响应序列化后的最大字节数，0 表示不限制
+	MaxResponseBytes int `mapstructure:"max_response_bytes"`
+	// StreamBatchSize 流式接口每批从数据库加载的行数，0 使用服务内默认值
+	StreamBatchSize int32 `mapstructure:"stream_batch_size"`
+
+	// RateLimit 按租户限流配置
+	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
+
+	// Interceptors 拦截器名称列表，按序组装标准拦截器栈
+	// 为空时按各项配置开关推导；未知名称在启动时报错
+	Interceptors []string `mapstructure:"interceptors"`
+
+	// Idempotency 幂等键保留与清理配置（order-service 使用）
+	Idempotency IdempotencyConfig `mapstructure:"idempotency"`
+
+	// UserValidation 用户输入校验规则（user-service 使用），0 使用默认值
+	UserValidation UserValidationConfig `mapstructure:"user_validation"`
+
+	// Timeout 按 RPC 的处理超时配置
+	Timeout TimeoutConfig `mapstructure:"timeout"`
+}
+
+// TimeoutConfig 按 RPC 的处理超时配置
+type TimeoutConfig struct {
+	// Default 默认单次调用超时，0 表示不启用超时拦截器
+	Default time.Duration `mapstructure:"default"`
+
+	// PerProcedure 按 procedure 全名覆盖默认超时（如 /user.v1.UserService/ListUsers）
+	PerProcedure map[string]time.Duration `mapstructure:"per_procedure"`
+}
+
+// UserValidationConfig 用户输入校验规则
+type UserValidationConfig struct {
+	MinUsernameLen int `mapstructure:"min_username_len"`
+	MaxUsernameLen int `mapstructure:"max_username_len"`
+	MinPasswordLen int `mapstructure:"min_password_len"`
+}
+
+// IdempotencyConfig 幂等键保留与后台清理配置
+type IdempotencyConfig struct {
+	// KeyTTL 幂等键保留时长，超过后由后台清理任务删除，0 使用默认值
+	KeyTTL time.Duration `mapstructure:"key_ttl"`
+
+	// CleanupInterval 后台清理任务的执行间隔，0 使用默认值
+	CleanupInterval time.Duration `mapstructure:"cleanup_interval"`
+}
+
+// RateLimitConfig 按租户限流配置
+type RateLimitConfig struct {
+	Enabled      bool    `mapstructure:"enabled"`
+	DefaultRate  float64 `mapstructure:"default_rate"`
+	DefaultBurst int     `mapstructure:"default_burst"`
+
+	// Tenants 租户 ID 到配额的映射，未列出的租户使用默认配额
+	Tenants map[string]TenantQuotaConfig `mapstructure:"tenants"`
+}
+
+// TenantQuotaConfig 单个租户的限流配额
+type TenantQuotaConfig struct {
+	Rate  float64 `mapstructure:"rate"`
+	Burst int     `mapstructure:"burst"`
 }
 
 type DatabaseConfig struct {
@@ -26,6 +98,24 @@ type DatabaseConfig struct {
 	Password string `mapstructure:"password"`
 	Database string `mapstructure:"database"`
 	SSLMode  string `mapstructure:"ssl_mode"`
+
+	// AutoMigrate 启动时自动应用内嵌的数据库迁移（默认关闭，生产环境单独执行迁移）
+	AutoMigrate bool `mapstructure:"auto_migrate"`
+
+	// Params 附加连接参数（如 connect_timeout、statement_timeout、application_name），
+	// 按键名排序追加到 DSN 和 URL
+	Params map[string]string `mapstructure:"params"`
+
+	// Pool 连接池配置，零值字段使用 database 包内默认值
+	Pool PoolConfig `mapstructure:"pool"`
+}
+
+// PoolConfig 数据库连接池配置
+type PoolConfig struct {
+	MaxOpenConns    int           `mapstructure:"max_open_conns"`
+	MaxIdleConns    int           `mapstructure:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `mapstructure:"conn_max_idle_time"`
 }
 
 type RedisConfig struct {
@@ -39,12 +129,82 @@ type LogConfig struct {
 	// 基础配置 - 保持向后兼容性
 	Level  string `mapstructure:"level"`
 	Format string `mapstructure:"format"`
-	
+
 	// 新增配置
 	Output      OutputConfig      `mapstructure:"output"`
 	Tracing     TracingConfig     `mapstructure:"tracing"`
 	Middleware  MiddlewareConfig  `mapstructure:"middleware"`
 	Performance PerformanceConfig `mapstructure:"performance"`
+
+	// Audit 审计日志输出配置，与应用日志相互独立
+	Audit AuditConfig `mapstructure:"audit"`
+}
+
+// ToLoggerConfig 把 YAML 日志配置转换为 logger.LoggerConfig
+// 以 ENVIRONMENT 对应的环境预设为底，再把 YAML 中显式启用的部分叠加上去，
+// 让日志行为可以从 configs/*.yaml 调整而无需重新编译
+func (c *LogConfig) ToLoggerConfig(serviceName string) logger.LoggerConfig {
+	environment := os.Getenv("ENVIRONMENT")
+	if environment == "" {
+		environment = "development"
+	}
+	result := logger.ConfigForEnvironment(serviceName, environment)
+
+	if c.Level != "" {
+		result.Level = logger.ParseLevel(c.Level)
+	}
+	if c.Format != "" {
+		result.Format = c.Format
+	}
+
+	if c.Output.Console.Enabled {
+		result.Output.Console = logger.ConsoleOutputConfig{
+			Enabled:    true,
+			Colorized:  c.Output.Console.Colorized,
+			TimeFormat: c.Output.Console.TimeFormat,
+		}
+	}
+	if c.Output.File.Enabled {
+		result.Output.File = logger.FileOutputConfig{
+			Enabled:    true,
+			Path:       c.Output.File.Path,
+			MaxSize:    c.Output.File.MaxSize,
+			MaxBackups: c.Output.File.MaxBackups,
+			MaxAge:     c.Output.File.MaxAge,
+			Compress:   c.Output.File.Compress,
+		}
+	}
+	if c.Output.Remote.Enabled {
+		result.Output.Remote = logger.RemoteOutputConfig{
+			Enabled:   true,
+			Endpoint:  c.Output.Remote.Endpoint,
+			Protocol:  c.Output.Remote.Protocol,
+			BatchSize: c.Output.Remote.BatchSize,
+			Timeout:   c.Output.Remote.Timeout,
+			TLS:       c.Output.Remote.TLS,
+		}
+	}
+
+	if c.Tracing.Enabled {
+		result.Tracing.Enabled = true
+	}
+	if c.Performance.AsyncWrite {
+		result.Performance = logger.PerformanceConfig{
+			AsyncWrite:    true,
+			BufferSize:    c.Performance.BufferSize,
+			FlushInterval: c.Performance.FlushInterval,
+		}
+	}
+
+	return result
+}
+
+// AuditConfig 审计日志输出配置
+// 审计事件不经过采样和级别过滤，文件与远程输出均未配置时写入标准输出
+type AuditConfig struct {
+	Enabled bool         `mapstructure:"enabled"`
+	File    FileConfig   `mapstructure:"file"`
+	Remote  RemoteConfig `mapstructure:"remote"`
 }
 
 // OutputConfig 输出配置
@@ -56,8 +216,8 @@ type OutputConfig struct {
 
 // ConsoleConfig 控制台输出配置
 type ConsoleConfig struct {
-	Enabled    bool `mapstructure:"enabled"`
-	Colorized  bool `mapstructure:"colorized"`
+	Enabled    bool   `mapstructure:"enabled"`
+	Colorized  bool   `mapstructure:"colorized"`
 	TimeFormat string `mapstructure:"time_format"`
 }
 
@@ -96,6 +256,37 @@ type MiddlewareConfig struct {
 	LogHeaders      bool     `mapstructure:"log_headers"`
 	SensitiveFields []string `mapstructure:"sensitive_fields"`
 	MaxBodySize     int      `mapstructure:"max_body_size"`
+
+	// SlowThreshold 慢请求阈值（毫秒），0 使用默认值
+	SlowThreshold int `mapstructure:"slow_threshold_ms"`
+}
+
+// defaultSensitiveFields 未配置时脱敏的请求头字段
+var defaultSensitiveFields = []string{
+	"authorization", "cookie", "x-api-key",
+	"password", "token", "secret",
+}
+
+// ToLoggerConfig 把 YAML 中间件配置转换为日志拦截器配置
+// 慢请求阈值和敏感字段列表的零值回退到各服务沿用的默认值
+func (c *MiddlewareConfig) ToLoggerConfig() logger.MiddlewareConfig {
+	slowThreshold := c.SlowThreshold
+	if slowThreshold <= 0 {
+		slowThreshold = 1000
+	}
+	sensitiveFields := c.SensitiveFields
+	if len(sensitiveFields) == 0 {
+		sensitiveFields = defaultSensitiveFields
+	}
+
+	return logger.MiddlewareConfig{
+		LogRequests:     c.LogRequests,
+		LogResponses:    c.LogResponses,
+		LogHeaders:      c.LogHeaders,
+		SensitiveFields: sensitiveFields,
+		MaxBodySize:     c.MaxBodySize,
+		SlowThreshold:   slowThreshold,
+	}
 }
 
 // PerformanceConfig 性能配置
@@ -118,16 +309,149 @@ func Load(configPath string) (*Config, error) {
 	if err := v.ReadInConfig(); err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
+	bindEnvKeys(v)
 
 	var config Config
 	if err := v.Unmarshal(&config); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", configPath, err)
+	}
+
 	return &config, nil
 }
 
+// Validate 校验必填配置项，一次性汇总所有问题返回，
+// 避免缺失的 DB 主机或端口拖到建连时才暴露
+func (c *Config) Validate() error {
+	var problems []string
+
+	if c.Server.Port <= 0 || c.Server.Port > 65535 {
+		problems = append(problems, fmt.Sprintf("server.port must be between 1 and 65535, got %d", c.Server.Port))
+	}
+
+	if c.Database.Host == "" {
+		problems = append(problems, "database.host is required")
+	}
+	if c.Database.Port <= 0 || c.Database.Port > 65535 {
+		problems = append(problems, fmt.Sprintf("database.port must be between 1 and 65535, got %d", c.Database.Port))
+	}
+	if c.Database.User == "" {
+		problems = append(problems, "database.user is required")
+	}
+	if c.Database.Database == "" {
+		problems = append(problems, "database.database is required")
+	}
+
+	if c.Redis.Host == "" {
+		problems = append(problems, "redis.host is required")
+	}
+	if c.Redis.Port <= 0 || c.Redis.Port > 65535 {
+		problems = append(problems, fmt.Sprintf("redis.port must be between 1 and 65535, got %d", c.Redis.Port))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%d problem(s) found:\n  - %s", len(problems), strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// bindEnvKeys 为配置文件中出现的每个键显式绑定环境变量。
+// AutomaticEnv 不会让 Unmarshal 看到嵌套键的覆盖值，
+// 显式 BindEnv 后 PIGEON_DATABASE_HOST 这类变量才能覆盖 database.host
+func bindEnvKeys(v *viper.Viper) {
+	for _, key := range v.AllKeys() {
+		// BindEnv 只有在键为空时才报错，这里键恒非空
+		_ = v.BindEnv(key)
+	}
+}
+
+// Watch 监听配置文件变更，每次成功重新解析后回调 onChange
+// 解析失败时保留旧配置，不触发回调
+func Watch(configPath string, onChange func(*Config)) error {
+	v := viper.New()
+
+	v.SetConfigFile(configPath)
+	v.SetConfigType("yaml")
+
+	v.SetEnvPrefix("PIGEON")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	if err := v.ReadInConfig(); err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	bindEnvKeys(v)
+
+	v.OnConfigChange(func(fsnotify.Event) {
+		var config Config
+		if err := v.Unmarshal(&config); err != nil {
+			return
+		}
+		onChange(&config)
+	})
+	v.WatchConfig()
+
+	return nil
+}
+
+// DSN 返回关键字式连接串，值按 libpq 规则引用，
+// 含空格或引号的密码不会破坏解析
 func (c *DatabaseConfig) DSN() string {
-	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		c.Host, c.Port, c.User, c.Password, c.Database, c.SSLMode)
-}
\ No newline at end of file
+	pairs := []string{
+		"host=" + quoteDSNValue(c.Host),
+		fmt.Sprintf("port=%d", c.Port),
+		"user=" + quoteDSNValue(c.User),
+		"password=" + quoteDSNValue(c.Password),
+		"dbname=" + quoteDSNValue(c.Database),
+		"sslmode=" + quoteDSNValue(c.SSLMode),
+	}
+	for _, key := range sortedKeys(c.Params) {
+		pairs = append(pairs, key+"="+quoteDSNValue(c.Params[key]))
+	}
+	return strings.Join(pairs, " ")
+}
+
+// URL 返回 postgres:// 形式的连接串，用户名和密码经过 URL 转义，
+// 供偏好 URL 形式的工具（如 migrate CLI）使用
+func (c *DatabaseConfig) URL() string {
+	u := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(c.User, c.Password),
+		Host:   fmt.Sprintf("%s:%d", c.Host, c.Port),
+		Path:   "/" + c.Database,
+	}
+
+	query := url.Values{}
+	if c.SSLMode != "" {
+		query.Set("sslmode", c.SSLMode)
+	}
+	for key, value := range c.Params {
+		query.Set(key, value)
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+// quoteDSNValue 按 libpq 规则引用关键字式 DSN 的值：
+// 为空或含空白、引号、反斜杠时用单引号包裹，并转义反斜杠和单引号
+func quoteDSNValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\n'\\") {
+		return value
+	}
+	escaped := strings.NewReplacer("\\", "\\\\", "'", "\\'").Replace(value)
+	return "'" + escaped + "'"
+}
+
+// sortedKeys 返回 map 按字典序排列的键，保证 DSN 输出稳定
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,295 @@
+package config
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"micro-holtye/internal/pkg/logger"
+)
+
+func TestToLoggerConfigDevelopmentEnvironmentPreset(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "development")
+
+	cfg := (&LogConfig{}).ToLoggerConfig("user-service")
+
+	if cfg.Format != "console" {
+		t.Errorf("Format = %q, want console for development", cfg.Format)
+	}
+	if cfg.Level != logger.DebugLevel {
+		t.Errorf("Level = %v, want DebugLevel for development", cfg.Level)
+	}
+	if cfg.Environment != "development" {
+		t.Errorf("Environment = %q, want development", cfg.Environment)
+	}
+}
+
+func TestToLoggerConfigYAMLOverridesWinOverPreset(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "development")
+
+	cfg := (&LogConfig{Level: "warn", Format: "json"}).ToLoggerConfig("user-service")
+
+	if cfg.Format != "json" {
+		t.Errorf("Format = %q, want json from YAML override", cfg.Format)
+	}
+	if cfg.Level != logger.WarnLevel {
+		t.Errorf("Level = %v, want WarnLevel from YAML override", cfg.Level)
+	}
+}
+
+// writeConfigFile 把 YAML 内容写入临时配置文件
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadRejectsIncompleteConfigWithAllProblems(t *testing.T) {
+	// 缺 database.host/user/database 和 redis.host，server.port 越界
+	path := writeConfigFile(t, `
+server:
+  host: localhost
+  port: 0
+database:
+  port: 5432
+redis:
+  port: 6379
+`)
+
+	_, err := Load(path)
+	if err == nil {
+		t.Fatal("incomplete config accepted, want aggregated validation error")
+	}
+
+	for _, want := range []string{
+		"server.port must be between 1 and 65535",
+		"database.host is required",
+		"database.user is required",
+		"database.database is required",
+		"redis.host is required",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not mention %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestLoadEnvVarsOverrideFileValues(t *testing.T) {
+	path := writeConfigFile(t, `
+server:
+  host: localhost
+  port: 8080
+database:
+  host: db.internal
+  port: 5432
+  user: pigeon
+  password: from-file
+  database: pigeon_db
+redis:
+  host: redis.internal
+  port: 6379
+`)
+
+	t.Setenv("PIGEON_SERVER_PORT", "9090")
+	// 嵌套键同样能被环境变量覆盖
+	t.Setenv("PIGEON_DATABASE_PASSWORD", "from-env")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if cfg.Server.Port != 9090 {
+		t.Errorf("Server.Port = %d, want 9090 from PIGEON_SERVER_PORT", cfg.Server.Port)
+	}
+	if cfg.Database.Password != "from-env" {
+		t.Errorf("Database.Password = %q, want from-env from PIGEON_DATABASE_PASSWORD", cfg.Database.Password)
+	}
+	if cfg.Database.Host != "db.internal" {
+		t.Errorf("Database.Host = %q, want file value untouched", cfg.Database.Host)
+	}
+}
+
+func TestDSNQuotesTrickyPasswords(t *testing.T) {
+	cfg := &DatabaseConfig{
+		Host:     "localhost",
+		Port:     5432,
+		User:     "pigeon",
+		Password: `p@ss word'"`,
+		Database: "pigeon_db",
+		SSLMode:  "disable",
+		Params:   map[string]string{"application_name": "user-service", "connect_timeout": "5"},
+	}
+
+	dsn := cfg.DSN()
+
+	// 含空格和引号的密码必须整体用单引号包裹并转义
+	if !strings.Contains(dsn, `password='p@ss word\'"'`) {
+		t.Errorf("DSN does not quote the tricky password:\n%s", dsn)
+	}
+	// 附加参数按字典序稳定输出
+	if !strings.Contains(dsn, "application_name=user-service connect_timeout=5") {
+		t.Errorf("DSN missing sorted extra params:\n%s", dsn)
+	}
+}
+
+func TestURLEscapesCredentials(t *testing.T) {
+	cfg := &DatabaseConfig{
+		Host:     "localhost",
+		Port:     5432,
+		User:     "pigeon",
+		Password: `p@ss word'"`,
+		Database: "pigeon_db",
+		SSLMode:  "disable",
+	}
+
+	raw := cfg.URL()
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("URL() produced unparseable output %q: %v", raw, err)
+	}
+
+	// 往返解析后密码原样恢复，说明转义正确
+	password, _ := u.User.Password()
+	if password != cfg.Password {
+		t.Errorf("round-tripped password = %q, want %q", password, cfg.Password)
+	}
+	if u.Query().Get("sslmode") != "disable" {
+		t.Errorf("sslmode query = %q, want disable", u.Query().Get("sslmode"))
+	}
+}
+
+func TestMiddlewareConfigRoundTripsFromYAML(t *testing.T) {
+	path := writeConfigFile(t, `
+server:
+  host: localhost
+  port: 8080
+database:
+  host: db.internal
+  port: 5432
+  user: pigeon
+  database: pigeon_db
+redis:
+  host: redis.internal
+  port: 6379
+log:
+  middleware:
+    log_requests: true
+    log_headers: true
+    sensitive_fields: [authorization, x-internal-token]
+    max_body_size: 2048
+    slow_threshold_ms: 250
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	mw := cfg.Log.Middleware.ToLoggerConfig()
+	if !mw.LogRequests || mw.LogResponses {
+		t.Errorf("LogRequests=%v LogResponses=%v, want true/false from YAML", mw.LogRequests, mw.LogResponses)
+	}
+	if !mw.LogHeaders {
+		t.Error("LogHeaders not carried over from YAML")
+	}
+	if mw.MaxBodySize != 2048 {
+		t.Errorf("MaxBodySize = %d, want 2048", mw.MaxBodySize)
+	}
+	if mw.SlowThreshold != 250 {
+		t.Errorf("SlowThreshold = %d, want 250 from slow_threshold_ms", mw.SlowThreshold)
+	}
+	if len(mw.SensitiveFields) != 2 || mw.SensitiveFields[1] != "x-internal-token" {
+		t.Errorf("SensitiveFields = %v, want the YAML list", mw.SensitiveFields)
+	}
+}
+
+func TestMiddlewareConfigZeroValuesFallBackToDefaults(t *testing.T) {
+	mw := (&MiddlewareConfig{}).ToLoggerConfig()
+
+	if mw.SlowThreshold != 1000 {
+		t.Errorf("SlowThreshold = %d, want default 1000", mw.SlowThreshold)
+	}
+	if len(mw.SensitiveFields) == 0 {
+		t.Error("SensitiveFields empty, want default redaction list")
+	}
+}
+
+func TestLogConfigToLoggerConfigMapsAllThreeOutputs(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "production")
+
+	path := writeConfigFile(t, `
+server:
+  host: localhost
+  port: 8080
+database:
+  host: db.internal
+  port: 5432
+  user: pigeon
+  database: pigeon_db
+redis:
+  host: redis.internal
+  port: 6379
+log:
+  level: warn
+  output:
+    console:
+      enabled: true
+      colorized: true
+      time_format: "15:04:05"
+    file:
+      enabled: true
+      path: /var/log/pigeon/user.log
+      max_size_mb: 64
+      max_backups: 7
+      max_age_days: 14
+      compress: true
+    remote:
+      enabled: true
+      endpoint: logs.internal:4318
+      protocol: grpc
+      batch_size: 500
+      timeout_ms: 3000
+      tls: true
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	lc := cfg.Log.ToLoggerConfig("user-service")
+	if lc.ServiceName != "user-service" {
+		t.Errorf("ServiceName = %q, want user-service", lc.ServiceName)
+	}
+	if lc.Level != logger.WarnLevel {
+		t.Errorf("Level = %v, want WarnLevel from YAML", lc.Level)
+	}
+
+	console := lc.Output.Console
+	if !console.Enabled || !console.Colorized || console.TimeFormat != "15:04:05" {
+		t.Errorf("Console = %+v, want enabled/colorized with custom time format", console)
+	}
+
+	file := lc.Output.File
+	if !file.Enabled || file.Path != "/var/log/pigeon/user.log" {
+		t.Errorf("File = %+v, want enabled with the YAML path", file)
+	}
+	if file.MaxSize != 64 || file.MaxBackups != 7 || file.MaxAge != 14 || !file.Compress {
+		t.Errorf("File rotation = %+v, want 64MB/7 backups/14 days/compress", file)
+	}
+
+	remote := lc.Output.Remote
+	if !remote.Enabled || remote.Endpoint != "logs.internal:4318" || remote.Protocol != "grpc" {
+		t.Errorf("Remote = %+v, want enabled grpc endpoint from YAML", remote)
+	}
+	if remote.BatchSize != 500 || remote.Timeout != 3000 || !remote.TLS {
+		t.Errorf("Remote delivery = %+v, want batch 500/timeout 3000ms/tls", remote)
+	}
+}
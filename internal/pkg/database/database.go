@@ -13,18 +13,55 @@ type DB struct {
 	*sql.DB
 }
 
+// 连接池默认值，PoolConfig 零值字段回退到这些设置
+const (
+	defaultMaxOpenConns    = 25
+	defaultMaxIdleConns    = 25
+	defaultConnMaxLifetime = 2 * time.Hour
+	defaultConnMaxIdleTime = 5 * time.Minute
+
+	pingTimeout = 5 * time.Second
+)
+
+// PoolConfig 连接池配置，零值字段使用包内默认值（与既有行为一致）
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
 func NewConnection(dsn string) (*DB, error) {
+	return NewConnectionWithPool(dsn, PoolConfig{})
+}
+
+// NewConnectionWithPool 按给定连接池配置打开数据库连接，
+// 打开后立即带超时 Ping，坏 DSN 在启动时快速失败而不是拖到首次查询
+func NewConnectionWithPool(dsn string, pool PoolConfig) (*DB, error) {
 	db, err := sql.Open("postgres", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(25)
-	db.SetConnMaxIdleTime(5 * time.Minute)
-	db.SetConnMaxLifetime(2 * time.Hour)
+	if pool.MaxOpenConns <= 0 {
+		pool.MaxOpenConns = defaultMaxOpenConns
+	}
+	if pool.MaxIdleConns <= 0 {
+		pool.MaxIdleConns = defaultMaxIdleConns
+	}
+	if pool.ConnMaxLifetime <= 0 {
+		pool.ConnMaxLifetime = defaultConnMaxLifetime
+	}
+	if pool.ConnMaxIdleTime <= 0 {
+		pool.ConnMaxIdleTime = defaultConnMaxIdleTime
+	}
+
+	db.SetMaxOpenConns(pool.MaxOpenConns)
+	db.SetMaxIdleConns(pool.MaxIdleConns)
+	db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
 	defer cancel()
 
 	if err := db.PingContext(ctx); err != nil {
@@ -36,4 +73,4 @@ func NewConnection(dsn string) (*DB, error) {
 
 func (db *DB) Close() error {
 	return db.DB.Close()
-}
\ No newline at end of file
+}
@@ -0,0 +1,163 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// migration 单个待应用的迁移脚本
+type migration struct {
+	version int64
+	name    string
+	script  string
+}
+
+// RunMigrations 按版本号顺序应用尚未执行的迁移脚本（*.up.sql）
+// 已应用的版本记录在 schema_migrations 表中；发现 dirty 记录时立即失败，
+// 需要人工修复后重试。返回本次应用的版本号列表。
+func RunMigrations(db *sql.DB, fsys fs.FS) ([]int64, error) {
+	if err := ensureMigrationsTable(db); err != nil {
+		return nil, fmt.Errorf("failed to ensure schema_migrations table: %w", err)
+	}
+
+	if err := checkDirty(db); err != nil {
+		return nil, err
+	}
+
+	appliedSet, err := appliedVersions(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+
+	pending, err := pendingMigrations(fsys, appliedSet)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []int64
+	for _, m := range pending {
+		if err := applyMigration(db, m); err != nil {
+			return applied, fmt.Errorf("migration %d (%s) failed: %w", m.version, m.name, err)
+		}
+		applied = append(applied, m.version)
+	}
+
+	return applied, nil
+}
+
+// ensureMigrationsTable 创建版本记录表（如不存在）
+func ensureMigrationsTable(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			dirty BOOLEAN NOT NULL DEFAULT FALSE,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)`)
+	return err
+}
+
+// checkDirty 检查是否存在应用到一半的迁移，有则立即失败
+func checkDirty(db *sql.DB) error {
+	var version int64
+	err := db.QueryRow(`SELECT version FROM schema_migrations WHERE dirty LIMIT 1`).Scan(&version)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check for dirty migrations: %w", err)
+	}
+	return fmt.Errorf("migration %d is in a dirty state, manual intervention required", version)
+}
+
+// appliedVersions 返回已成功应用的版本集合
+func appliedVersions(db *sql.DB) (map[int64]bool, error) {
+	rows, err := db.Query(`SELECT version FROM schema_migrations WHERE NOT dirty`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]bool)
+	for rows.Next() {
+		var version int64
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+// pendingMigrations 扫描 *.up.sql 脚本，过滤已应用的版本并按版本号排序
+func pendingMigrations(fsys fs.FS, applied map[int64]bool) ([]migration, error) {
+	entries, err := fs.Glob(fsys, "*.up.sql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migration scripts: %w", err)
+	}
+
+	var pending []migration
+	for _, entry := range entries {
+		version, name, err := parseMigrationName(entry)
+		if err != nil {
+			return nil, err
+		}
+		if applied[version] {
+			continue
+		}
+
+		script, err := fs.ReadFile(fsys, entry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry, err)
+		}
+
+		pending = append(pending, migration{
+			version: version,
+			name:    name,
+			script:  string(script),
+		})
+	}
+
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].version < pending[j].version
+	})
+
+	return pending, nil
+}
+
+// parseMigrationName 从形如 000001_create_users_table.up.sql 的文件名解析版本号和名称
+func parseMigrationName(filename string) (int64, string, error) {
+	base := strings.TrimSuffix(filename, ".up.sql")
+	prefix, name, ok := strings.Cut(base, "_")
+	if !ok {
+		return 0, "", fmt.Errorf("invalid migration filename: %s", filename)
+	}
+
+	version, err := strconv.ParseInt(prefix, 10, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid migration version in %s: %w", filename, err)
+	}
+
+	return version, name, nil
+}
+
+// applyMigration 应用单个迁移：先标记 dirty，执行成功后清除
+// 中途失败会留下 dirty 记录，下次启动时 checkDirty 会拒绝继续
+func applyMigration(db *sql.DB, m migration) error {
+	if _, err := db.Exec(`INSERT INTO schema_migrations (version, dirty) VALUES ($1, TRUE)`, m.version); err != nil {
+		return fmt.Errorf("failed to record migration start: %w", err)
+	}
+
+	if _, err := db.Exec(m.script); err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(`UPDATE schema_migrations SET dirty = FALSE WHERE version = $1`, m.version); err != nil {
+		return fmt.Errorf("failed to record migration completion: %w", err)
+	}
+
+	return nil
+}
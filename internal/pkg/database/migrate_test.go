@@ -0,0 +1,118 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestRunMigrationsAppliesAllInOrderOnFreshDB(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	// 两个乱序命名的脚本，应按版本号顺序应用
+	fsys := fstest.MapFS{
+		"000002_create_orders_table.up.sql":  {Data: []byte("CREATE TABLE orders (id UUID)")},
+		"000001_create_users_table.up.sql":   {Data: []byte("CREATE TABLE users (id UUID)")},
+		"000001_create_users_table.down.sql": {Data: []byte("DROP TABLE users")},
+	}
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version FROM schema_migrations WHERE dirty").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("SELECT version FROM schema_migrations WHERE NOT dirty").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}))
+
+	for _, m := range []struct {
+		version int64
+		script  string
+	}{
+		{1, "CREATE TABLE users"},
+		{2, "CREATE TABLE orders"},
+	} {
+		mock.ExpectExec("INSERT INTO schema_migrations").
+			WithArgs(m.version).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(m.script).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("UPDATE schema_migrations SET dirty").
+			WithArgs(m.version).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	applied, err := RunMigrations(db, fsys)
+	if err != nil {
+		t.Fatalf("RunMigrations: %v", err)
+	}
+	if len(applied) != 2 || applied[0] != 1 || applied[1] != 2 {
+		t.Errorf("applied versions = %v, want [1 2]", applied)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unexpected statement order: %v", err)
+	}
+}
+
+func TestRunMigrationsSkipsAlreadyAppliedVersions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	fsys := fstest.MapFS{
+		"000001_create_users_table.up.sql":  {Data: []byte("CREATE TABLE users (id UUID)")},
+		"000002_create_orders_table.up.sql": {Data: []byte("CREATE TABLE orders (id UUID)")},
+	}
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version FROM schema_migrations WHERE dirty").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("SELECT version FROM schema_migrations WHERE NOT dirty").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(int64(1)))
+
+	mock.ExpectExec("INSERT INTO schema_migrations").
+		WithArgs(int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("CREATE TABLE orders").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE schema_migrations SET dirty").
+		WithArgs(int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	applied, err := RunMigrations(db, fsys)
+	if err != nil {
+		t.Fatalf("RunMigrations: %v", err)
+	}
+	if len(applied) != 1 || applied[0] != 2 {
+		t.Errorf("applied versions = %v, want [2]", applied)
+	}
+}
+
+func TestRunMigrationsFailsFastOnDirtyState(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version FROM schema_migrations WHERE dirty").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(int64(3)))
+
+	_, err = RunMigrations(db, fstest.MapFS{})
+	if err == nil {
+		t.Fatal("dirty state accepted, want fail-fast error")
+	}
+	if !strings.Contains(err.Error(), "dirty") {
+		t.Errorf("error = %v, want dirty-state message", err)
+	}
+}
@@ -20,4 +20,4 @@ func NewRedisClient(host string, port int, password string, db int) (*redis.Clie
 	}
 
 	return client, nil
-}
\ No newline at end of file
+}
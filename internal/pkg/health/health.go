@@ -0,0 +1,94 @@
+// Package health 提供就绪探针的 HTTP 处理器
+// /health 保持纯存活检查，/readyz 逐个探测外部依赖（数据库、Redis 等）
+package health
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// defaultCheckTimeout 默认探测超时，避免慢依赖拖垮探针
+const defaultCheckTimeout = 2 * time.Second
+
+// CheckFunc 单个依赖的探测函数，返回 nil 表示就绪
+type CheckFunc func(ctx context.Context) error
+
+// Check 命名的依赖检查
+type Check struct {
+	Name  string
+	Probe CheckFunc
+}
+
+// DatabaseCheck 创建数据库就绪检查
+func DatabaseCheck(db *sql.DB) Check {
+	return Check{
+		Name: "database",
+		Probe: func(ctx context.Context) error {
+			return db.PingContext(ctx)
+		},
+	}
+}
+
+// RedisCheck 创建 Redis 就绪检查
+func RedisCheck(client *redis.Client) Check {
+	return Check{
+		Name: "redis",
+		Probe: func(ctx context.Context) error {
+			return client.Ping(ctx).Err()
+		},
+	}
+}
+
+// checkResult 单个依赖的探测结果
+type checkResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// readyzResponse /readyz 的 JSON 响应体
+type readyzResponse struct {
+	Status string        `json:"status"`
+	Checks []checkResult `json:"checks"`
+}
+
+// ReadyzHandler 创建就绪探针处理器
+// 任一依赖探测失败时返回 503，响应体中列出各依赖的状态
+// timeout 为整个探测的上限，非正数时使用默认值
+func ReadyzHandler(timeout time.Duration, checks ...Check) http.Handler {
+	if timeout <= 0 {
+		timeout = defaultCheckTimeout
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		response := readyzResponse{
+			Status: "ready",
+			Checks: make([]checkResult, 0, len(checks)),
+		}
+		for _, check := range checks {
+			result := checkResult{Name: check.Name, Status: "ok"}
+			if err := check.Probe(ctx); err != nil {
+				result.Status = "failed"
+				result.Error = err.Error()
+				response.Status = "not_ready"
+			}
+			response.Checks = append(response.Checks, result)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if response.Status != "ready" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+		_ = json.NewEncoder(w).Encode(response)
+	})
+}
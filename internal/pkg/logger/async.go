@@ -0,0 +1,111 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// asyncWriteSyncer 异步缓冲的 WriteSyncer 包装器
+// 写入先进入内存缓冲区，缓冲区满或到达刷新间隔时由后台 goroutine 批量落盘
+// Sync 会同步排空缓冲区，保证关闭时不丢日志
+type asyncWriteSyncer struct {
+	mu         sync.Mutex
+	underlying zapcore.WriteSyncer
+	buf        bytes.Buffer
+	bufferSize int
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// newAsyncWriteSyncer 创建异步写入器并启动后台刷新 goroutine
+func newAsyncWriteSyncer(underlying zapcore.WriteSyncer, bufferSize, flushIntervalMs int) *asyncWriteSyncer {
+	if bufferSize <= 0 {
+		bufferSize = 256 * 1024 // 256KB
+	}
+	if flushIntervalMs <= 0 {
+		flushIntervalMs = 1000
+	}
+
+	w := &asyncWriteSyncer{
+		underlying: underlying,
+		bufferSize: bufferSize,
+		done:       make(chan struct{}),
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(flushIntervalMs) * time.Millisecond)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				w.mu.Lock()
+				_ = w.flushLocked()
+				w.mu.Unlock()
+			case <-w.done:
+				return
+			}
+		}
+	}()
+
+	return w
+}
+
+func (w *asyncWriteSyncer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// 缓冲区放不下时先刷新，单条超大日志直接透传
+	if w.buf.Len()+len(p) > w.bufferSize {
+		if err := w.flushLocked(); err != nil {
+			return 0, err
+		}
+	}
+	if len(p) > w.bufferSize {
+		return w.underlying.Write(p)
+	}
+
+	return w.buf.Write(p)
+}
+
+// Sync 同步排空缓冲区并下沉 Sync，保证关闭时日志完整
+func (w *asyncWriteSyncer) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.flushLocked(); err != nil {
+		return err
+	}
+	return w.underlying.Sync()
+}
+
+// flushLocked 把缓冲区内容写入底层输出，调用方必须持有锁
+func (w *asyncWriteSyncer) flushLocked() error {
+	if w.buf.Len() == 0 {
+		return nil
+	}
+
+	_, err := w.underlying.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+// Close 停止后台刷新 goroutine，排空缓冲区并关闭底层写入器（若可关闭）
+func (w *asyncWriteSyncer) Close() error {
+	var err error
+	w.closeOnce.Do(func() {
+		close(w.done)
+		err = w.Sync()
+		if closer, ok := w.underlying.(io.Closer); ok {
+			if closeErr := closer.Close(); err == nil {
+				err = closeErr
+			}
+		}
+	})
+	return err
+}
@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestAsyncWriteSyncerDrainsOnSync(t *testing.T) {
+	var out bytes.Buffer
+	w := newAsyncWriteSyncer(zapcore.AddSync(&out), 1<<20, 60_000)
+	defer w.Close()
+
+	line := strings.Repeat("x", 100) + "\n"
+	for i := 0; i < 50; i++ {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	if err := w.Sync(); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if got, want := out.Len(), 50*len(line); got != want {
+		t.Errorf("underlying received %d bytes after Sync, want %d", got, want)
+	}
+}
+
+func BenchmarkSyncWrite(b *testing.B) {
+	w := zapcore.AddSync(io.Discard)
+	line := []byte(strings.Repeat("x", 200) + "\n")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = w.Write(line)
+		_ = w.Sync()
+	}
+}
+
+func BenchmarkAsyncWrite(b *testing.B) {
+	w := newAsyncWriteSyncer(zapcore.AddSync(io.Discard), 256*1024, 1000)
+	defer w.Close()
+	line := []byte(strings.Repeat("x", 200) + "\n")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = w.Write(line)
+	}
+	b.StopTimer()
+	_ = w.Sync()
+}
+
+func TestAsyncWriteSyncerNoLossOnClose(t *testing.T) {
+	var out bytes.Buffer
+	// 大缓冲加超长刷新间隔：Close 前不会有任何后台刷新发生
+	w := newAsyncWriteSyncer(zapcore.AddSync(&out), 1<<20, 60_000)
+
+	line := strings.Repeat("y", 80) + "\n"
+	for i := 0; i < 1000; i++ {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got, want := out.Len(), 1000*len(line); got != want {
+		t.Errorf("underlying received %d bytes after Close, want %d (log loss)", got, want)
+	}
+
+	// 重复 Close 幂等，不 panic 也不重复写入
+	if err := w.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+	if got, want := out.Len(), 1000*len(line); got != want {
+		t.Errorf("byte count changed after second Close: %d, want %d", got, want)
+	}
+}
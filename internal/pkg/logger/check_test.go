@@ -0,0 +1,125 @@
+package logger
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newLevelledZapLogger(level Level) *zapLogger {
+	z := zap.New(zapcore.NewNopCore())
+	return &zapLogger{
+		zap:         z,
+		sugar:       z.Sugar(),
+		level:       level,
+		atomicLevel: zap.NewAtomicLevelAt(levelToZapLevel(level)),
+	}
+}
+
+func TestCheckRespectsEnabledLevel(t *testing.T) {
+	log := newLevelledZapLogger(InfoLevel)
+
+	if cl, ok := log.Check(DebugLevel); ok || cl != nil {
+		t.Error("Check(Debug) enabled on an info-level logger")
+	}
+	if cl, ok := log.Check(WarnLevel); !ok || cl == nil {
+		t.Error("Check(Warn) disabled on an info-level logger")
+	}
+}
+
+func TestCheckDisabledAllocatesNothing(t *testing.T) {
+	log := newLevelledZapLogger(InfoLevel)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		if _, ok := log.Check(DebugLevel); ok {
+			t.Error("debug unexpectedly enabled")
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("disabled Check allocates %.0f objects per call, want 0", allocs)
+	}
+}
+
+func BenchmarkCheckDisabled(b *testing.B) {
+	log := newLevelledZapLogger(InfoLevel)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if cl, ok := log.Check(DebugLevel); ok {
+			cl.Log("never reached", zap.Int("i", i))
+		}
+	}
+}
+
+// newObservedLevelledLogger 构造给定级别的 zapLogger 并返回可观测的日志输出
+func newObservedLevelledLogger(level Level) (*zapLogger, *observer.ObservedLogs) {
+	atomic := zap.NewAtomicLevelAt(levelToZapLevel(level))
+	core, logs := observer.New(atomic)
+	z := zap.New(core)
+	return &zapLogger{
+		zap:         z,
+		sugar:       z.Sugar(),
+		level:       level,
+		atomicLevel: atomic,
+	}, logs
+}
+
+func TestConditionalLoggersAppearAndVanishPerLevel(t *testing.T) {
+	cases := []struct {
+		name        string
+		loggerLevel Level
+		emit        func(log *zapLogger)
+		want        []zapcore.Level
+	}{
+		{
+			name:        "warn-level logger drops info keeps warn and error",
+			loggerLevel: WarnLevel,
+			emit: func(log *zapLogger) {
+				log.IfInfo().Log("info message")
+				log.IfWarn().Log("warn message")
+				log.IfError().Log("error message")
+			},
+			want: []zapcore.Level{zapcore.WarnLevel, zapcore.ErrorLevel},
+		},
+		{
+			name:        "error-level logger drops warn keeps error",
+			loggerLevel: ErrorLevel,
+			emit: func(log *zapLogger) {
+				log.IfWarn().Log("warn message")
+				log.IfError().Logf("error %s", "formatted")
+			},
+			want: []zapcore.Level{zapcore.ErrorLevel},
+		},
+		{
+			name:        "debug-level logger keeps everything",
+			loggerLevel: DebugLevel,
+			emit: func(log *zapLogger) {
+				log.IfDebug().Log("debug message")
+				log.IfInfo().Log("info message")
+				log.IfWarn().Log("warn message")
+				log.IfError().Log("error message")
+			},
+			want: []zapcore.Level{zapcore.DebugLevel, zapcore.InfoLevel, zapcore.WarnLevel, zapcore.ErrorLevel},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			log, logs := newObservedLevelledLogger(tc.loggerLevel)
+			tc.emit(log)
+
+			entries := logs.All()
+			if len(entries) != len(tc.want) {
+				t.Fatalf("emitted %d entries, want %d: %+v", len(entries), len(tc.want), entries)
+			}
+			for i, entry := range entries {
+				if entry.Level != tc.want[i] {
+					t.Errorf("entries[%d].Level = %v, want %v", i, entry.Level, tc.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package logger
+
+import "context"
+
+// contextKey 日志上下文专用的类型化键
+// 避免 bare string 键触发 go vet 告警并与其他包的上下文键冲突
+type contextKey string
+
+const (
+	requestIDKey contextKey = "request_id"
+	userIDKey    contextKey = "user_id"
+	sessionIDKey contextKey = "session_id"
+	tenantIDKey  contextKey = "tenant_id"
+	traceIDKey   contextKey = "trace_id"
+	spanIDKey    contextKey = "span_id"
+)
+
+// ContextWithRequestID 将请求 ID 存入上下文
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// ContextWithUserID 将用户 ID 存入上下文
+func ContextWithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// ContextWithSessionID 将会话 ID 存入上下文
+func ContextWithSessionID(ctx context.Context, sessionID string) context.Context {
+	return context.WithValue(ctx, sessionIDKey, sessionID)
+}
+
+// ContextWithTenantID 将租户 ID 存入上下文
+func ContextWithTenantID(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantIDKey, tenantID)
+}
+
+// ContextWithTraceID 将追踪 ID 存入上下文
+func ContextWithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey, traceID)
+}
+
+// ContextWithSpanID 将 Span ID 存入上下文
+func ContextWithSpanID(ctx context.Context, spanID string) context.Context {
+	return context.WithValue(ctx, spanIDKey, spanID)
+}
+
+// RequestIDFromContext 从上下文读取请求 ID，未设置时返回空串
+func RequestIDFromContext(ctx context.Context) string {
+	return getContextValue(ctx, string(requestIDKey))
+}
+
+// UserIDFromContext 从上下文读取用户 ID，未设置时返回空串
+func UserIDFromContext(ctx context.Context) string {
+	return getContextValue(ctx, string(userIDKey))
+}
+
+// TenantIDFromContext 从上下文读取租户 ID，未设置时返回空串
+func TenantIDFromContext(ctx context.Context) string {
+	return getContextValue(ctx, string(tenantIDKey))
+}
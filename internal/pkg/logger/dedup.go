@@ -0,0 +1,296 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultDedupWindow 默认的去重时间窗口
+const defaultDedupWindow = 10 * time.Second
+
+// dedupState 派生日志器之间共享的去重状态
+type dedupState struct {
+	mu   sync.Mutex
+	seen map[string]*dedupEntry
+}
+
+// dedupEntry 窗口内的一组重复日志
+type dedupEntry struct {
+	level  Level
+	msg    string
+	fields []zap.Field
+	count  int
+	timer  *time.Timer
+}
+
+// DedupLogger 去重日志器，在时间窗口内折叠重复的 Warn/Error 日志
+// 相同（级别+消息+error_code）的日志只有首条立即输出，
+// 窗口结束时补一条带 occurrences 计数的汇总行，防止下游故障时的日志风暴
+// Debug/Info/Fatal 不参与去重，直接透传给内部日志器
+type DedupLogger struct {
+	inner  Logger
+	window time.Duration
+	state  *dedupState
+}
+
+// NewDedupLogger 创建去重日志器，包装任意 Logger 实现
+// window 小于等于 0 时使用默认窗口 10 秒
+func NewDedupLogger(inner Logger, window time.Duration) *DedupLogger {
+	if window <= 0 {
+		window = defaultDedupWindow
+	}
+	return &DedupLogger{
+		inner:  inner,
+		window: window,
+		state: &dedupState{
+			seen: make(map[string]*dedupEntry),
+		},
+	}
+}
+
+// dedupKey 构造去重键：级别 + 消息 + error_code 字段
+// 只取少量稳定字段参与键，request_id 等逐请求变化的字段不影响去重
+func dedupKey(level Level, msg string, fields []zap.Field) string {
+	errorCode := ""
+	for _, field := range fields {
+		if field.Key == "error_code" && field.Type == zapcore.StringType {
+			errorCode = field.String
+			break
+		}
+	}
+	return fmt.Sprintf("%d|%s|%s", level, msg, errorCode)
+}
+
+// shouldLog 判断是否应立即输出该日志，重复日志只累计计数
+func (l *DedupLogger) shouldLog(level Level, msg string, fields []zap.Field) bool {
+	key := dedupKey(level, msg, fields)
+
+	l.state.mu.Lock()
+	defer l.state.mu.Unlock()
+
+	if entry, ok := l.state.seen[key]; ok {
+		entry.count++
+		return false
+	}
+
+	l.state.seen[key] = &dedupEntry{
+		level:  level,
+		msg:    msg,
+		fields: fields,
+		count:  1,
+		timer: time.AfterFunc(l.window, func() {
+			l.flushKey(key)
+		}),
+	}
+	return true
+}
+
+// flushKey 窗口结束时输出汇总行并移除去重键
+func (l *DedupLogger) flushKey(key string) {
+	l.state.mu.Lock()
+	entry, ok := l.state.seen[key]
+	delete(l.state.seen, key)
+	l.state.mu.Unlock()
+
+	if !ok || entry.count <= 1 {
+		return
+	}
+	l.emitSummary(entry)
+}
+
+// emitSummary 输出带计数的汇总行
+func (l *DedupLogger) emitSummary(entry *dedupEntry) {
+	fields := make([]zap.Field, 0, len(entry.fields)+2)
+	fields = append(fields, entry.fields...)
+	fields = append(fields,
+		zap.Int("occurrences", entry.count),
+		zap.Duration("dedup_window", l.window),
+	)
+
+	switch entry.level {
+	case WarnLevel:
+		l.inner.Warn(entry.msg, fields...)
+	case ErrorLevel:
+		l.inner.Error(entry.msg, fields...)
+	}
+}
+
+// Flush 立即输出所有待汇总的重复日志，停止对应的定时器
+func (l *DedupLogger) Flush() {
+	l.state.mu.Lock()
+	entries := make([]*dedupEntry, 0, len(l.state.seen))
+	for key, entry := range l.state.seen {
+		entry.timer.Stop()
+		entries = append(entries, entry)
+		delete(l.state.seen, key)
+	}
+	l.state.mu.Unlock()
+
+	for _, entry := range entries {
+		if entry.count > 1 {
+			l.emitSummary(entry)
+		}
+	}
+}
+
+// Debug 记录调试日志（不去重）
+func (l *DedupLogger) Debug(msg string, fields ...zap.Field) {
+	l.inner.Debug(msg, fields...)
+}
+
+// Info 记录信息日志（不去重）
+func (l *DedupLogger) Info(msg string, fields ...zap.Field) {
+	l.inner.Info(msg, fields...)
+}
+
+// Warn 记录警告日志，窗口内重复日志被折叠
+func (l *DedupLogger) Warn(msg string, fields ...zap.Field) {
+	if l.shouldLog(WarnLevel, msg, fields) {
+		l.inner.Warn(msg, fields...)
+	}
+}
+
+// Error 记录错误日志，窗口内重复日志被折叠
+func (l *DedupLogger) Error(msg string, fields ...zap.Field) {
+	if l.shouldLog(ErrorLevel, msg, fields) {
+		l.inner.Error(msg, fields...)
+	}
+}
+
+// Fatal 记录致命错误日志（不去重，内部日志器可能随即退出进程）
+func (l *DedupLogger) Fatal(msg string, fields ...zap.Field) {
+	l.inner.Fatal(msg, fields...)
+}
+
+// Debugf 记录 Printf 风格的调试日志（不去重）
+func (l *DedupLogger) Debugf(format string, args ...any) {
+	l.inner.Debugf(format, args...)
+}
+
+// Infof 记录 Printf 风格的信息日志（不去重）
+func (l *DedupLogger) Infof(format string, args ...any) {
+	l.inner.Infof(format, args...)
+}
+
+// Warnf 记录 Printf 风格的警告日志，窗口内重复日志被折叠
+func (l *DedupLogger) Warnf(format string, args ...any) {
+	l.Warn(fmt.Sprintf(format, args...))
+}
+
+// Errorf 记录 Printf 风格的错误日志，窗口内重复日志被折叠
+func (l *DedupLogger) Errorf(format string, args ...any) {
+	l.Error(fmt.Sprintf(format, args...))
+}
+
+// DebugContext 记录带上下文的调试日志（不去重）
+func (l *DedupLogger) DebugContext(ctx context.Context, msg string, fields ...zap.Field) {
+	l.inner.DebugContext(ctx, msg, fields...)
+}
+
+// InfoContext 记录带上下文的信息日志（不去重）
+func (l *DedupLogger) InfoContext(ctx context.Context, msg string, fields ...zap.Field) {
+	l.inner.InfoContext(ctx, msg, fields...)
+}
+
+// WarnContext 记录带上下文的警告日志，窗口内重复日志被折叠
+func (l *DedupLogger) WarnContext(ctx context.Context, msg string, fields ...zap.Field) {
+	if l.shouldLog(WarnLevel, msg, fields) {
+		l.inner.WarnContext(ctx, msg, fields...)
+	}
+}
+
+// ErrorContext 记录带上下文的错误日志，窗口内重复日志被折叠
+func (l *DedupLogger) ErrorContext(ctx context.Context, msg string, fields ...zap.Field) {
+	if l.shouldLog(ErrorLevel, msg, fields) {
+		l.inner.ErrorContext(ctx, msg, fields...)
+	}
+}
+
+// WithFields 创建带有额外字段的日志器，共享去重状态
+func (l *DedupLogger) WithFields(fields ...zap.Field) Logger {
+	return &DedupLogger{
+		inner:  l.inner.WithFields(fields...),
+		window: l.window,
+		state:  l.state,
+	}
+}
+
+// WithContext 创建带有上下文的日志器，共享去重状态
+func (l *DedupLogger) WithContext(ctx context.Context) Logger {
+	return &DedupLogger{
+		inner:  l.inner.WithContext(ctx),
+		window: l.window,
+		state:  l.state,
+	}
+}
+
+// WithService 创建带有服务名的日志器，共享去重状态
+func (l *DedupLogger) WithService(service string) Logger {
+	return &DedupLogger{
+		inner:  l.inner.WithService(service),
+		window: l.window,
+		state:  l.state,
+	}
+}
+
+// IfDebug 返回条件调试日志器
+func (l *DedupLogger) IfDebug() ConditionalLogger {
+	if _, ok := l.inner.Check(DebugLevel); ok {
+		return &conditionalLogger{logger: l, level: DebugLevel}
+	}
+	return &noopConditionalLogger{}
+}
+
+// IfInfo 返回条件信息日志器
+func (l *DedupLogger) IfInfo() ConditionalLogger {
+	if _, ok := l.inner.Check(InfoLevel); ok {
+		return &conditionalLogger{logger: l, level: InfoLevel}
+	}
+	return &noopConditionalLogger{}
+}
+
+// IfWarn 返回条件警告日志器
+func (l *DedupLogger) IfWarn() ConditionalLogger {
+	if _, ok := l.inner.Check(WarnLevel); ok {
+		return &conditionalLogger{logger: l, level: WarnLevel}
+	}
+	return &noopConditionalLogger{}
+}
+
+// IfError 返回条件错误日志器
+func (l *DedupLogger) IfError() ConditionalLogger {
+	if _, ok := l.inner.Check(ErrorLevel); ok {
+		return &conditionalLogger{logger: l, level: ErrorLevel}
+	}
+	return &noopConditionalLogger{}
+}
+
+// Check 检查级别是否启用，启用时返回绑定到该级别的条件日志器
+func (l *DedupLogger) Check(level Level) (ConditionalLogger, bool) {
+	if _, ok := l.inner.Check(level); !ok {
+		return nil, false
+	}
+	return &conditionalLogger{logger: l, level: level}, true
+}
+
+// SetLevel 设置日志级别
+func (l *DedupLogger) SetLevel(level Level) {
+	l.inner.SetLevel(level)
+}
+
+// Sync 输出待汇总的重复日志后同步内部日志器
+func (l *DedupLogger) Sync() error {
+	l.Flush()
+	return l.inner.Sync()
+}
+
+// Close 输出待汇总的重复日志后关闭内部日志器
+func (l *DedupLogger) Close() error {
+	l.Flush()
+	return l.inner.Close()
+}
@@ -0,0 +1,75 @@
+package logger
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestDedupLoggerCollapsesIdenticalErrors(t *testing.T) {
+	mem := NewMemoryLogger()
+	log := NewDedupLogger(mem, time.Minute)
+
+	// 模拟下游故障时的日志风暴：1000 条完全相同的错误
+	for i := 0; i < 1000; i++ {
+		log.Error("order service unavailable",
+			ErrorCode("ORDER_UNAVAILABLE"),
+			RequestID("req-varies"),
+		)
+	}
+	log.Flush()
+
+	entries := mem.EntriesAtLevel(ErrorLevel)
+	if len(entries) != 2 {
+		t.Fatalf("1000 identical errors produced %d lines, want first line + summary", len(entries))
+	}
+	summary := entries[1]
+	if !summary.FieldEquals("occurrences", int64(1000)) {
+		t.Errorf("summary fields = %v, want occurrences=1000", summary.FieldMap())
+	}
+	if !summary.HasField("dedup_window") {
+		t.Errorf("summary fields = %v, want dedup_window attached", summary.FieldMap())
+	}
+}
+
+func TestDedupLoggerKeysOnLevelMessageAndErrorCode(t *testing.T) {
+	mem := NewMemoryLogger()
+	log := NewDedupLogger(mem, time.Minute)
+
+	log.Error("downstream failed", ErrorCode("ORDER_UNAVAILABLE"))
+	log.Error("downstream failed", ErrorCode("USER_UNAVAILABLE"))
+	log.Warn("downstream failed", ErrorCode("ORDER_UNAVAILABLE"))
+	log.Error("downstream failed", ErrorCode("ORDER_UNAVAILABLE"))
+	log.Flush()
+
+	// 不同 error_code 和不同级别各算一组，只有第四条被折叠进第一组
+	if got := len(mem.EntriesAtLevel(WarnLevel)); got != 1 {
+		t.Errorf("warn entries = %d, want 1", got)
+	}
+	errors := mem.EntriesAtLevel(ErrorLevel)
+	if len(errors) != 3 {
+		t.Fatalf("error entries = %d, want 2 distinct groups + 1 summary", len(errors))
+	}
+	if !errors[2].FieldEquals("occurrences", int64(2)) {
+		t.Errorf("summary fields = %v, want occurrences=2", errors[2].FieldMap())
+	}
+}
+
+func TestDedupLoggerPassesThroughInfoAndSingletons(t *testing.T) {
+	mem := NewMemoryLogger()
+	log := NewDedupLogger(mem, time.Minute)
+
+	log.Info("request started", zap.Int("attempt", 1))
+	log.Info("request started", zap.Int("attempt", 2))
+	log.Error("one-off failure", ErrorCode("ONE_OFF"))
+	log.Flush()
+
+	// Info 不参与去重；窗口内只出现一次的错误不补汇总行
+	if got := len(mem.EntriesAtLevel(InfoLevel)); got != 2 {
+		t.Errorf("info entries = %d, want 2 passed through", got)
+	}
+	if got := len(mem.EntriesAtLevel(ErrorLevel)); got != 1 {
+		t.Errorf("error entries = %d, want 1 without summary", got)
+	}
+}
@@ -40,18 +40,18 @@ func DefaultLoggerConfig() LoggerConfig {
 func (f *LoggerFactory) CreateLogger(config LoggerConfig) (Logger, error) {
 	// 合并默认配置
 	mergedConfig := f.mergeWithDefaults(config)
-	
+
 	// 根据配置选择创建方式
 	if mergedConfig.Tracing.Enabled {
 		// 启用追踪的日志器
 		return CreateTracedLogger(mergedConfig)
 	}
-	
+
 	if hasMultipleOutputs(mergedConfig) {
 		// 多输出日志器
 		return CreateLoggerWithOutputs(mergedConfig)
 	}
-	
+
 	// 基础日志器
 	return NewLogger(mergedConfig)
 }
@@ -105,7 +105,7 @@ func (f *LoggerFactory) CreateTracedServiceLogger(serviceName, version, environm
 // mergeWithDefaults 合并默认配置
 func (f *LoggerFactory) mergeWithDefaults(config LoggerConfig) LoggerConfig {
 	result := f.defaultConfig
-	
+
 	// 覆盖非零值
 	if config.Level != 0 {
 		result.Level = config.Level
@@ -122,7 +122,7 @@ func (f *LoggerFactory) mergeWithDefaults(config LoggerConfig) LoggerConfig {
 	if config.Environment != "" {
 		result.Environment = config.Environment
 	}
-	
+
 	// 合并输出配置
 	if config.Output.Console.Enabled {
 		result.Output.Console = config.Output.Console
@@ -133,12 +133,17 @@ func (f *LoggerFactory) mergeWithDefaults(config LoggerConfig) LoggerConfig {
 	if config.Output.Remote.Enabled {
 		result.Output.Remote = config.Output.Remote
 	}
-	
+
 	// 合并追踪配置
 	if config.Tracing.Enabled {
 		result.Tracing = config.Tracing
 	}
-	
+
+	// 合并堆栈跟踪模式（默认 StacktraceManual 为零值，非零时覆盖）
+	if config.Stacktrace != StacktraceManual {
+		result.Stacktrace = config.Stacktrace
+	}
+
 	return result
 }
 
@@ -172,7 +177,7 @@ func CreateTracedServiceLogger(serviceName, version, environment string) (Logger
 	return defaultFactory.CreateTracedServiceLogger(serviceName, version, environment)
 }
 
-// 兼容性函数 - 保持与现有 observability.NewLogger 的兼容性
+// 兼容性函数 - 替代已移除的 observability 裸 zap 日志器，返回统一的 Logger 接口
 
 // NewLoggerCompat 兼容现有的 NewLogger API
 func NewLoggerCompat(level, format string) (Logger, error) {
@@ -183,7 +188,7 @@ func NewLoggerCompat(level, format string) (Logger, error) {
 	return CreateLogger(config)
 }
 
-// NewLoggerFromEnvCompat 兼容现有的 NewLoggerFromEnv API  
+// NewLoggerFromEnvCompat 兼容现有的 NewLoggerFromEnv API
 func NewLoggerFromEnvCompat() (Logger, error) {
 	level := os.Getenv("LOG_LEVEL")
 	if level == "" {
@@ -245,6 +250,8 @@ func ProductionLoggerConfig(serviceName string) LoggerConfig {
 			},
 		},
 		Tracing: TracingConfig{Enabled: true},
+		// 生产环境只在 Error 及以上级别由 zap 采集堆栈，避免逐条手动采集的开销
+		Stacktrace: StacktraceZap,
 	}
 }
 
@@ -260,6 +267,8 @@ func DevelopmentLoggerConfig(serviceName string) LoggerConfig {
 			Console: ConsoleOutputConfig{Enabled: true},
 		},
 		Tracing: TracingConfig{Enabled: false},
+		// 开发环境保留每条错误日志的手动堆栈，便于排查
+		Stacktrace: StacktraceManual,
 	}
 }
 
@@ -275,6 +284,25 @@ func TestLoggerConfig(serviceName string) LoggerConfig {
 			Console: ConsoleOutputConfig{Enabled: true},
 		},
 		Tracing: TracingConfig{Enabled: false},
+		// 测试环境完全关闭堆栈跟踪，保持输出紧凑
+		Stacktrace: StacktraceDisabled,
+	}
+}
+
+// ConfigForEnvironment 根据环境名返回对应的预设配置
+// 未识别的环境名按开发环境处理，但保留原始环境标签
+func ConfigForEnvironment(serviceName, environment string) LoggerConfig {
+	switch environment {
+	case "production", "prod":
+		return ProductionLoggerConfig(serviceName)
+	case "development", "dev":
+		return DevelopmentLoggerConfig(serviceName)
+	case "test", "testing":
+		return TestLoggerConfig(serviceName)
+	default:
+		config := DevelopmentLoggerConfig(serviceName)
+		config.Environment = environment
+		return config
 	}
 }
 
@@ -291,21 +319,7 @@ func MustCreateLogger(config LoggerConfig) Logger {
 
 // MustCreateServiceLogger 创建服务日志器，失败时 panic
 func MustCreateServiceLogger(serviceName, environment string) Logger {
-	var config LoggerConfig
-	
-	switch environment {
-	case "production", "prod":
-		config = ProductionLoggerConfig(serviceName)
-	case "development", "dev":
-		config = DevelopmentLoggerConfig(serviceName)
-	case "test", "testing":
-		config = TestLoggerConfig(serviceName)
-	default:
-		config = DevelopmentLoggerConfig(serviceName)
-		config.Environment = environment
-	}
-	
-	return MustCreateLogger(config)
+	return MustCreateLogger(ConfigForEnvironment(serviceName, environment))
 }
 
 // QuickLogger 快速创建日志器（用于简单场景）
@@ -331,17 +345,17 @@ func CreateLoggerWithBuildInfo(buildInfo BuildInfo) (Logger, error) {
 		Version:     buildInfo.Version,
 		Environment: buildInfo.Environment,
 	}
-	
+
 	logger, err := CreateLogger(config)
 	if err != nil {
 		return nil, err
 	}
-	
+
 	// 添加构建信息字段
 	buildFields := []zap.Field{
 		zap.String("build_time", buildInfo.BuildTime),
 		zap.String("git_commit", buildInfo.GitCommit),
 	}
-	
+
 	return logger.WithFields(buildFields...), nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,35 @@
+package logger
+
+import "testing"
+
+func TestPresetStacktraceModes(t *testing.T) {
+	cases := []struct {
+		name   string
+		config LoggerConfig
+		want   StacktraceMode
+	}{
+		{"production uses zap error-level stacks", ProductionLoggerConfig("svc"), StacktraceZap},
+		{"development keeps manual stacks", DevelopmentLoggerConfig("svc"), StacktraceManual},
+		{"test disables stacks entirely", TestLoggerConfig("svc"), StacktraceDisabled},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.config.Stacktrace != tc.want {
+				t.Errorf("Stacktrace = %v, want %v", tc.config.Stacktrace, tc.want)
+			}
+		})
+	}
+}
+
+func TestStacktraceOptionsOnlyForZapMode(t *testing.T) {
+	if opts := stacktraceOptions(StacktraceZap); len(opts) != 1 {
+		t.Errorf("StacktraceZap options = %d, want 1 (AddStacktrace)", len(opts))
+	}
+	if opts := stacktraceOptions(StacktraceManual); len(opts) != 0 {
+		t.Errorf("StacktraceManual options = %d, want 0", len(opts))
+	}
+	if opts := stacktraceOptions(StacktraceDisabled); len(opts) != 0 {
+		t.Errorf("StacktraceDisabled options = %d, want 0", len(opts))
+	}
+}
@@ -1,10 +1,16 @@
 package logger
 
 import (
+	"errors"
+	"fmt"
+	"reflect"
 	"regexp"
 	"strings"
+	"time"
 
+	"connectrpc.com/connect"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 // 敏感数据检测和脱敏 - 这是我们的核心增值功能
@@ -40,6 +46,9 @@ var (
 
 	// 自定义敏感字段列表
 	customSensitiveFields = make(map[string]bool)
+
+	// 允许字段列表，命中时豁免脱敏，优先级高于敏感模式
+	allowedFields = make(map[string]bool)
 )
 
 // AddSensitiveField 添加自定义敏感字段
@@ -52,43 +61,151 @@ func RemoveSensitiveField(fieldName string) {
 	delete(customSensitiveFields, strings.ToLower(fieldName))
 }
 
+// AddAllowedField 添加允许字段，豁免脱敏
+// 用于 author、public_key_id 等误中敏感子串模式（auth、key）的合法字段
+func AddAllowedField(fieldName string) {
+	allowedFields[strings.ToLower(fieldName)] = true
+}
+
+// RemoveAllowedField 移除允许字段
+func RemoveAllowedField(fieldName string) {
+	delete(allowedFields, strings.ToLower(fieldName))
+}
+
 // SanitizeFields 对 zap 字段进行敏感数据脱敏
+// 字段名敏感时整体替换为 [REDACTED]，字符串字段值命中敏感模式时按模式脱敏
+// zap.Object / zap.Any 等复合字段会递归遍历，脱敏嵌套的敏感子键和字符串叶子值
 func SanitizeFields(fields []zap.Field) []zap.Field {
 	result := make([]zap.Field, 0, len(fields))
-	
+
 	for _, field := range fields {
 		// 检查字段名是否敏感
 		if isSensitiveField(field.Key) {
 			result = append(result, zap.String(field.Key, "[REDACTED]"))
 			continue
 		}
-		
-		// 对字符串字段检查敏感值 - 这里简化处理，实际应该检查字段类型
-		// 但 zap.Field 的内部结构比较复杂，为了简化我们只处理字段名
-		// 在实际使用中，敏感数据主要通过字段名识别
-		
-		result = append(result, field)
+
+		// 检查字符串字段值中嵌入的敏感信息（信用卡号、手机号等）
+		if field.Type == zapcore.StringType && containsSensitiveValue(field.String) {
+			result = append(result, zap.String(field.Key, sanitizeString(field.String)))
+			continue
+		}
+
+		// 复合字段递归遍历，其余类型走快速路径原样保留
+		switch field.Type {
+		case zapcore.ObjectMarshalerType, zapcore.InlineMarshalerType:
+			result = append(result, sanitizeObjectField(field))
+		case zapcore.ReflectType:
+			result = append(result, zap.Any(field.Key, sanitizeValue(field.Interface)))
+		default:
+			result = append(result, field)
+		}
 	}
-	
+
 	return result
 }
 
+// sanitizeObjectField 将 ObjectMarshaler 字段物化为 map 后递归脱敏
+// 物化失败时保守处理，整体替换为 [REDACTED] 避免敏感数据泄漏
+func sanitizeObjectField(field zap.Field) zap.Field {
+	enc := zapcore.NewMapObjectEncoder()
+	if err := field.Interface.(zapcore.ObjectMarshaler).MarshalLogObject(enc); err != nil {
+		return zap.String(field.Key, "[REDACTED]")
+	}
+	return zap.Any(field.Key, sanitizeMap(enc.Fields))
+}
+
+// sanitizeMap 递归脱敏 map，敏感子键整体替换为 [REDACTED]
+func sanitizeMap(m map[string]any) map[string]any {
+	result := make(map[string]any, len(m))
+	for key, value := range m {
+		if isSensitiveField(key) {
+			result[key] = "[REDACTED]"
+			continue
+		}
+		result[key] = sanitizeValue(value)
+	}
+	return result
+}
+
+// sanitizeValue 递归脱敏任意值：字符串叶子按模式脱敏，
+// map / 结构体 / 切片继续下钻，其余类型原样返回
+func sanitizeValue(value any) any {
+	switch v := value.(type) {
+	case string:
+		if containsSensitiveValue(v) {
+			return sanitizeString(v)
+		}
+		return v
+	case map[string]any:
+		return sanitizeMap(v)
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return value
+		}
+		return sanitizeValue(rv.Elem().Interface())
+	case reflect.Struct:
+		result := make(map[string]any, rv.NumField())
+		rt := rv.Type()
+		for i := 0; i < rv.NumField(); i++ {
+			structField := rt.Field(i)
+			if !structField.IsExported() {
+				continue
+			}
+			if isSensitiveField(structField.Name) {
+				result[structField.Name] = "[REDACTED]"
+				continue
+			}
+			result[structField.Name] = sanitizeValue(rv.Field(i).Interface())
+		}
+		return result
+	case reflect.Slice, reflect.Array:
+		result := make([]any, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			result[i] = sanitizeValue(rv.Index(i).Interface())
+		}
+		return result
+	case reflect.Map:
+		result := make(map[string]any, rv.Len())
+		for _, key := range rv.MapKeys() {
+			keyStr := fmt.Sprintf("%v", key.Interface())
+			if isSensitiveField(keyStr) {
+				result[keyStr] = "[REDACTED]"
+				continue
+			}
+			result[keyStr] = sanitizeValue(rv.MapIndex(key).Interface())
+		}
+		return result
+	}
+
+	return value
+}
+
 // isSensitiveField 检查字段名是否敏感
 func isSensitiveField(key string) bool {
 	lowerKey := strings.ToLower(key)
-	
+
+	// 允许列表优先级最高，命中时豁免脱敏
+	if allowedFields[lowerKey] {
+		return false
+	}
+
 	// 检查自定义敏感字段列表
 	if customSensitiveFields[lowerKey] {
 		return true
 	}
-	
+
 	// 检查预定义模式
 	for _, pattern := range sensitiveFieldPatterns {
 		if pattern.MatchString(lowerKey) {
 			return true
 		}
 	}
-	
+
 	return false
 }
 
@@ -105,29 +222,29 @@ func containsSensitiveValue(value string) bool {
 // sanitizeString 对字符串进行脱敏处理
 func sanitizeString(input string) string {
 	result := input
-	
+
 	// 脱敏信用卡号 - 只显示前4位和后4位
 	result = regexp.MustCompile(`\b(\d{4})[-\s]?\d{4}[-\s]?\d{4}[-\s]?(\d{4})\b`).
 		ReplaceAllString(result, "$1-****-****-$2")
-	
+
 	// 脱敏身份证号 - 只显示前3位和后2位
 	result = regexp.MustCompile(`\b(\d{3})\d{12}(\d{2})\b`).
 		ReplaceAllString(result, "$1***********$2")
 	result = regexp.MustCompile(`\b(\d{3})\d{11}(\d[\dXx])\b`).
 		ReplaceAllString(result, "$1***********$2")
-	
+
 	// 脱敏邮箱 - 只显示用户名前2位和域名
 	result = regexp.MustCompile(`\b([A-Za-z0-9._%+-]{1,2})[A-Za-z0-9._%+-]*(@[A-Za-z0-9.-]+\.[A-Z|a-z]{2,})\b`).
 		ReplaceAllString(result, "$1****$2")
-	
+
 	// 脱敏手机号 - 只显示前3位和后4位
 	result = regexp.MustCompile(`\b(1[3-9]\d)(\d{4})(\d{4})\b`).
 		ReplaceAllString(result, "$1****$3")
-	
+
 	// 脱敏 JWT Token 和 API Key - 只显示前8位
 	result = regexp.MustCompile(`\b([A-Za-z0-9_-]{8})[A-Za-z0-9_-]{24,}\b`).
 		ReplaceAllString(result, "$1****")
-	
+
 	return result
 }
 
@@ -143,6 +260,11 @@ func UserID(value string) zap.Field {
 	return zap.String("user_id", value)
 }
 
+// Email 创建邮箱字段
+func Email(value string) zap.Field {
+	return zap.String("email", value)
+}
+
 // TraceID 创建追踪ID字段
 func TraceID(value string) zap.Field {
 	return zap.String("trace_id", value)
@@ -168,6 +290,40 @@ func ErrorCode(value string) zap.Field {
 	return zap.String("error_code", value)
 }
 
+// Err 创建错误字段，*connect.Error 会同时展开 error_code 和 error_message
+// 替代 zap.Error(err) 加手动 ErrorCode(...) 的组合
+func Err(err error) zap.Field {
+	if err == nil {
+		return zap.Skip()
+	}
+
+	var connectErr *connect.Error
+	if errors.As(err, &connectErr) {
+		return zap.Inline(zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			enc.AddString("error", connectErr.Error())
+			enc.AddString("error_code", connectErr.Code().String())
+			enc.AddString("error_message", connectErr.Message())
+			return nil
+		}))
+	}
+	return zap.Error(err)
+}
+
+// Stack 创建调用方堆栈字段，仅在真正写日志时才有采集开销
+func Stack() zap.Field {
+	return zap.Stack("stack_trace")
+}
+
+// Duration 创建时长字段
+func Duration(name string, d time.Duration) zap.Field {
+	return zap.Duration(name, d)
+}
+
+// Count 创建计数字段
+func Count(name string, n int) zap.Field {
+	return zap.Int(name, n)
+}
+
 // Latency 创建延迟字段（毫秒）
 func Latency(ms int64) zap.Field {
 	return zap.Int64("latency_ms", ms)
@@ -176,4 +332,4 @@ func Latency(ms int64) zap.Field {
 // StatusCode 创建HTTP状态码字段
 func StatusCode(code int) zap.Field {
 	return zap.Int("status_code", code)
-}
\ No newline at end of file
+}
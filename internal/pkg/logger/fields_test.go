@@ -0,0 +1,238 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"connectrpc.com/connect"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSanitizeFieldsRedactsByFieldName(t *testing.T) {
+	fields := SanitizeFields([]zap.Field{
+		zap.String("password", "hunter2"),
+		zap.String("api_token", "secret-token"),
+		zap.String("username", "alice"),
+	})
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+
+	if enc.Fields["password"] != "[REDACTED]" {
+		t.Errorf("password = %v, want [REDACTED]", enc.Fields["password"])
+	}
+	if enc.Fields["api_token"] != "[REDACTED]" {
+		t.Errorf("api_token = %v, want [REDACTED]", enc.Fields["api_token"])
+	}
+	if enc.Fields["username"] != "alice" {
+		t.Errorf("username = %v, want untouched", enc.Fields["username"])
+	}
+}
+
+func TestSanitizeFieldsMasksEmbeddedCardNumber(t *testing.T) {
+	fields := SanitizeFields([]zap.Field{
+		zap.String("note", "paid with card 4111-1111-1111-1234 today"),
+	})
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+
+	note, _ := enc.Fields["note"].(string)
+	if strings.Contains(note, "1111-1111") {
+		t.Errorf("note still contains full card number: %q", note)
+	}
+	if !strings.Contains(note, "4111-****-****-1234") {
+		t.Errorf("note = %q, want masked card number", note)
+	}
+}
+
+func TestEnhanceFieldsAppliesRedactionWhenEnabled(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	z := zap.New(core)
+	log := &zapLogger{
+		zap:         z,
+		sugar:       z.Sugar(),
+		level:       DebugLevel,
+		redact:      true,
+		atomicLevel: zap.NewAtomicLevelAt(zapcore.DebugLevel),
+	}
+
+	log.Info("login attempt", zap.String("password", "hunter2"))
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	if got := entries[0].ContextMap()["password"]; got != "[REDACTED]" {
+		t.Errorf("password = %v, want [REDACTED]", got)
+	}
+}
+
+func TestEnhanceFieldsLeavesFieldsAloneWhenDisabled(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	z := zap.New(core)
+	log := &zapLogger{
+		zap:         z,
+		sugar:       z.Sugar(),
+		level:       DebugLevel,
+		atomicLevel: zap.NewAtomicLevelAt(zapcore.DebugLevel),
+	}
+
+	log.Info("login attempt", zap.String("password", "hunter2"))
+
+	if got := logs.All()[0].ContextMap()["password"]; got != "hunter2" {
+		t.Errorf("password = %v, want hunter2 with redaction disabled", got)
+	}
+}
+
+// fieldMap 把字段编码为 map 便于断言
+func fieldMap(fields ...zap.Field) map[string]interface{} {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+	return enc.Fields
+}
+
+func TestErrDecomposesConnectError(t *testing.T) {
+	connectErr := connect.NewError(connect.CodeNotFound, errors.New("user not found"))
+
+	got := fieldMap(Err(connectErr))
+	if got["error_code"] != "not_found" {
+		t.Errorf("error_code = %v, want not_found", got["error_code"])
+	}
+	if got["error_message"] != "user not found" {
+		t.Errorf("error_message = %v, want the wrapped message", got["error_message"])
+	}
+	if msg, _ := got["error"].(string); !strings.Contains(msg, "user not found") {
+		t.Errorf("error = %v, want the full error text", got["error"])
+	}
+}
+
+func TestErrPlainAndNilErrors(t *testing.T) {
+	got := fieldMap(Err(errors.New("disk full")))
+	if got["error"] != "disk full" {
+		t.Errorf("plain error field = %v, want disk full", got["error"])
+	}
+	if _, ok := got["error_code"]; ok {
+		t.Error("plain error should not carry error_code")
+	}
+
+	if got := fieldMap(Err(nil)); len(got) != 0 {
+		t.Errorf("Err(nil) emitted fields %v, want none", got)
+	}
+}
+
+func TestErrUnwrapsToConnectError(t *testing.T) {
+	// 经过 fmt.Errorf 包装后仍能识别出 connect.Error
+	wrapped := fmt.Errorf("calling downstream: %w",
+		connect.NewError(connect.CodeUnavailable, errors.New("dial tcp: refused")))
+
+	got := fieldMap(Err(wrapped))
+	if got["error_code"] != "unavailable" {
+		t.Errorf("error_code = %v, want unavailable from the wrapped connect.Error", got["error_code"])
+	}
+}
+
+func TestSanitizeFieldsRedactsNestedAnyStruct(t *testing.T) {
+	type credentials struct {
+		Username string
+		Password string
+	}
+	type signupPayload struct {
+		Email string
+		Creds credentials
+	}
+
+	fields := SanitizeFields([]zap.Field{
+		zap.Any("payload", signupPayload{
+			Email: "a@b.com",
+			Creds: credentials{Username: "alice", Password: "hunter2"},
+		}),
+	})
+
+	payload, ok := fieldMap(fields...)["payload"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("payload field is not a map: %v", fieldMap(fields...)["payload"])
+	}
+	creds, ok := payload["Creds"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Creds sub-field is not a map: %v", payload["Creds"])
+	}
+	if creds["Password"] != "[REDACTED]" {
+		t.Errorf("nested Password = %v, want [REDACTED]", creds["Password"])
+	}
+	if creds["Username"] != "alice" {
+		t.Errorf("nested Username = %v, want untouched", creds["Username"])
+	}
+	// 字符串叶子同样过一遍模式脱敏，邮箱会被掩码
+	if email, _ := payload["Email"].(string); !strings.Contains(email, "****") {
+		t.Errorf("Email = %v, want masked by the value patterns", payload["Email"])
+	}
+}
+
+func TestSanitizeFieldsWalksObjectMarshalerAndMaps(t *testing.T) {
+	fields := SanitizeFields([]zap.Field{
+		zap.Any("request", map[string]interface{}{
+			"token": "abc123",
+			"body": map[string]interface{}{
+				"card": "4111 1111 1111 1111",
+			},
+		}),
+	})
+
+	request := fieldMap(fields...)["request"].(map[string]interface{})
+	if request["token"] != "[REDACTED]" {
+		t.Errorf("token = %v, want [REDACTED]", request["token"])
+	}
+	body := request["body"].(map[string]interface{})
+	card, _ := body["card"].(string)
+	if strings.Contains(card, "1111 1111") {
+		t.Errorf("card = %q, want the embedded card number masked", card)
+	}
+}
+
+func TestAllowedFieldsExemptFromRedaction(t *testing.T) {
+	AddAllowedField("author")
+	AddAllowedField("public_key_id")
+	defer func() {
+		RemoveAllowedField("author")
+		RemoveAllowedField("public_key_id")
+	}()
+
+	got := fieldMap(SanitizeFields([]zap.Field{
+		zap.String("author", "alice"),
+		zap.String("public_key_id", "pk-42"),
+		zap.String("authorization", "Bearer abc"),
+	})...)
+
+	// 允许列表豁免误中 auth/key 子串的合法字段，真正的敏感字段仍被脱敏
+	if got["author"] != "alice" {
+		t.Errorf("author = %v, want preserved via allowlist", got["author"])
+	}
+	if got["public_key_id"] != "pk-42" {
+		t.Errorf("public_key_id = %v, want preserved via allowlist", got["public_key_id"])
+	}
+	if got["authorization"] != "[REDACTED]" {
+		t.Errorf("authorization = %v, want [REDACTED]", got["authorization"])
+	}
+}
+
+func TestRemoveAllowedFieldRestoresRedaction(t *testing.T) {
+	AddAllowedField("author")
+	RemoveAllowedField("author")
+
+	got := fieldMap(SanitizeFields([]zap.Field{zap.String("author", "alice")})...)
+	if got["author"] != "[REDACTED]" {
+		t.Errorf("author = %v, want redacted again after removal from allowlist", got["author"])
+	}
+}
@@ -1,7 +1,11 @@
 package logger
 
 import (
+	"os"
+	"sort"
+
 	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
 	"go.uber.org/zap/zapcore"
 )
 
@@ -15,7 +19,7 @@ func CreateZapConfig(level Level, format string) zap.Config {
 		config = zap.NewProductionConfig()
 		config.EncoderConfig = zap.NewProductionEncoderConfig()
 		config.EncoderConfig.TimeKey = "timestamp"
-		config.EncoderConfig.LevelKey = "level" 
+		config.EncoderConfig.LevelKey = "level"
 		config.EncoderConfig.MessageKey = "message"
 		config.EncoderConfig.CallerKey = "caller"
 		config.EncoderConfig.StacktraceKey = "stacktrace"
@@ -56,7 +60,7 @@ func CreateZapEncoder(format string) zapcore.Encoder {
 		}
 		return zapcore.NewJSONEncoder(encoderConfig)
 	}
-	
+
 	encoderConfig := zapcore.EncoderConfig{
 		TimeKey:        "T",
 		LevelKey:       "L",
@@ -73,8 +77,118 @@ func CreateZapEncoder(format string) zapcore.Encoder {
 	return zapcore.NewConsoleEncoder(encoderConfig)
 }
 
+// ConsoleEncoderOptions 控制台编码器的样式选项
+type ConsoleEncoderOptions struct {
+	// Colorized 为 true 时强制启用 ANSI 颜色；为 false 时仅在 stdout 连接终端时着色
+	Colorized bool
+	// TimeFormat 自定义时间布局，为空时使用 "2006-01-02 15:04:05"
+	TimeFormat string
+}
+
+// defaultConsoleTimeLayout 控制台输出的默认时间布局
+const defaultConsoleTimeLayout = "2006-01-02 15:04:05"
+
+// CreateConsoleEncoder 按样式选项创建控制台编码器
+// 输出重定向到文件或管道时默认关闭颜色，避免 ANSI 转义码污染日志
+func CreateConsoleEncoder(opts ConsoleEncoderOptions) zapcore.Encoder {
+	encodeLevel := zapcore.CapitalLevelEncoder
+	if opts.Colorized || isTerminal(os.Stdout) {
+		encodeLevel = zapcore.CapitalColorLevelEncoder
+	}
+
+	layout := opts.TimeFormat
+	if layout == "" {
+		layout = defaultConsoleTimeLayout
+	}
+
+	encoderConfig := zapcore.EncoderConfig{
+		TimeKey:        "T",
+		LevelKey:       "L",
+		NameKey:        "N",
+		CallerKey:      "C",
+		MessageKey:     "M",
+		StacktraceKey:  "S",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    encodeLevel,
+		EncodeTime:     zapcore.TimeEncoderOfLayout(layout),
+		EncodeDuration: zapcore.StringDurationEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+	}
+	return zapcore.NewConsoleEncoder(encoderConfig)
+}
+
+// flattenEncoder 包装 JSON 编码器，编码前把嵌套对象展平为点号连接的键
+// （如 order.id、order.total），并按键名排序输出，
+// 保证同一字段集的日志行键序稳定，适配要求严格 schema 的日志管道
+type flattenEncoder struct {
+	zapcore.Encoder
+}
+
+// NewFlattenEncoder 创建展平编码器，包装任意 zapcore.Encoder
+// 通常与 CreateZapEncoder("json") 组合使用
+func NewFlattenEncoder(inner zapcore.Encoder) zapcore.Encoder {
+	return &flattenEncoder{Encoder: inner}
+}
+
+// Clone 克隆编码器，保持展平包装
+func (e *flattenEncoder) Clone() zapcore.Encoder {
+	return &flattenEncoder{Encoder: e.Encoder.Clone()}
+}
+
+// EncodeEntry 展平字段后交给内部编码器编码
+func (e *flattenEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	return e.Encoder.EncodeEntry(entry, flattenLogFields(fields))
+}
+
+// flattenLogFields 把字段物化后展平嵌套对象，按键名排序返回
+// 嵌套层级以点号连接（parent.child.key），标量字段原样保留
+func flattenLogFields(fields []zapcore.Field) []zapcore.Field {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+
+	flat := make(map[string]any, len(enc.Fields))
+	flattenInto(flat, "", enc.Fields)
+
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	out := make([]zapcore.Field, 0, len(keys))
+	for _, key := range keys {
+		out = append(out, zap.Any(key, flat[key]))
+	}
+	return out
+}
+
+// flattenInto 递归展开嵌套 map，把叶子值写入 dst
+func flattenInto(dst map[string]any, prefix string, src map[string]any) {
+	for key, value := range src {
+		flatKey := key
+		if prefix != "" {
+			flatKey = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]any); ok {
+			flattenInto(dst, flatKey, nested)
+			continue
+		}
+		dst[flatKey] = value
+	}
+}
+
+// isTerminal 判断文件是否连接到终端（字符设备）
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
 // CreateCore 创建 zapcore.Core
 func CreateCore(encoder zapcore.Encoder, writer zapcore.WriteSyncer, level zapcore.Level) zapcore.Core {
 	return zapcore.NewCore(encoder, writer, level)
 }
-
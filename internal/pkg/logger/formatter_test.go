@@ -0,0 +1,123 @@
+package logger
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// encodeConsoleEntry 用给定选项编码一条 warn 日志并返回文本行
+func encodeConsoleEntry(t *testing.T, opts ConsoleEncoderOptions) string {
+	t.Helper()
+
+	encoder := CreateConsoleEncoder(opts)
+	buf, err := encoder.EncodeEntry(zapcore.Entry{
+		Level:   zapcore.WarnLevel,
+		Time:    time.Date(2026, 8, 1, 12, 30, 45, 0, time.UTC),
+		Message: "disk almost full",
+	}, nil)
+	if err != nil {
+		t.Fatalf("EncodeEntry: %v", err)
+	}
+	return buf.String()
+}
+
+func TestConsoleEncoderEmitsANSICodesWhenColorized(t *testing.T) {
+	line := encodeConsoleEntry(t, ConsoleEncoderOptions{Colorized: true})
+
+	if !strings.Contains(line, "\x1b[") {
+		t.Errorf("colorized output carries no ANSI escape codes:\n%q", line)
+	}
+	if !strings.Contains(line, "WARN") {
+		t.Errorf("output missing level text:\n%q", line)
+	}
+}
+
+func TestConsoleEncoderPlainWhenNotColorized(t *testing.T) {
+	// 测试进程的 stdout 不是 TTY，未显式开启颜色时必须是纯文本
+	line := encodeConsoleEntry(t, ConsoleEncoderOptions{})
+
+	if strings.Contains(line, "\x1b[") {
+		t.Errorf("non-colorized output carries ANSI escape codes:\n%q", line)
+	}
+}
+
+func TestConsoleEncoderHonorsCustomTimeFormat(t *testing.T) {
+	line := encodeConsoleEntry(t, ConsoleEncoderOptions{TimeFormat: "15:04:05"})
+
+	if !strings.Contains(line, "12:30:45") {
+		t.Errorf("output does not use the custom time layout:\n%q", line)
+	}
+	if strings.Contains(line, "2026-08-01") {
+		t.Errorf("output still carries the default date layout:\n%q", line)
+	}
+}
+
+func TestFlattenEncoderFlattensNestedAnyWithStableKeyOrder(t *testing.T) {
+	entry := zapcore.Entry{
+		Level:   zapcore.InfoLevel,
+		Time:    time.Date(2026, 8, 1, 12, 30, 45, 0, time.UTC),
+		Message: "order created",
+	}
+	fields := []zapcore.Field{
+		zap.Any("order", map[string]interface{}{
+			"id": "order-1",
+			"customer": map[string]interface{}{
+				"id": "user-1",
+			},
+		}),
+		zap.String("status", "pending"),
+	}
+
+	plain := CreateZapEncoder("json")
+	flat := NewFlattenEncoder(CreateZapEncoder("json"))
+
+	plainBuf, err := plain.EncodeEntry(entry, fields)
+	if err != nil {
+		t.Fatalf("EncodeEntry(plain): %v", err)
+	}
+	flatBuf, err := flat.EncodeEntry(entry, fields)
+	if err != nil {
+		t.Fatalf("EncodeEntry(flatten): %v", err)
+	}
+
+	// 未展平：嵌套对象原样输出
+	var plainDoc map[string]interface{}
+	if err := json.Unmarshal(plainBuf.Bytes(), &plainDoc); err != nil {
+		t.Fatalf("plain output is not valid JSON: %v", err)
+	}
+	if _, ok := plainDoc["order"].(map[string]interface{}); !ok {
+		t.Errorf("plain output order field = %v, want a nested object", plainDoc["order"])
+	}
+
+	// 展平：点号连接的键，不再有嵌套对象
+	var flatDoc map[string]interface{}
+	if err := json.Unmarshal(flatBuf.Bytes(), &flatDoc); err != nil {
+		t.Fatalf("flattened output is not valid JSON: %v", err)
+	}
+	if flatDoc["order.id"] != "order-1" {
+		t.Errorf("order.id = %v, want order-1", flatDoc["order.id"])
+	}
+	if flatDoc["order.customer.id"] != "user-1" {
+		t.Errorf("order.customer.id = %v, want user-1", flatDoc["order.customer.id"])
+	}
+	if flatDoc["status"] != "pending" {
+		t.Errorf("status = %v, want scalar preserved", flatDoc["status"])
+	}
+	if _, ok := flatDoc["order"]; ok {
+		t.Error("flattened output still carries the nested order object")
+	}
+
+	// 键序稳定：相同字段集两次编码得到字节一致的输出
+	again, err := flat.Clone().EncodeEntry(entry, fields)
+	if err != nil {
+		t.Fatalf("EncodeEntry(clone): %v", err)
+	}
+	if flatBuf.String() != again.String() {
+		t.Errorf("flattened output not deterministic:\n%q\n%q", flatBuf.String(), again.String())
+	}
+}
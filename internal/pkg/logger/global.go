@@ -19,14 +19,14 @@ func GetGlobalLogger() Logger {
 	globalLoggerLock.RLock()
 	logger := globalLogger
 	globalLoggerLock.RUnlock()
-	
+
 	if logger != nil {
 		return logger
 	}
-	
+
 	// 如果没有设置全局日志器，初始化默认的
 	globalInitOnce.Do(initDefaultGlobalLogger)
-	
+
 	globalLoggerLock.RLock()
 	defer globalLoggerLock.RUnlock()
 	return globalLogger
@@ -36,12 +36,12 @@ func GetGlobalLogger() Logger {
 func SetGlobalLogger(logger Logger) {
 	globalLoggerLock.Lock()
 	defer globalLoggerLock.Unlock()
-	
+
 	// 同步旧的日志器
 	if globalLogger != nil {
 		_ = globalLogger.Sync()
 	}
-	
+
 	globalLogger = logger
 }
 
@@ -49,10 +49,10 @@ func SetGlobalLogger(logger Logger) {
 func ReplaceGlobalLogger(logger Logger) func() {
 	globalLoggerLock.Lock()
 	defer globalLoggerLock.Unlock()
-	
+
 	oldLogger := globalLogger
 	globalLogger = logger
-	
+
 	// 返回恢复函数
 	return func() {
 		SetGlobalLogger(oldLogger)
@@ -66,7 +66,7 @@ func initDefaultGlobalLogger() {
 		// 如果创建失败，使用最基础的日志器
 		logger = &fallbackLogger{}
 	}
-	
+
 	globalLoggerLock.Lock()
 	globalLogger = logger
 	globalLoggerLock.Unlock()
@@ -130,15 +130,15 @@ var globalConfig = &GlobalLoggerConfig{
 func SetGlobalLoggerConfig(config LoggerConfig) error {
 	globalConfig.mutex.Lock()
 	defer globalConfig.mutex.Unlock()
-	
+
 	logger, err := CreateLogger(config)
 	if err != nil {
 		return err
 	}
-	
+
 	globalConfig.config = config
 	SetGlobalLogger(logger)
-	
+
 	return nil
 }
 
@@ -153,7 +153,7 @@ func GetGlobalLoggerConfig() LoggerConfig {
 func UpdateGlobalLogLevel(level Level) {
 	globalConfig.mutex.Lock()
 	defer globalConfig.mutex.Unlock()
-	
+
 	globalConfig.config.Level = level
 	GetGlobalLogger().SetLevel(level)
 }
@@ -169,21 +169,7 @@ func GetGlobalLogLevel() Level {
 
 // InitGlobalLogger 初始化全局日志器（应用启动时调用）
 func InitGlobalLogger(serviceName, environment string) error {
-	var config LoggerConfig
-	
-	switch environment {
-	case "production", "prod":
-		config = ProductionLoggerConfig(serviceName)
-	case "development", "dev":
-		config = DevelopmentLoggerConfig(serviceName)
-	case "test", "testing":
-		config = TestLoggerConfig(serviceName)
-	default:
-		config = DevelopmentLoggerConfig(serviceName)
-		config.Environment = environment
-	}
-	
-	return SetGlobalLoggerConfig(config)
+	return SetGlobalLoggerConfig(ConfigForEnvironment(serviceName, environment))
 }
 
 // InitGlobalLoggerFromEnv 从环境变量初始化全局日志器
@@ -192,7 +178,7 @@ func InitGlobalLoggerFromEnv() error {
 	if err != nil {
 		return err
 	}
-	
+
 	SetGlobalLogger(logger)
 	return nil
 }
@@ -206,16 +192,16 @@ func MustInitGlobalLogger(serviceName, environment string) {
 
 // 优雅关闭支持
 
-// Cleanup 清理全局日志器资源
+// Cleanup 清理全局日志器资源：排空缓冲并关闭输出
 func Cleanup() error {
 	globalLoggerLock.RLock()
 	logger := globalLogger
 	globalLoggerLock.RUnlock()
-	
+
 	if logger != nil {
-		return logger.Sync()
+		return logger.Close()
 	}
-	
+
 	return nil
 }
 
@@ -234,17 +220,28 @@ func (f *fallbackLogger) Warn(msg string, fields ...zap.Field)  {}
 func (f *fallbackLogger) Error(msg string, fields ...zap.Field) {}
 func (f *fallbackLogger) Fatal(msg string, fields ...zap.Field) {}
 
+func (f *fallbackLogger) Debugf(format string, args ...any) {}
+func (f *fallbackLogger) Infof(format string, args ...any)  {}
+func (f *fallbackLogger) Warnf(format string, args ...any)  {}
+func (f *fallbackLogger) Errorf(format string, args ...any) {}
+
 func (f *fallbackLogger) DebugContext(ctx context.Context, msg string, fields ...zap.Field) {}
 func (f *fallbackLogger) InfoContext(ctx context.Context, msg string, fields ...zap.Field)  {}
 func (f *fallbackLogger) WarnContext(ctx context.Context, msg string, fields ...zap.Field)  {}
 func (f *fallbackLogger) ErrorContext(ctx context.Context, msg string, fields ...zap.Field) {}
 
-func (f *fallbackLogger) WithFields(fields ...zap.Field) Logger { return f }
+func (f *fallbackLogger) WithFields(fields ...zap.Field) Logger  { return f }
 func (f *fallbackLogger) WithContext(ctx context.Context) Logger { return f }
-func (f *fallbackLogger) WithService(service string) Logger     { return f }
+func (f *fallbackLogger) WithService(service string) Logger      { return f }
 
 func (f *fallbackLogger) IfDebug() ConditionalLogger { return &noopConditionalLogger{} }
 func (f *fallbackLogger) IfInfo() ConditionalLogger  { return &noopConditionalLogger{} }
+func (f *fallbackLogger) IfWarn() ConditionalLogger  { return &noopConditionalLogger{} }
+func (f *fallbackLogger) IfError() ConditionalLogger { return &noopConditionalLogger{} }
+
+func (f *fallbackLogger) Close() error { return nil }
+
+func (f *fallbackLogger) Check(level Level) (ConditionalLogger, bool) { return nil, false }
 
 func (f *fallbackLogger) SetLevel(level Level) {}
 func (f *fallbackLogger) Sync() error          { return nil }
@@ -255,11 +252,11 @@ func (f *fallbackLogger) Sync() error          { return nil }
 func ResetGlobalLogger() {
 	globalLoggerLock.Lock()
 	defer globalLoggerLock.Unlock()
-	
+
 	if globalLogger != nil {
 		_ = globalLogger.Sync()
 	}
-	
+
 	globalLogger = nil
 	globalInitOnce = sync.Once{}
 }
@@ -268,8 +265,8 @@ func ResetGlobalLogger() {
 func SetTestGlobalLogger(logger Logger) func() {
 	oldLogger := GetGlobalLogger()
 	SetGlobalLogger(logger)
-	
+
 	return func() {
 		SetGlobalLogger(oldLogger)
 	}
-}
\ No newline at end of file
+}
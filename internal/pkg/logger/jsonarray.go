@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// jsonArrayWriter 将日志条目累积为 JSON 数组并在 Sync 时原子写入文件
+// 用于离线分析导出等需要完整 JSON 数组（而非 JSON-lines）的场景，
+// 条目保存在内存中，不适合长时间运行的常规输出
+type jsonArrayWriter struct {
+	mu      sync.Mutex
+	path    string
+	entries [][]byte
+}
+
+func newJSONArrayWriter(path string) *jsonArrayWriter {
+	return &jsonArrayWriter{
+		path: path,
+	}
+}
+
+func (w *jsonArrayWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// 去掉编码器追加的换行，保存条目副本
+	entry := make([]byte, len(p))
+	copy(entry, p)
+	w.entries = append(w.entries, bytes.TrimRight(entry, "\n"))
+
+	return len(p), nil
+}
+
+// Sync 把累积的条目写成合法的 JSON 数组，先写临时文件再改名保证原子性
+func (w *jsonArrayWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var buf bytes.Buffer
+	buf.WriteString("[\n")
+	for i, entry := range w.entries {
+		if i > 0 {
+			buf.WriteString(",\n")
+		}
+		buf.Write(entry)
+	}
+	buf.WriteString("\n]\n")
+
+	tmpPath := w.path + ".tmp"
+	if err := os.WriteFile(tmpPath, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write JSON array log file: %w", err)
+	}
+	return os.Rename(tmpPath, w.path)
+}
+
+// AddJSONArrayFileOutput 添加 JSON 数组文件输出
+// 日志条目在内存中累积，Sync 时整体作为一个 JSON 数组原子写入 path
+func (om *OutputManager) AddJSONArrayFileOutput(path string, level zapcore.Level) error {
+	om.mutex.Lock()
+	defer om.mutex.Unlock()
+
+	// 确保目录存在
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	encoder := CreateZapEncoder("json")
+	core := zapcore.NewCore(encoder, newJSONArrayWriter(path), level)
+	om.cores = append(om.cores, core)
+
+	return nil
+}
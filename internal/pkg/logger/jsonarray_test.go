@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestJSONArrayFileOutputProducesValidArray(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.json")
+
+	om := NewOutputManager()
+	if err := om.AddJSONArrayFileOutput(path, zapcore.DebugLevel); err != nil {
+		t.Fatalf("AddJSONArrayFileOutput: %v", err)
+	}
+
+	log := zap.New(om.CreateTeeCore())
+	log.Info("first entry", zap.String("key", "value"))
+	log.Warn("second entry")
+	log.Error("third entry")
+	if err := log.Sync(); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	var entries []map[string]any
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		t.Fatalf("output is not a valid JSON array: %v\n%s", err, raw)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+	if entries[0]["message"] != "first entry" || entries[0]["key"] != "value" {
+		t.Errorf("first entry = %v, want msg/key preserved", entries[0])
+	}
+	if entries[2]["level"] != "error" {
+		t.Errorf("third entry level = %v, want error", entries[2]["level"])
+	}
+}
@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// levelPayload 日志级别端点的请求和响应体
+type levelPayload struct {
+	Level string `json:"level"`
+}
+
+// LevelHandler 创建运行时日志级别端点的处理器
+// GET 返回当前全局级别，PUT/POST 接收 {"level":"debug"} 更新全局级别
+// 级别名称通过 ParseLevel 校验，未知名称返回 400
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeLevelResponse(w, http.StatusOK, GetGlobalLogLevel())
+
+		case http.MethodPut, http.MethodPost:
+			var payload levelPayload
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+				writeLevelError(w, http.StatusBadRequest, "invalid JSON body")
+				return
+			}
+
+			// ParseLevel 对未知名称回退到 info，这里要求名称严格匹配
+			if payload.Level != ParseLevel(payload.Level).String() {
+				writeLevelError(w, http.StatusBadRequest, "unknown level: "+payload.Level)
+				return
+			}
+
+			UpdateGlobalLogLevel(ParseLevel(payload.Level))
+			writeLevelResponse(w, http.StatusOK, GetGlobalLogLevel())
+
+		default:
+			w.Header().Set("Allow", "GET, POST, PUT")
+			writeLevelError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	})
+}
+
+// writeLevelResponse 输出当前级别的 JSON 响应
+func writeLevelResponse(w http.ResponseWriter, status int, level Level) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(levelPayload{Level: level.String()})
+}
+
+// writeLevelError 输出 JSON 格式的错误响应
+func writeLevelError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
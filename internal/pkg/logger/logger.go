@@ -18,18 +18,57 @@ type zapLogger struct {
 	level      Level
 	service    string
 	baseFields []zap.Field
+
+	// redact 为 true 时字段经过 SanitizeFields 脱敏
+	redact bool
+
+	// stacktrace 堆栈跟踪采集模式
+	stacktrace StacktraceMode
+
+	// atomicLevel 运行时可双向调整的级别开关，派生日志器共享同一实例
+	atomicLevel zap.AtomicLevel
 }
 
 // NewLogger 创建新的日志器实例
 func NewLogger(config LoggerConfig) (Logger, error) {
 	zapConfig := buildZapConfig(config)
-	
-	zapInst, err := zapConfig.Build()
+	atomicLevel := zap.NewAtomicLevelAt(levelToZapLevel(config.Level))
+	zapConfig.Level = atomicLevel
+
+	zapInst, err := zapConfig.Build(stacktraceOptions(config.Stacktrace)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build zap logger: %w", err)
 	}
 
 	// 添加服务信息作为基础字段
+	baseFields := buildBaseFields(config)
+
+	zapWithBase := zapInst.With(baseFields...)
+	logger := &zapLogger{
+		zap:         zapWithBase,
+		sugar:       zapWithBase.Sugar(),
+		level:       config.Level,
+		service:     config.ServiceName,
+		baseFields:  baseFields,
+		redact:      config.RedactSensitiveFields,
+		stacktrace:  config.Stacktrace,
+		atomicLevel: atomicLevel,
+	}
+
+	return logger, nil
+}
+
+// stacktraceOptions 根据堆栈跟踪模式返回额外的 zap 构建选项
+// StacktraceZap 模式下由 zap 仅在 Error 及以上级别采集堆栈
+func stacktraceOptions(mode StacktraceMode) []zap.Option {
+	if mode == StacktraceZap {
+		return []zap.Option{zap.AddStacktrace(zapcore.ErrorLevel)}
+	}
+	return nil
+}
+
+// buildBaseFields 构建日志器的基础字段（服务信息和可选的主机信息）
+func buildBaseFields(config LoggerConfig) []zap.Field {
 	var baseFields []zap.Field
 	if config.ServiceName != "" {
 		baseFields = append(baseFields, zap.String("service", config.ServiceName))
@@ -40,16 +79,13 @@ func NewLogger(config LoggerConfig) (Logger, error) {
 	if config.Environment != "" {
 		baseFields = append(baseFields, zap.String("environment", config.Environment))
 	}
-
-	logger := &zapLogger{
-		zap:        zapInst.With(baseFields...),
-		sugar:      zapInst.Sugar(),
-		level:      config.Level,
-		service:    config.ServiceName,
-		baseFields: baseFields,
+	if config.IncludeHostInfo {
+		if hostname, err := os.Hostname(); err == nil {
+			baseFields = append(baseFields, zap.String("host", hostname))
+		}
+		baseFields = append(baseFields, zap.Int("pid", os.Getpid()))
 	}
-
-	return logger, nil
+	return baseFields
 }
 
 // buildZapConfig 构建 zap 配置
@@ -67,6 +103,9 @@ func buildZapConfig(config LoggerConfig) zap.Config {
 	zapConfig.OutputPaths = []string{"stdout"}
 	zapConfig.ErrorOutputPaths = []string{"stderr"}
 
+	// zap 自带的堆栈采集由 Stacktrace 模式统一控制，避免与手动 stack_trace 字段重复
+	zapConfig.DisableStacktrace = true
+
 	return zapConfig
 }
 
@@ -105,16 +144,40 @@ func (l *zapLogger) Warn(msg string, fields ...zap.Field) {
 
 // Error 记录错误日志
 func (l *zapLogger) Error(msg string, fields ...zap.Field) {
-	// 自动添加堆栈信息到错误日志
-	fieldsWithStack := append(fields, zap.String("stack_trace", getStackTrace()))
-	l.zap.Error(msg, l.enhanceFields(fieldsWithStack...)...)
+	// 手动模式下自动添加堆栈信息到错误日志
+	if l.stacktrace == StacktraceManual {
+		fields = append(fields, zap.String("stack_trace", getStackTrace()))
+	}
+	l.zap.Error(msg, l.enhanceFields(fields...)...)
 }
 
 // Fatal 记录致命错误日志
 func (l *zapLogger) Fatal(msg string, fields ...zap.Field) {
-	// 自动添加堆栈信息到致命错误日志
-	fieldsWithStack := append(fields, zap.String("stack_trace", getStackTrace()))
-	l.zap.Fatal(msg, l.enhanceFields(fieldsWithStack...)...)
+	// 手动模式下自动添加堆栈信息到致命错误日志
+	if l.stacktrace == StacktraceManual {
+		fields = append(fields, zap.String("stack_trace", getStackTrace()))
+	}
+	l.zap.Fatal(msg, l.enhanceFields(fields...)...)
+}
+
+// Debugf 记录 Printf 风格的调试日志
+func (l *zapLogger) Debugf(format string, args ...any) {
+	l.sugar.Debugf(format, args...)
+}
+
+// Infof 记录 Printf 风格的信息日志
+func (l *zapLogger) Infof(format string, args ...any) {
+	l.sugar.Infof(format, args...)
+}
+
+// Warnf 记录 Printf 风格的警告日志
+func (l *zapLogger) Warnf(format string, args ...any) {
+	l.sugar.Warnf(format, args...)
+}
+
+// Errorf 记录 Printf 风格的错误日志
+func (l *zapLogger) Errorf(format string, args ...any) {
+	l.sugar.Errorf(format, args...)
 }
 
 // DebugContext 记录带上下文的调试日志
@@ -147,12 +210,16 @@ func (l *zapLogger) ErrorContext(ctx context.Context, msg string, fields ...zap.
 
 // WithFields 创建带有额外字段的日志器
 func (l *zapLogger) WithFields(fields ...zap.Field) Logger {
+	zapWithFields := l.zap.With(fields...)
 	return &zapLogger{
-		zap:        l.zap.With(fields...),
-		sugar:      l.sugar,
-		level:      l.level,
-		service:    l.service,
-		baseFields: l.baseFields,
+		zap:         zapWithFields,
+		sugar:       zapWithFields.Sugar(),
+		level:       l.level,
+		service:     l.service,
+		baseFields:  l.baseFields,
+		redact:      l.redact,
+		stacktrace:  l.stacktrace,
+		atomicLevel: l.atomicLevel,
 	}
 }
 
@@ -164,18 +231,22 @@ func (l *zapLogger) WithContext(ctx context.Context) Logger {
 
 // WithService 创建带有服务名的日志器
 func (l *zapLogger) WithService(service string) Logger {
+	zapWithService := l.zap.With(zap.String("service", service))
 	return &zapLogger{
-		zap:        l.zap.With(zap.String("service", service)),
-		sugar:      l.sugar,
-		level:      l.level,
-		service:    service,
-		baseFields: l.baseFields,
+		zap:         zapWithService,
+		sugar:       zapWithService.Sugar(),
+		level:       l.level,
+		service:     service,
+		baseFields:  l.baseFields,
+		redact:      l.redact,
+		stacktrace:  l.stacktrace,
+		atomicLevel: l.atomicLevel,
 	}
 }
 
 // IfDebug 返回条件调试日志器
 func (l *zapLogger) IfDebug() ConditionalLogger {
-	if l.level <= DebugLevel {
+	if l.atomicLevel.Enabled(zapcore.DebugLevel) {
 		return &conditionalLogger{logger: l, level: DebugLevel}
 	}
 	return &noopConditionalLogger{}
@@ -183,17 +254,41 @@ func (l *zapLogger) IfDebug() ConditionalLogger {
 
 // IfInfo 返回条件信息日志器
 func (l *zapLogger) IfInfo() ConditionalLogger {
-	if l.level <= InfoLevel {
+	if l.atomicLevel.Enabled(zapcore.InfoLevel) {
 		return &conditionalLogger{logger: l, level: InfoLevel}
 	}
 	return &noopConditionalLogger{}
 }
 
-// SetLevel 设置日志级别
+// IfWarn 返回条件警告日志器
+func (l *zapLogger) IfWarn() ConditionalLogger {
+	if l.atomicLevel.Enabled(zapcore.WarnLevel) {
+		return &conditionalLogger{logger: l, level: WarnLevel}
+	}
+	return &noopConditionalLogger{}
+}
+
+// IfError 返回条件错误日志器
+func (l *zapLogger) IfError() ConditionalLogger {
+	if l.atomicLevel.Enabled(zapcore.ErrorLevel) {
+		return &conditionalLogger{logger: l, level: ErrorLevel}
+	}
+	return &noopConditionalLogger{}
+}
+
+// Check 检查级别是否启用，启用时返回绑定到该级别的条件日志器
+func (l *zapLogger) Check(level Level) (ConditionalLogger, bool) {
+	if !l.atomicLevel.Enabled(levelToZapLevel(level)) {
+		return nil, false
+	}
+	return &conditionalLogger{logger: l, level: level}, true
+}
+
+// SetLevel 设置日志级别，通过共享的 AtomicLevel 双向调整
+// 降回更低级别（如 error -> debug）同样立即生效
 func (l *zapLogger) SetLevel(level Level) {
 	l.level = level
-	l.zap = l.zap.WithOptions(zap.IncreaseLevel(levelToZapLevel(level)))
-	l.sugar = l.zap.Sugar()
+	l.atomicLevel.SetLevel(levelToZapLevel(level))
 }
 
 // Sync 同步日志输出
@@ -201,11 +296,18 @@ func (l *zapLogger) Sync() error {
 	return l.zap.Sync()
 }
 
-// enhanceFields 增强字段（添加敏感数据处理等）
+// Close 关闭日志器；基础日志器只写标准输出，排空缓冲即可
+func (l *zapLogger) Close() error {
+	return l.zap.Sync()
+}
+
+// enhanceFields 增强字段（敏感数据脱敏等）
 func (l *zapLogger) enhanceFields(fields ...zap.Field) []zap.Field {
-	// 这里可以添加敏感数据脱敏等逻辑
-	// 目前直接返回，保持高性能
-	return fields
+	// 未开启脱敏时直接返回，保持高性能
+	if !l.redact {
+		return fields
+	}
+	return SanitizeFields(fields)
 }
 
 // conditionalLogger 条件日志器实现
@@ -220,6 +322,12 @@ func (c *conditionalLogger) Log(msg string, fields ...zap.Field) {
 		c.logger.Debug(msg, fields...)
 	case InfoLevel:
 		c.logger.Info(msg, fields...)
+	case WarnLevel:
+		c.logger.Warn(msg, fields...)
+	case ErrorLevel:
+		c.logger.Error(msg, fields...)
+	case FatalLevel:
+		c.logger.Fatal(msg, fields...)
 	}
 }
 
@@ -232,22 +340,22 @@ func (c *conditionalLogger) Logf(format string, args ...any) {
 type noopConditionalLogger struct{}
 
 func (n *noopConditionalLogger) Log(msg string, fields ...zap.Field) {}
-func (n *noopConditionalLogger) Logf(format string, args ...any) {}
+func (n *noopConditionalLogger) Logf(format string, args ...any)     {}
 
 // extractContextFields 从上下文中提取字段 - 这是我们的核心增值功能
 func extractContextFields(ctx context.Context) []zap.Field {
 	var fields []zap.Field
-	
+
 	// 提取请求 ID
 	if requestID := getRequestIDFromContext(ctx); requestID != "" {
 		fields = append(fields, zap.String("request_id", requestID))
 	}
-	
+
 	// 提取用户 ID
 	if userID := getUserIDFromContext(ctx); userID != "" {
 		fields = append(fields, zap.String("user_id", userID))
 	}
-	
+
 	// TODO: 添加分布式追踪支持
 	// if traceID := getTraceIDFromContext(ctx); traceID != "" {
 	//     fields = append(fields, zap.String("trace_id", traceID))
@@ -255,28 +363,18 @@ func extractContextFields(ctx context.Context) []zap.Field {
 	// if spanID := getSpanIDFromContext(ctx); spanID != "" {
 	//     fields = append(fields, zap.String("span_id", spanID))
 	// }
-	
+
 	return fields
 }
 
 // getRequestIDFromContext 从上下文获取请求ID
 func getRequestIDFromContext(ctx context.Context) string {
-	if val := ctx.Value("request_id"); val != nil {
-		if requestID, ok := val.(string); ok {
-			return requestID
-		}
-	}
-	return ""
+	return getContextValue(ctx, string(requestIDKey))
 }
 
 // getUserIDFromContext 从上下文获取用户ID
 func getUserIDFromContext(ctx context.Context) string {
-	if val := ctx.Value("user_id"); val != nil {
-		if userID, ok := val.(string); ok {
-			return userID
-		}
-	}
-	return ""
+	return getContextValue(ctx, string(userIDKey))
 }
 
 // getStackTrace 获取堆栈跟踪
@@ -284,24 +382,24 @@ func getStackTrace() string {
 	const depth = 32
 	var pcs [depth]uintptr
 	n := runtime.Callers(3, pcs[:])
-	
+
 	var sb strings.Builder
 	frames := runtime.CallersFrames(pcs[:n])
-	
+
 	for {
 		frame, more := frames.Next()
 		if !more {
 			break
 		}
-		
+
 		// 跳过系统库的堆栈信息
 		if strings.Contains(frame.File, "runtime/") {
 			continue
 		}
-		
+
 		sb.WriteString(fmt.Sprintf("%s:%d %s\n", frame.File, frame.Line, frame.Function))
 	}
-	
+
 	return sb.String()
 }
 
@@ -331,4 +429,4 @@ func NewLoggerFromEnv() (Logger, error) {
 	}
 
 	return NewLogger(config)
-}
\ No newline at end of file
+}
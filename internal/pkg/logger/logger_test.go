@@ -0,0 +1,139 @@
+package logger
+
+import (
+	"os"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// newObservedZapLogger 构造接到 observer 核心的 zapLogger，便于断言实际输出
+func newObservedZapLogger(t *testing.T) (*zapLogger, *observer.ObservedLogs) {
+	t.Helper()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	z := zap.New(core)
+	return &zapLogger{
+		zap:         z,
+		sugar:       z.Sugar(),
+		level:       DebugLevel,
+		atomicLevel: zap.NewAtomicLevelAt(zapcore.DebugLevel),
+	}, logs
+}
+
+func TestSugaredMethodsFormatAndKeepServiceField(t *testing.T) {
+	base, logs := newObservedZapLogger(t)
+	log := base.WithService("user-service")
+
+	log.Infof("imported %d users from %s", 3, "csv")
+	log.Errorf("import failed: %v", "broken pipe")
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+
+	if entries[0].Message != "imported 3 users from csv" {
+		t.Errorf("Infof message = %q, want formatted output", entries[0].Message)
+	}
+	if entries[0].Level != zapcore.InfoLevel {
+		t.Errorf("Infof level = %v, want info", entries[0].Level)
+	}
+	if entries[1].Message != "import failed: broken pipe" {
+		t.Errorf("Errorf message = %q, want formatted output", entries[1].Message)
+	}
+	if entries[1].Level != zapcore.ErrorLevel {
+		t.Errorf("Errorf level = %v, want error", entries[1].Level)
+	}
+
+	// 派生时附加的 service 基础字段必须跟随 sugared 调用输出
+	for i, entry := range entries {
+		fields := entry.ContextMap()
+		if fields["service"] != "user-service" {
+			t.Errorf("entry %d service field = %v, want user-service", i, fields["service"])
+		}
+	}
+}
+
+func TestBuildBaseFieldsIncludesHostInfoWhenEnabled(t *testing.T) {
+	fields := buildBaseFields(LoggerConfig{
+		ServiceName:     "user-service",
+		IncludeHostInfo: true,
+	})
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+
+	hostname, err := os.Hostname()
+	if err == nil {
+		if enc.Fields["host"] != hostname {
+			t.Errorf("host field = %v, want %q", enc.Fields["host"], hostname)
+		}
+	}
+	if enc.Fields["pid"] != int64(os.Getpid()) {
+		t.Errorf("pid field = %v, want %d", enc.Fields["pid"], os.Getpid())
+	}
+}
+
+func TestBuildBaseFieldsOmitsHostInfoByDefault(t *testing.T) {
+	fields := buildBaseFields(LoggerConfig{ServiceName: "user-service"})
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+
+	if _, ok := enc.Fields["host"]; ok {
+		t.Error("host field present although IncludeHostInfo is false")
+	}
+	if _, ok := enc.Fields["pid"]; ok {
+		t.Error("pid field present although IncludeHostInfo is false")
+	}
+}
+
+func TestSugaredMethodsRespectLevel(t *testing.T) {
+	base, logs := newObservedZapLogger(t)
+
+	base.Debugf("debug %s", "detail")
+	base.Warnf("warn %s", "detail")
+
+	if got := len(logs.All()); got != 2 {
+		t.Fatalf("got %d entries, want 2", got)
+	}
+	if logs.All()[0].Level != zapcore.DebugLevel || logs.All()[1].Level != zapcore.WarnLevel {
+		t.Errorf("levels = %v/%v, want debug/warn", logs.All()[0].Level, logs.All()[1].Level)
+	}
+}
+
+func TestSetLevelLowersLevelBidirectionally(t *testing.T) {
+	// 观察核心与日志器共享同一个 AtomicLevel，SetLevel 才能双向生效
+	atomic := zap.NewAtomicLevelAt(zapcore.ErrorLevel)
+	core, logs := observer.New(atomic)
+	z := zap.New(core)
+	log := &zapLogger{
+		zap:         z,
+		sugar:       z.Sugar(),
+		level:       ErrorLevel,
+		atomicLevel: atomic,
+	}
+
+	log.Debug("suppressed at error level")
+	if got := logs.Len(); got != 0 {
+		t.Fatalf("got %d entries at error level, want 0", got)
+	}
+
+	// 从 error 降回 debug：zap.IncreaseLevel 做不到这一点
+	log.SetLevel(DebugLevel)
+
+	log.Debug("visible again at debug level")
+	if got := logs.Len(); got != 1 {
+		t.Fatalf("got %d entries after lowering to debug, want 1", got)
+	}
+	if msg := logs.All()[0].Message; msg != "visible again at debug level" {
+		t.Errorf("message = %q, want the debug entry", msg)
+	}
+}
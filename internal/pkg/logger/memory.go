@@ -0,0 +1,271 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// MemoryEntry 内存日志器记录的单条日志
+type MemoryEntry struct {
+	Level   Level
+	Message string
+	Fields  []zap.Field
+}
+
+// FieldMap 将字段物化为 map，便于断言字段值
+// 通过 zapcore 的 MapObjectEncoder 展开，与真实编码结果保持一致
+func (e MemoryEntry) FieldMap() map[string]any {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range e.Fields {
+		field.AddTo(enc)
+	}
+	return enc.Fields
+}
+
+// HasField 判断条目中是否存在指定键的字段
+func (e MemoryEntry) HasField(key string) bool {
+	_, ok := e.FieldMap()[key]
+	return ok
+}
+
+// FieldEquals 判断条目中指定键的字段值是否等于期望值
+func (e MemoryEntry) FieldEquals(key string, want any) bool {
+	got, ok := e.FieldMap()[key]
+	return ok && got == want
+}
+
+// memoryStore 派生日志器之间共享的条目存储
+type memoryStore struct {
+	mu      sync.RWMutex
+	entries []MemoryEntry
+}
+
+// MemoryLogger 将日志记录在内存中的 Logger 实现，用于测试断言
+// 与 SetTestGlobalLogger 配合使用；Fatal 只记录条目，不会退出进程
+type MemoryLogger struct {
+	store      *memoryStore
+	level      Level
+	service    string
+	baseFields []zap.Field
+}
+
+// NewMemoryLogger 创建内存日志器，默认级别为 Debug，记录所有日志
+func NewMemoryLogger() *MemoryLogger {
+	return &MemoryLogger{
+		store: &memoryStore{},
+		level: DebugLevel,
+	}
+}
+
+// Entries 返回已记录条目的副本
+func (l *MemoryLogger) Entries() []MemoryEntry {
+	l.store.mu.RLock()
+	defer l.store.mu.RUnlock()
+
+	entries := make([]MemoryEntry, len(l.store.entries))
+	copy(entries, l.store.entries)
+	return entries
+}
+
+// LastEntry 返回最近记录的条目，无条目时 ok 为 false
+func (l *MemoryLogger) LastEntry() (MemoryEntry, bool) {
+	l.store.mu.RLock()
+	defer l.store.mu.RUnlock()
+
+	if len(l.store.entries) == 0 {
+		return MemoryEntry{}, false
+	}
+	return l.store.entries[len(l.store.entries)-1], true
+}
+
+// EntriesAtLevel 返回指定级别的条目
+func (l *MemoryLogger) EntriesAtLevel(level Level) []MemoryEntry {
+	l.store.mu.RLock()
+	defer l.store.mu.RUnlock()
+
+	var entries []MemoryEntry
+	for _, entry := range l.store.entries {
+		if entry.Level == level {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Reset 清空已记录的条目
+func (l *MemoryLogger) Reset() {
+	l.store.mu.Lock()
+	defer l.store.mu.Unlock()
+
+	l.store.entries = nil
+}
+
+// record 按级别过滤后追加条目，基础字段排在调用方字段之前
+func (l *MemoryLogger) record(level Level, msg string, fields []zap.Field) {
+	if level < l.level {
+		return
+	}
+
+	allFields := make([]zap.Field, 0, len(l.baseFields)+len(fields))
+	allFields = append(allFields, l.baseFields...)
+	allFields = append(allFields, fields...)
+
+	l.store.mu.Lock()
+	defer l.store.mu.Unlock()
+
+	l.store.entries = append(l.store.entries, MemoryEntry{
+		Level:   level,
+		Message: msg,
+		Fields:  allFields,
+	})
+}
+
+// Debug 记录调试日志
+func (l *MemoryLogger) Debug(msg string, fields ...zap.Field) {
+	l.record(DebugLevel, msg, fields)
+}
+
+// Info 记录信息日志
+func (l *MemoryLogger) Info(msg string, fields ...zap.Field) {
+	l.record(InfoLevel, msg, fields)
+}
+
+// Warn 记录警告日志
+func (l *MemoryLogger) Warn(msg string, fields ...zap.Field) {
+	l.record(WarnLevel, msg, fields)
+}
+
+// Error 记录错误日志
+func (l *MemoryLogger) Error(msg string, fields ...zap.Field) {
+	l.record(ErrorLevel, msg, fields)
+}
+
+// Fatal 记录致命错误日志（仅记录，不退出进程）
+func (l *MemoryLogger) Fatal(msg string, fields ...zap.Field) {
+	l.record(FatalLevel, msg, fields)
+}
+
+// Debugf 记录 Printf 风格的调试日志
+func (l *MemoryLogger) Debugf(format string, args ...any) {
+	l.record(DebugLevel, fmt.Sprintf(format, args...), nil)
+}
+
+// Infof 记录 Printf 风格的信息日志
+func (l *MemoryLogger) Infof(format string, args ...any) {
+	l.record(InfoLevel, fmt.Sprintf(format, args...), nil)
+}
+
+// Warnf 记录 Printf 风格的警告日志
+func (l *MemoryLogger) Warnf(format string, args ...any) {
+	l.record(WarnLevel, fmt.Sprintf(format, args...), nil)
+}
+
+// Errorf 记录 Printf 风格的错误日志
+func (l *MemoryLogger) Errorf(format string, args ...any) {
+	l.record(ErrorLevel, fmt.Sprintf(format, args...), nil)
+}
+
+// DebugContext 记录带上下文的调试日志
+func (l *MemoryLogger) DebugContext(ctx context.Context, msg string, fields ...zap.Field) {
+	l.record(DebugLevel, msg, append(extractContextFields(ctx), fields...))
+}
+
+// InfoContext 记录带上下文的信息日志
+func (l *MemoryLogger) InfoContext(ctx context.Context, msg string, fields ...zap.Field) {
+	l.record(InfoLevel, msg, append(extractContextFields(ctx), fields...))
+}
+
+// WarnContext 记录带上下文的警告日志
+func (l *MemoryLogger) WarnContext(ctx context.Context, msg string, fields ...zap.Field) {
+	l.record(WarnLevel, msg, append(extractContextFields(ctx), fields...))
+}
+
+// ErrorContext 记录带上下文的错误日志
+func (l *MemoryLogger) ErrorContext(ctx context.Context, msg string, fields ...zap.Field) {
+	l.record(ErrorLevel, msg, append(extractContextFields(ctx), fields...))
+}
+
+// WithFields 创建带有额外字段的日志器，与原日志器共享条目存储
+func (l *MemoryLogger) WithFields(fields ...zap.Field) Logger {
+	baseFields := make([]zap.Field, 0, len(l.baseFields)+len(fields))
+	baseFields = append(baseFields, l.baseFields...)
+	baseFields = append(baseFields, fields...)
+
+	return &MemoryLogger{
+		store:      l.store,
+		level:      l.level,
+		service:    l.service,
+		baseFields: baseFields,
+	}
+}
+
+// WithContext 创建带有上下文的日志器
+func (l *MemoryLogger) WithContext(ctx context.Context) Logger {
+	return l.WithFields(extractContextFields(ctx)...)
+}
+
+// WithService 创建带有服务名的日志器
+func (l *MemoryLogger) WithService(service string) Logger {
+	derived := l.WithFields(zap.String("service", service)).(*MemoryLogger)
+	derived.service = service
+	return derived
+}
+
+// IfDebug 返回条件调试日志器
+func (l *MemoryLogger) IfDebug() ConditionalLogger {
+	if l.level <= DebugLevel {
+		return &conditionalLogger{logger: l, level: DebugLevel}
+	}
+	return &noopConditionalLogger{}
+}
+
+// IfInfo 返回条件信息日志器
+func (l *MemoryLogger) IfInfo() ConditionalLogger {
+	if l.level <= InfoLevel {
+		return &conditionalLogger{logger: l, level: InfoLevel}
+	}
+	return &noopConditionalLogger{}
+}
+
+// IfWarn 返回条件警告日志器
+func (l *MemoryLogger) IfWarn() ConditionalLogger {
+	if l.level <= WarnLevel {
+		return &conditionalLogger{logger: l, level: WarnLevel}
+	}
+	return &noopConditionalLogger{}
+}
+
+// IfError 返回条件错误日志器
+func (l *MemoryLogger) IfError() ConditionalLogger {
+	if l.level <= ErrorLevel {
+		return &conditionalLogger{logger: l, level: ErrorLevel}
+	}
+	return &noopConditionalLogger{}
+}
+
+// Check 检查级别是否启用，启用时返回绑定到该级别的条件日志器
+func (l *MemoryLogger) Check(level Level) (ConditionalLogger, bool) {
+	if level < l.level {
+		return nil, false
+	}
+	return &conditionalLogger{logger: l, level: level}, true
+}
+
+// SetLevel 设置日志级别
+func (l *MemoryLogger) SetLevel(level Level) {
+	l.level = level
+}
+
+// Sync 同步日志输出（内存日志器无需同步）
+func (l *MemoryLogger) Sync() error {
+	return nil
+}
+
+// Close 关闭日志器（内存日志器无需释放资源）
+func (l *MemoryLogger) Close() error {
+	return nil
+}
@@ -4,16 +4,31 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"connectrpc.com/connect"
 	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
 )
 
 // ConnectLoggingInterceptor Connect RPC 日志拦截器
 type ConnectLoggingInterceptor struct {
 	logger Logger
 	config MiddlewareConfig
+
+	// successCount 成功请求计数器，用于成功日志采样
+	successCount atomic.Uint64
+
+	// samplers 按 procedure 维护的采样器，键为 procedure 字符串
+	samplers sync.Map
+
+	// 采样决策统计：用于核对预期与实际日志量
+	successEmitted    atomic.Uint64
+	successSampledOut atomic.Uint64
+	warnEmitted       atomic.Uint64
+	errorEmitted      atomic.Uint64
 }
 
 // 确保 ConnectLoggingInterceptor 实现 connect.Interceptor 接口
@@ -21,12 +36,26 @@ var _ connect.Interceptor = (*ConnectLoggingInterceptor)(nil)
 
 // MiddlewareConfig 中间件配置
 type MiddlewareConfig struct {
-	LogRequests     bool     // 记录请求
-	LogResponses    bool     // 记录响应
-	LogHeaders      bool     // 记录头部信息
-	SensitiveFields []string // 敏感字段列表
-	MaxBodySize     int      // 最大请求体大小（字节）
-	SlowThreshold   int      // 慢请求阈值（毫秒）
+	LogRequests       bool     // 记录请求
+	LogResponses      bool     // 记录响应
+	LogHeaders        bool     // 记录头部信息
+	SensitiveFields   []string // 敏感字段列表
+	MaxBodySize       int      // 最大请求体大小（字节）
+	SlowThreshold     int      // 慢请求阈值（毫秒）
+	SuccessSampleRate int      // 成功请求采样率：每 N 条记录 1 条，0 或 1 表示全量记录；错误和慢请求始终记录
+	StatsLogInterval  int      // 采样统计输出间隔（秒），0 表示不输出
+
+	// Sampling 按 procedure 的令牌桶采样策略，Enabled 时优先于 SuccessSampleRate
+	Sampling SamplingConfig
+}
+
+// SamplingConfig 请求日志采样策略：每个 procedure 每秒前 Initial 条全量记录，
+// 之后每 Thereafter 条记录 1 条（Thereafter <= 0 表示超额部分全部丢弃）。
+// 仅作用于请求开始日志和成功完成日志，错误和慢请求始终记录。
+type SamplingConfig struct {
+	Enabled    bool
+	Initial    int
+	Thereafter int
 }
 
 // NewConnectLoggingInterceptor 创建 Connect 日志拦截器
@@ -39,17 +68,38 @@ func NewConnectLoggingInterceptor(logger Logger, config MiddlewareConfig) *Conne
 		config.SlowThreshold = 1000 // 1秒
 	}
 
-	return &ConnectLoggingInterceptor{
+	interceptor := &ConnectLoggingInterceptor{
 		logger: logger,
 		config: config,
 	}
+
+	// 可选的周期性采样统计输出
+	if config.StatsLogInterval > 0 {
+		interceptor.StartStatsLogging(time.Duration(config.StatsLogInterval) * time.Second)
+	}
+
+	return interceptor
 }
 
+// requestIDHeader 是传递请求 ID 的标准头部
+const requestIDHeader = "X-Request-ID"
+
 // WrapUnary 包装一元 RPC 调用
 func (i *ConnectLoggingInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
 	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
 		startTime := time.Now()
-		
+
+		// 采样决策对"开始/完成"成对生效，避免只剩一半的请求日志
+		sampled := i.shouldSampleProcedure(req.Spec().Procedure)
+
+		// 确保请求 ID 存在：保留调用方传入的 X-Request-ID，缺失时生成一个；
+		// 写入上下文供下游 InfoContext 等调用自动携带
+		requestID := req.Header().Get(requestIDHeader)
+		if requestID == "" {
+			requestID = GenerateTraceID()
+		}
+		ctx = ContextWithRequestID(ctx, requestID)
+
 		// 提取基础信息
 		procedure := req.Spec().Procedure
 		baseFields := []zap.Field{
@@ -65,14 +115,21 @@ func (i *ConnectLoggingInterceptor) WrapUnary(next connect.UnaryFunc) connect.Un
 		}
 
 		// 记录请求开始
-		i.logger.InfoContext(ctx, "RPC request started", baseFields...)
+		if sampled {
+			i.logger.InfoContext(ctx, "RPC request started", baseFields...)
+		}
 
 		// 执行实际调用
 		resp, err := next(ctx, req)
-		
+
+		// 请求 ID 回写到响应头，方便客户端关联日志
+		if resp != nil {
+			resp.Header().Set(requestIDHeader, requestID)
+		}
+
 		// 计算耗时
 		duration := time.Since(startTime)
-		
+
 		// 构建响应日志字段
 		responseFields := []zap.Field{
 			zap.String("procedure", procedure),
@@ -88,12 +145,13 @@ func (i *ConnectLoggingInterceptor) WrapUnary(next connect.UnaryFunc) connect.Un
 
 		// 记录结果
 		if err != nil {
-			// 错误情况
-			errorFields := append(responseFields, 
+			// 错误情况，记录客户端实际收到的 HTTP 状态码
+			errorFields := append(responseFields,
 				zap.Error(err),
 				zap.String("status", "error"),
+				zap.Int("http_status", httpStatusFromCode(connect.CodeOf(err))),
 			)
-			
+
 			// 提取 Connect 错误信息
 			if connectErr, ok := err.(*connect.Error); ok {
 				errorFields = append(errorFields,
@@ -102,11 +160,15 @@ func (i *ConnectLoggingInterceptor) WrapUnary(next connect.UnaryFunc) connect.Un
 				)
 			}
 
+			i.errorEmitted.Add(1)
 			i.logger.ErrorContext(ctx, "RPC request failed", errorFields...)
 		} else {
 			// 成功情况
-			successFields := append(responseFields, zap.String("status", "success"))
-			
+			successFields := append(responseFields,
+				zap.String("status", "success"),
+				zap.Int("http_status", 200),
+			)
+
 			// 判断是否为慢请求
 			logLevel := "info"
 			logMsg := "RPC request completed"
@@ -117,9 +179,13 @@ func (i *ConnectLoggingInterceptor) WrapUnary(next connect.UnaryFunc) connect.Un
 			}
 
 			if logLevel == "warn" {
+				i.warnEmitted.Add(1)
 				i.logger.WarnContext(ctx, logMsg, successFields...)
-			} else {
+			} else if sampled && i.shouldLogSuccess() {
+				i.successEmitted.Add(1)
 				i.logger.InfoContext(ctx, logMsg, successFields...)
+			} else {
+				i.successSampledOut.Add(1)
 			}
 		}
 
@@ -131,7 +197,7 @@ func (i *ConnectLoggingInterceptor) WrapUnary(next connect.UnaryFunc) connect.Un
 func (i *ConnectLoggingInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
 	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
 		startTime := time.Now()
-		
+
 		baseFields := []zap.Field{
 			zap.String("method", "streaming_client"),
 			zap.String("procedure", spec.Procedure),
@@ -139,9 +205,9 @@ func (i *ConnectLoggingInterceptor) WrapStreamingClient(next connect.StreamingCl
 		}
 
 		i.logger.InfoContext(ctx, "Streaming client started", baseFields...)
-		
+
 		conn := next(ctx, spec)
-		
+
 		// 包装连接以记录流式操作
 		return &wrappedStreamingClientConn{
 			StreamingClientConn: conn,
@@ -156,7 +222,7 @@ func (i *ConnectLoggingInterceptor) WrapStreamingClient(next connect.StreamingCl
 func (i *ConnectLoggingInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
 	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
 		startTime := time.Now()
-		
+
 		baseFields := []zap.Field{
 			zap.String("method", "streaming_handler"),
 			zap.String("procedure", conn.Spec().Procedure),
@@ -164,11 +230,11 @@ func (i *ConnectLoggingInterceptor) WrapStreamingHandler(next connect.StreamingH
 		}
 
 		i.logger.InfoContext(ctx, "Streaming handler started", baseFields...)
-		
+
 		err := next(ctx, conn)
-		
+
 		duration := time.Since(startTime)
-		
+
 		responseFields := []zap.Field{
 			zap.String("procedure", conn.Spec().Procedure),
 			zap.Duration("duration", duration),
@@ -186,6 +252,136 @@ func (i *ConnectLoggingInterceptor) WrapStreamingHandler(next connect.StreamingH
 	}
 }
 
+// shouldSampleProcedure 按 procedure 执行令牌桶采样决策，未启用采样时始终放行
+func (i *ConnectLoggingInterceptor) shouldSampleProcedure(procedure string) bool {
+	if !i.config.Sampling.Enabled {
+		return true
+	}
+	v, ok := i.samplers.Load(procedure)
+	if !ok {
+		v, _ = i.samplers.LoadOrStore(procedure, &procedureSampler{})
+	}
+	return v.(*procedureSampler).allow(i.config.Sampling.Initial, i.config.Sampling.Thereafter)
+}
+
+// procedureSampler 单个 procedure 的每秒令牌桶，并发安全
+type procedureSampler struct {
+	mu          sync.Mutex
+	windowStart int64
+	seen        int64
+}
+
+// allow 报告当前这条日志是否应记录：秒级窗口内前 initial 条放行，
+// 之后每 thereafter 条放行 1 条
+func (s *procedureSampler) allow(initial, thereafter int) bool {
+	now := time.Now().Unix()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now != s.windowStart {
+		s.windowStart = now
+		s.seen = 0
+	}
+	s.seen++
+
+	if s.seen <= int64(initial) {
+		return true
+	}
+	if thereafter <= 0 {
+		return false
+	}
+	return (s.seen-int64(initial))%int64(thereafter) == 0
+}
+
+// shouldLogSuccess 判断本次成功请求是否应记录日志
+// 按 SuccessSampleRate 每 N 条记录 1 条，错误和慢请求不经过此判断，始终记录
+func (i *ConnectLoggingInterceptor) shouldLogSuccess() bool {
+	if i.config.SuccessSampleRate <= 1 {
+		return true
+	}
+	count := i.successCount.Add(1)
+	return count%uint64(i.config.SuccessSampleRate) == 1
+}
+
+// SamplingStats 采样决策统计快照
+type SamplingStats struct {
+	SuccessEmitted    uint64 `json:"success_emitted"`
+	SuccessSampledOut uint64 `json:"success_sampled_out"`
+	WarnEmitted       uint64 `json:"warn_emitted"`
+	ErrorEmitted      uint64 `json:"error_emitted"`
+}
+
+// Stats 返回当前的采样统计，供统计端点暴露
+func (i *ConnectLoggingInterceptor) Stats() SamplingStats {
+	return SamplingStats{
+		SuccessEmitted:    i.successEmitted.Load(),
+		SuccessSampledOut: i.successSampledOut.Load(),
+		WarnEmitted:       i.warnEmitted.Load(),
+		ErrorEmitted:      i.errorEmitted.Load(),
+	}
+}
+
+// StartStatsLogging 按固定间隔输出聚合的采样统计
+// 让运维能核对"缺失"的日志是被采样掉还是真正丢失，返回停止函数
+func (i *ConnectLoggingInterceptor) StartStatsLogging(interval time.Duration) func() {
+	stop := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				stats := i.Stats()
+				i.logger.Debug("RPC logging sampling stats",
+					zap.Uint64("success_emitted", stats.SuccessEmitted),
+					zap.Uint64("success_sampled_out", stats.SuccessSampledOut),
+					zap.Uint64("warn_emitted", stats.WarnEmitted),
+					zap.Uint64("error_emitted", stats.ErrorEmitted),
+					zap.Int("success_sample_rate", i.config.SuccessSampleRate),
+				)
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return func() { close(stop) }
+}
+
+// httpStatusFromCode 返回 Connect 错误码在 Connect 协议下映射的 HTTP 状态码
+// 与 connect 运行时的 connectCodeToHTTP 保持一致，保证日志里的状态码
+// 就是客户端实际收到的；非 Connect 错误由 CodeOf 归为 CodeUnknown，映射到 500
+func httpStatusFromCode(code connect.Code) int {
+	switch code {
+	case connect.CodeCanceled:
+		return 499
+	case connect.CodeInvalidArgument, connect.CodeFailedPrecondition, connect.CodeOutOfRange:
+		return 400
+	case connect.CodeDeadlineExceeded:
+		return 504
+	case connect.CodeNotFound:
+		return 404
+	case connect.CodeAlreadyExists, connect.CodeAborted:
+		return 409
+	case connect.CodePermissionDenied:
+		return 403
+	case connect.CodeResourceExhausted:
+		return 429
+	case connect.CodeUnimplemented:
+		return 501
+	case connect.CodeUnavailable:
+		return 503
+	case connect.CodeUnauthenticated:
+		return 401
+	default:
+		// unknown、internal、data_loss 等
+		return 500
+	}
+}
+
 // extractRequestFields 提取请求字段
 func (i *ConnectLoggingInterceptor) extractRequestFields(req connect.AnyRequest) []zap.Field {
 	var fields []zap.Field
@@ -206,12 +402,28 @@ func (i *ConnectLoggingInterceptor) extractRequestFields(req connect.AnyRequest)
 		}
 	}
 
-	// 添加请求大小（如果有消息体）
+	// 添加请求类型和序列化大小
 	if req.Any() != nil {
-		// 这里简化处理，实际应该根据具体的消息类型来计算大小
 		fields = append(fields, zap.String("request_type", fmt.Sprintf("%T", req.Any())))
+		fields = append(fields, i.bodySizeFields(req.Any(), "request_bytes", "request_truncated")...)
+	}
+
+	return fields
+}
+
+// bodySizeFields 在消息实现 proto.Message 时计算序列化后的字节数；
+// 超过 MaxBodySize 时额外附带截断标记，提示不应记录消息体本身
+func (i *ConnectLoggingInterceptor) bodySizeFields(msg any, sizeKey, truncatedKey string) []zap.Field {
+	pb, ok := msg.(proto.Message)
+	if !ok {
+		return nil
 	}
 
+	size := proto.Size(pb)
+	fields := []zap.Field{zap.Int(sizeKey, size)}
+	if i.config.MaxBodySize > 0 && size > i.config.MaxBodySize {
+		fields = append(fields, zap.Bool(truncatedKey, true))
+	}
 	return fields
 }
 
@@ -234,9 +446,10 @@ func (i *ConnectLoggingInterceptor) extractResponseFields(resp connect.AnyRespon
 		}
 	}
 
-	// 添加响应类型
+	// 添加响应类型和序列化大小
 	if resp.Any() != nil {
 		fields = append(fields, zap.String("response_type", fmt.Sprintf("%T", resp.Any())))
+		fields = append(fields, i.bodySizeFields(resp.Any(), "response_bytes", "response_truncated")...)
 	}
 
 	return fields
@@ -245,26 +458,26 @@ func (i *ConnectLoggingInterceptor) extractResponseFields(resp connect.AnyRespon
 // isSensitiveField 检查字段是否敏感
 func (i *ConnectLoggingInterceptor) isSensitiveField(fieldName string) bool {
 	lowerField := strings.ToLower(fieldName)
-	
+
 	// 检查配置的敏感字段
 	for _, sensitive := range i.config.SensitiveFields {
 		if strings.ToLower(sensitive) == lowerField {
 			return true
 		}
 	}
-	
+
 	// 检查常见的敏感头部
 	commonSensitive := []string{
 		"authorization", "cookie", "x-api-key", "x-auth-token",
 		"authentication", "x-access-token", "bearer", "password",
 	}
-	
+
 	for _, sensitive := range commonSensitive {
 		if lowerField == sensitive || strings.Contains(lowerField, sensitive) {
 			return true
 		}
 	}
-	
+
 	return false
 }
 
@@ -274,6 +487,41 @@ type wrappedStreamingClientConn struct {
 	logger    Logger
 	procedure string
 	startTime time.Time
+
+	messagesSent     atomic.Int64
+	messagesReceived atomic.Int64
+}
+
+// Send 发送消息并计数；仅在 debug 级别启用时记录逐条日志，
+// 字段构造在 Check 之后进行，级别更高时开销只剩一次原子自增
+func (w *wrappedStreamingClientConn) Send(msg any) error {
+	err := w.StreamingClientConn.Send(msg)
+	sent := w.messagesSent.Add(1)
+	if cl, ok := w.logger.Check(DebugLevel); ok {
+		cl.Log("Streaming message sent",
+			zap.String("procedure", w.procedure),
+			zap.String("message_type", fmt.Sprintf("%T", msg)),
+			zap.Int64("messages_sent", sent),
+		)
+	}
+	return err
+}
+
+// Receive 接收消息并计数，日志策略与 Send 相同
+func (w *wrappedStreamingClientConn) Receive(msg any) error {
+	err := w.StreamingClientConn.Receive(msg)
+	if err != nil {
+		return err
+	}
+	received := w.messagesReceived.Add(1)
+	if cl, ok := w.logger.Check(DebugLevel); ok {
+		cl.Log("Streaming message received",
+			zap.String("procedure", w.procedure),
+			zap.String("message_type", fmt.Sprintf("%T", msg)),
+			zap.Int64("messages_received", received),
+		)
+	}
+	return nil
 }
 
 func (w *wrappedStreamingClientConn) CloseRequest() error {
@@ -290,12 +538,14 @@ func (w *wrappedStreamingClientConn) CloseRequest() error {
 func (w *wrappedStreamingClientConn) CloseResponse() error {
 	err := w.StreamingClientConn.CloseResponse()
 	duration := time.Since(w.startTime)
-	
+
 	fields := []zap.Field{
 		zap.String("procedure", w.procedure),
 		zap.Duration("total_duration", duration),
+		zap.Int64("messages_sent", w.messagesSent.Load()),
+		zap.Int64("messages_received", w.messagesReceived.Load()),
 	}
-	
+
 	if err != nil {
 		fields = append(fields, zap.Error(err), zap.String("status", "error"))
 		w.logger.Error("Streaming client connection closed with error", fields...)
@@ -303,7 +553,7 @@ func (w *wrappedStreamingClientConn) CloseResponse() error {
 		fields = append(fields, zap.String("status", "success"))
 		w.logger.Info("Streaming client connection closed", fields...)
 	}
-	
+
 	return err
 }
 
@@ -345,6 +595,17 @@ func WithMaxBodySize(size int) InterceptorOption {
 	}
 }
 
+// WithSampling 启用按 procedure 的令牌桶日志采样
+func WithSampling(initial, thereafter int) InterceptorOption {
+	return func(config *MiddlewareConfig) {
+		config.Sampling = SamplingConfig{
+			Enabled:    true,
+			Initial:    initial,
+			Thereafter: thereafter,
+		}
+	}
+}
+
 // WithSlowThreshold 设置慢请求阈值
 func WithSlowThreshold(ms int) InterceptorOption {
 	return func(config *MiddlewareConfig) {
@@ -352,6 +613,20 @@ func WithSlowThreshold(ms int) InterceptorOption {
 	}
 }
 
+// WithSuccessSampleRate 设置成功请求采样率（每 N 条记录 1 条）
+func WithSuccessSampleRate(n int) InterceptorOption {
+	return func(config *MiddlewareConfig) {
+		config.SuccessSampleRate = n
+	}
+}
+
+// WithStatsLogInterval 设置采样统计的输出间隔（秒）
+func WithStatsLogInterval(seconds int) InterceptorOption {
+	return func(config *MiddlewareConfig) {
+		config.StatsLogInterval = seconds
+	}
+}
+
 // NewConnectLoggingInterceptorWithOptions 使用选项创建拦截器
 func NewConnectLoggingInterceptorWithOptions(logger Logger, options ...InterceptorOption) *ConnectLoggingInterceptor {
 	config := MiddlewareConfig{
@@ -375,8 +650,8 @@ func DefaultMiddlewareConfig() MiddlewareConfig {
 		LogRequests:   true,
 		LogResponses:  true,
 		LogHeaders:    false,
-		MaxBodySize:   4096,  // 4KB
-		SlowThreshold: 1000,  // 1秒
+		MaxBodySize:   4096, // 4KB
+		SlowThreshold: 1000, // 1秒
 		SensitiveFields: []string{
 			"password", "token", "key", "secret", "auth",
 		},
@@ -386,11 +661,11 @@ func DefaultMiddlewareConfig() MiddlewareConfig {
 // CreateInterceptors 创建 Connect 拦截器选项
 func CreateInterceptors(logger Logger, config MiddlewareConfig) []connect.Option {
 	interceptor := NewConnectLoggingInterceptor(logger, config)
-	
+
 	return []connect.Option{
 		connect.WithInterceptors(interceptor),
 	}
 }
 
 // 注意：WrapUnary、WrapStreamingClient、WrapStreamingHandler 方法已经在上面定义
-// 它们构成了 connect.Interceptor 接口的实现
\ No newline at end of file
+// 它们构成了 connect.Interceptor 接口的实现
@@ -0,0 +1,225 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestLoggingInterceptorLogsResolvedHTTPStatus(t *testing.T) {
+	mem := NewMemoryLogger()
+	interceptor := NewConnectLoggingInterceptor(mem, MiddlewareConfig{})
+
+	wrapped := interceptor.WrapUnary(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		return nil, connect.NewError(connect.CodeNotFound, errors.New("no such user"))
+	})
+
+	_, err := wrapped(context.Background(), connect.NewRequest(&emptypb.Empty{}))
+	if connect.CodeOf(err) != connect.CodeNotFound {
+		t.Fatalf("CodeOf(err) = %v, want NotFound", connect.CodeOf(err))
+	}
+
+	entries := mem.EntriesAtLevel(ErrorLevel)
+	if len(entries) != 1 {
+		t.Fatalf("got %d error entries, want 1", len(entries))
+	}
+	if !entries[0].FieldEquals("http_status", int64(404)) {
+		t.Errorf("http_status field = %v, want 404", entries[0].FieldMap()["http_status"])
+	}
+	if !entries[0].FieldEquals("error_code", "not_found") {
+		t.Errorf("error_code field = %v, want not_found", entries[0].FieldMap()["error_code"])
+	}
+}
+
+func TestSuccessSamplingKeepsAllErrors(t *testing.T) {
+	mem := NewMemoryLogger()
+	interceptor := NewConnectLoggingInterceptor(mem, MiddlewareConfig{SuccessSampleRate: 3})
+
+	success := interceptor.WrapUnary(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&emptypb.Empty{}), nil
+	})
+	failure := interceptor.WrapUnary(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		return nil, connect.NewError(connect.CodeInternal, errors.New("boom"))
+	})
+
+	for i := 0; i < 6; i++ {
+		_, _ = success(context.Background(), connect.NewRequest(&emptypb.Empty{}))
+	}
+	for i := 0; i < 3; i++ {
+		_, _ = failure(context.Background(), connect.NewRequest(&emptypb.Empty{}))
+	}
+
+	var completed, failed int
+	for _, entry := range mem.Entries() {
+		switch entry.Message {
+		case "RPC request completed":
+			completed++
+		case "RPC request failed":
+			failed++
+		}
+	}
+
+	// 每 3 条成功记录 1 条：6 次调用只留 2 条完成日志
+	if completed != 2 {
+		t.Errorf("completed entries = %d, want 2", completed)
+	}
+	// 错误不参与采样，全部记录
+	if failed != 3 {
+		t.Errorf("failed entries = %d, want 3", failed)
+	}
+}
+
+func TestSamplingStatsCountersMove(t *testing.T) {
+	mem := NewMemoryLogger()
+	interceptor := NewConnectLoggingInterceptor(mem, MiddlewareConfig{SuccessSampleRate: 2})
+
+	success := interceptor.WrapUnary(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&emptypb.Empty{}), nil
+	})
+	failure := interceptor.WrapUnary(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		return nil, connect.NewError(connect.CodeUnavailable, errors.New("down"))
+	})
+
+	for i := 0; i < 4; i++ {
+		_, _ = success(context.Background(), connect.NewRequest(&emptypb.Empty{}))
+	}
+	_, _ = failure(context.Background(), connect.NewRequest(&emptypb.Empty{}))
+
+	stats := interceptor.Stats()
+	if stats.SuccessEmitted != 2 {
+		t.Errorf("SuccessEmitted = %d, want 2", stats.SuccessEmitted)
+	}
+	if stats.SuccessSampledOut != 2 {
+		t.Errorf("SuccessSampledOut = %d, want 2", stats.SuccessSampledOut)
+	}
+	if stats.ErrorEmitted != 1 {
+		t.Errorf("ErrorEmitted = %d, want 1", stats.ErrorEmitted)
+	}
+	// 预期量与实际量必须能对上：emitted + sampled_out == 总成功数
+	if stats.SuccessEmitted+stats.SuccessSampledOut != 4 {
+		t.Errorf("success accounting does not reconcile: %+v", stats)
+	}
+}
+
+func TestHTTPStatusFromCodeMatchesConnectMapping(t *testing.T) {
+	cases := []struct {
+		code connect.Code
+		want int
+	}{
+		{connect.CodeCanceled, 499},
+		{connect.CodeInvalidArgument, 400},
+		{connect.CodeDeadlineExceeded, 504},
+		{connect.CodeNotFound, 404},
+		{connect.CodeAlreadyExists, 409},
+		{connect.CodePermissionDenied, 403},
+		{connect.CodeResourceExhausted, 429},
+		{connect.CodeFailedPrecondition, 400},
+		{connect.CodeAborted, 409},
+		{connect.CodeOutOfRange, 400},
+		{connect.CodeUnimplemented, 501},
+		{connect.CodeInternal, 500},
+		{connect.CodeUnavailable, 503},
+		{connect.CodeUnauthenticated, 401},
+	}
+	for _, tc := range cases {
+		if got := httpStatusFromCode(tc.code); got != tc.want {
+			t.Errorf("httpStatusFromCode(%v) = %d, want %d", tc.code, got, tc.want)
+		}
+	}
+}
+
+func TestBodySizeFieldsAndTruncationFlag(t *testing.T) {
+	mem := NewMemoryLogger()
+	interceptor := NewConnectLoggingInterceptor(mem, MiddlewareConfig{
+		LogRequests:  true,
+		LogResponses: true,
+		MaxBodySize:  64,
+	})
+
+	// 超过 MaxBodySize 的响应：记录字节数并带截断标记
+	large := interceptor.WrapUnary(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(wrapperspb.String(strings.Repeat("x", 500))), nil
+	})
+	if _, err := large(context.Background(), connect.NewRequest(&emptypb.Empty{})); err != nil {
+		t.Fatalf("large call: %v", err)
+	}
+
+	entry := findEntryWithField(t, mem, "response_bytes")
+	size, _ := entry.FieldMap()["response_bytes"].(int64)
+	if size <= 64 {
+		t.Errorf("response_bytes = %d, want > MaxBodySize", size)
+	}
+	if !entry.FieldEquals("response_truncated", true) {
+		t.Error("large response missing response_truncated flag")
+	}
+
+	// 小响应：有字节数字段但没有截断标记
+	mem.Reset()
+	small := interceptor.WrapUnary(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(wrapperspb.String("ok")), nil
+	})
+	if _, err := small(context.Background(), connect.NewRequest(&emptypb.Empty{})); err != nil {
+		t.Fatalf("small call: %v", err)
+	}
+
+	entry = findEntryWithField(t, mem, "response_bytes")
+	if entry.HasField("response_truncated") {
+		t.Error("small response carries response_truncated flag")
+	}
+	reqEntry := findEntryWithField(t, mem, "request_bytes")
+	if reqEntry.HasField("request_truncated") {
+		t.Error("small request carries request_truncated flag")
+	}
+}
+
+// findEntryWithField 返回首个携带指定字段的日志条目
+func findEntryWithField(t *testing.T, mem *MemoryLogger, key string) MemoryEntry {
+	t.Helper()
+	for _, entry := range mem.Entries() {
+		if entry.HasField(key) {
+			return entry
+		}
+	}
+	t.Fatalf("no log entry carries field %q", key)
+	return MemoryEntry{}
+}
+
+// benchmarkInterceptorLogVolume 压测成功请求并以 logs/op 汇报实际输出的日志量
+func benchmarkInterceptorLogVolume(b *testing.B, opts ...InterceptorOption) {
+	mem := NewMemoryLogger()
+	interceptor := NewConnectLoggingInterceptorWithOptions(mem, opts...)
+
+	wrapped := interceptor.WrapUnary(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&emptypb.Empty{}), nil
+	})
+	req := connect.NewRequest(&emptypb.Empty{})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := wrapped(context.Background(), req); err != nil {
+			b.Fatal(err)
+		}
+		// 定期清空内存日志器，避免基准测试被条目累积拖慢
+		if i%1024 == 1023 {
+			mem.Reset()
+		}
+	}
+	b.StopTimer()
+
+	stats := interceptor.Stats()
+	b.ReportMetric(float64(stats.SuccessEmitted)/float64(b.N), "logs/op")
+}
+
+func BenchmarkLoggingInterceptorUnsampled(b *testing.B) {
+	benchmarkInterceptorLogVolume(b)
+}
+
+func BenchmarkLoggingInterceptorSampled(b *testing.B) {
+	// 每秒前 10 条全量，之后每 100 条记 1 条——高 QPS 下日志量大幅下降
+	benchmarkInterceptorLogVolume(b, WithSampling(10, 100))
+}
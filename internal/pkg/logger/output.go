@@ -3,6 +3,7 @@ package logger
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sync"
@@ -16,6 +17,15 @@ import (
 type OutputManager struct {
 	cores []zapcore.Core
 	mutex sync.RWMutex
+
+	// performance 性能配置，AsyncWrite 为 true 时文件和远程输出经过异步缓冲
+	performance PerformanceConfig
+
+	// closers 需要在关闭时释放的写入器（异步缓冲、远程连接等）
+	closers []io.Closer
+
+	// remoteSyncers 远程输出的可刷新写入器，供启动自检单独刷新验证
+	remoteSyncers []interface{ Sync() error }
 }
 
 // NewOutputManager 创建输出管理器
@@ -25,8 +35,26 @@ func NewOutputManager() *OutputManager {
 	}
 }
 
+// SetPerformanceConfig 设置性能配置，需在添加输出目标之前调用
+func (om *OutputManager) SetPerformanceConfig(performance PerformanceConfig) {
+	om.mutex.Lock()
+	defer om.mutex.Unlock()
+
+	om.performance = performance
+}
+
+// maybeAsync 按性能配置决定是否用异步缓冲包装写入器
+func (om *OutputManager) maybeAsync(writer zapcore.WriteSyncer) zapcore.WriteSyncer {
+	if !om.performance.AsyncWrite {
+		return writer
+	}
+	async := newAsyncWriteSyncer(writer, om.performance.BufferSize, om.performance.FlushInterval)
+	om.closers = append(om.closers, async)
+	return async
+}
+
 // AddConsoleOutput 添加控制台输出
-func (om *OutputManager) AddConsoleOutput(level zapcore.Level, encoder zapcore.Encoder) {
+func (om *OutputManager) AddConsoleOutput(level zapcore.LevelEnabler, encoder zapcore.Encoder) {
 	om.mutex.Lock()
 	defer om.mutex.Unlock()
 
@@ -36,8 +64,32 @@ func (om *OutputManager) AddConsoleOutput(level zapcore.Level, encoder zapcore.E
 	om.cores = append(om.cores, core)
 }
 
+// AddSplitConsoleOutput 添加拆分的控制台输出
+// Error 及以上级别写入 stderr，其余级别写入 stdout，由两个互补的 LevelEnabler 控制
+func (om *OutputManager) AddSplitConsoleOutput(level zapcore.LevelEnabler, encoder zapcore.Encoder) {
+	om.addSplitConsoleCores(level, encoder, zapcore.AddSync(os.Stdout), zapcore.AddSync(os.Stderr))
+}
+
+// addSplitConsoleCores 按级别把日志拆分写入两个写入器，写入目标可注入便于测试
+func (om *OutputManager) addSplitConsoleCores(level zapcore.LevelEnabler, encoder zapcore.Encoder, stdout, stderr zapcore.WriteSyncer) {
+	om.mutex.Lock()
+	defer om.mutex.Unlock()
+
+	stdoutEnabler := zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+		return level.Enabled(l) && l < zapcore.ErrorLevel
+	})
+	stderrEnabler := zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+		return level.Enabled(l) && l >= zapcore.ErrorLevel
+	})
+
+	om.cores = append(om.cores,
+		zapcore.NewCore(encoder, stdout, stdoutEnabler),
+		zapcore.NewCore(encoder.Clone(), stderr, stderrEnabler),
+	)
+}
+
 // AddFileOutput 添加文件输出（带轮转）
-func (om *OutputManager) AddFileOutput(config InternalFileOutputConfig, level zapcore.Level, encoder zapcore.Encoder) error {
+func (om *OutputManager) AddFileOutput(config InternalFileOutputConfig, level zapcore.LevelEnabler, encoder zapcore.Encoder) error {
 	om.mutex.Lock()
 	defer om.mutex.Unlock()
 
@@ -50,28 +102,76 @@ func (om *OutputManager) AddFileOutput(config InternalFileOutputConfig, level za
 	// 使用 lumberjack 进行日志轮转
 	lumberjackLogger := &lumberjack.Logger{
 		Filename:   config.Filename,
-		MaxSize:    config.MaxSizeMB,    // MB
-		MaxBackups: config.MaxBackups,   // 保留的旧文件数量
-		MaxAge:     config.MaxAgeDays,   // 保留的天数
-		Compress:   config.Compress,     // 是否压缩
+		MaxSize:    config.MaxSizeMB,  // MB
+		MaxBackups: config.MaxBackups, // 保留的旧文件数量
+		MaxAge:     config.MaxAgeDays, // 保留的天数
+		Compress:   config.Compress,   // 是否压缩
 	}
 
-	writer := zapcore.AddSync(lumberjackLogger)
+	writer := om.maybeAsync(zapcore.AddSync(lumberjackLogger))
 	core := zapcore.NewCore(encoder, writer, level)
 	om.cores = append(om.cores, core)
 
 	return nil
 }
 
-// AddRemoteOutput 添加远程输出（预留接口）
-func (om *OutputManager) AddRemoteOutput(config InternalRemoteOutputConfig, level zapcore.Level, encoder zapcore.Encoder) error {
+// AddRemoteOutput 添加远程输出
+// http 协议按批量 NDJSON POST 发送，syslog/tcp/udp 协议写入 syslog
+// 连接失败只返回错误，由调用方决定是否降级（CreateLoggerWithOutputs 只警告不中断）
+func (om *OutputManager) AddRemoteOutput(config InternalRemoteOutputConfig, level zapcore.LevelEnabler, encoder zapcore.Encoder) error {
 	om.mutex.Lock()
 	defer om.mutex.Unlock()
 
-	// TODO: 实现远程日志输出
-	// 这里可以集成如 syslog、fluentd、elasticsearch 等
-	
-	return fmt.Errorf("remote output not implemented yet")
+	switch config.Type {
+	case "http", "https":
+		remote := newHTTPRemoteWriter(config)
+		om.closers = append(om.closers, remote)
+		writer := om.maybeAsync(zapcore.AddSync(remote))
+		om.remoteSyncers = append(om.remoteSyncers, writer)
+		om.cores = append(om.cores, zapcore.NewCore(encoder, writer, level))
+	case "syslog", "tcp", "udp":
+		core, err := newSyslogCore(config, level, encoder)
+		if err != nil {
+			return err
+		}
+		om.closers = append(om.closers, core)
+		om.remoteSyncers = append(om.remoteSyncers, core)
+		om.cores = append(om.cores, core)
+	default:
+		return fmt.Errorf("unsupported remote output type: %s", config.Type)
+	}
+
+	return nil
+}
+
+// Close 逆序关闭全部写入器：异步缓冲先排空，再关闭被包装的远程连接
+// 返回首个遇到的错误，但所有写入器都会被尝试关闭
+func (om *OutputManager) Close() error {
+	om.mutex.Lock()
+	defer om.mutex.Unlock()
+
+	var firstErr error
+	for i := len(om.closers) - 1; i >= 0; i-- {
+		if err := om.closers[i].Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	om.closers = nil
+	return firstErr
+}
+
+// SyncRemoteOutputs 刷新所有远程输出并返回首个失败
+// 供启动自检验证远程端点确实能接收日志，与文件输出的大小增长校验对应
+func (om *OutputManager) SyncRemoteOutputs() error {
+	om.mutex.RLock()
+	defer om.mutex.RUnlock()
+
+	for _, syncer := range om.remoteSyncers {
+		if err := syncer.Sync(); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // CreateTeeCore 创建组合的 Core
@@ -93,6 +193,23 @@ func (om *OutputManager) CreateTeeCore() zapcore.Core {
 	return zapcore.NewTee(om.cores...)
 }
 
+// formatOr 返回目标自带的编码格式，为空时回退到 fallback
+func formatOr(format, fallback string) string {
+	if format != "" {
+		return format
+	}
+	return fallback
+}
+
+// maybeFlatten 按配置用展平编码器包装 JSON 编码器
+// 仅作用于 JSON 路径，控制台编码器不做展平
+func maybeFlatten(config LoggerConfig, encoder zapcore.Encoder) zapcore.Encoder {
+	if config.FlattenFields {
+		return NewFlattenEncoder(encoder)
+	}
+	return encoder
+}
+
 // InternalFileOutputConfig 内部文件输出配置
 type InternalFileOutputConfig struct {
 	Filename   string
@@ -104,40 +221,57 @@ type InternalFileOutputConfig struct {
 
 // InternalRemoteOutputConfig 内部远程输出配置
 type InternalRemoteOutputConfig struct {
-	Type     string // syslog, http, grpc, tcp
-	Endpoint string
-	Protocol string
-	Timeout  int
-	TLS      bool
+	Type      string // syslog, http, grpc, tcp
+	Endpoint  string
+	Protocol  string
+	Timeout   int
+	TLS       bool
 	BatchSize int
 }
 
 // CreateLoggerWithOutputs 根据配置创建带有多输出的日志器
 func CreateLoggerWithOutputs(config LoggerConfig) (Logger, error) {
 	// 对于复杂的多输出配置，我们直接构建多输出的 Core
-	
+
 	// 构建多输出的 Core
 	outputManager := NewOutputManager()
-	level := levelToZapLevel(config.Level)
+	outputManager.SetPerformanceConfig(config.Performance)
 
-	// 添加控制台输出
+	// 所有输出共享同一个 AtomicLevel，SetLevel 可双向调整并立即生效
+	atomicLevel := zap.NewAtomicLevelAt(levelToZapLevel(config.Level))
+	level := atomicLevel
+
+	// 添加控制台输出，目标自带格式优先，否则沿用顶层 Format
 	if shouldAddConsoleOutput(config) {
-		encoder := CreateZapEncoder(config.Format)
-		outputManager.AddConsoleOutput(level, encoder)
+		var encoder zapcore.Encoder
+		if format := formatOr(config.Output.Console.Format, config.Format); format == "json" {
+			encoder = maybeFlatten(config, CreateZapEncoder(format))
+		} else {
+			// 控制台格式按配置决定颜色和时间布局
+			encoder = CreateConsoleEncoder(ConsoleEncoderOptions{
+				Colorized:  config.Output.Console.Colorized,
+				TimeFormat: config.Output.Console.TimeFormat,
+			})
+		}
+		if config.Output.Console.SplitErrorOutput {
+			outputManager.AddSplitConsoleOutput(level, encoder)
+		} else {
+			outputManager.AddConsoleOutput(level, encoder)
+		}
 	}
 
-	// 添加文件输出
+	// 添加文件输出，未指定格式时默认 JSON
 	if shouldAddFileOutput(config) {
-		encoder := CreateZapEncoder("json") // 文件通常使用JSON格式
+		encoder := maybeFlatten(config, CreateZapEncoder(formatOr(config.Output.File.Format, "json")))
 		fileConfig := createFileOutputConfig(config)
 		if err := outputManager.AddFileOutput(fileConfig, level, encoder); err != nil {
 			return nil, fmt.Errorf("failed to add file output: %w", err)
 		}
 	}
 
-	// 添加远程输出
+	// 添加远程输出，未指定格式时默认 JSON
 	if shouldAddRemoteOutput(config) {
-		encoder := CreateZapEncoder("json") // 远程输出通常使用JSON格式
+		encoder := maybeFlatten(config, CreateZapEncoder(formatOr(config.Output.Remote.Format, "json")))
 		remoteConfig := createRemoteOutputConfig(config)
 		if err := outputManager.AddRemoteOutput(remoteConfig, level, encoder); err != nil {
 			// 远程输出失败不应该阻止日志器创建，只记录警告
@@ -147,21 +281,16 @@ func CreateLoggerWithOutputs(config LoggerConfig) (Logger, error) {
 
 	// 创建组合的 Core
 	core := outputManager.CreateTeeCore()
-	
-	// 创建 zap logger
-	zapInst := zap.New(core, zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel))
 
-	// 添加服务信息作为基础字段
-	var baseFields []zap.Field
-	if config.ServiceName != "" {
-		baseFields = append(baseFields, zap.String("service", config.ServiceName))
-	}
-	if config.Version != "" {
-		baseFields = append(baseFields, zap.String("version", config.Version))
-	}
-	if config.Environment != "" {
-		baseFields = append(baseFields, zap.String("environment", config.Environment))
+	// 创建 zap logger，堆栈采集按配置的模式决定
+	options := []zap.Option{zap.AddCaller()}
+	if config.Stacktrace != StacktraceDisabled {
+		options = append(options, zap.AddStacktrace(zapcore.ErrorLevel))
 	}
+	zapInst := zap.New(core, options...)
+
+	// 添加服务信息作为基础字段
+	baseFields := buildBaseFields(config)
 
 	if len(baseFields) > 0 {
 		zapInst = zapInst.With(baseFields...)
@@ -169,11 +298,24 @@ func CreateLoggerWithOutputs(config LoggerConfig) (Logger, error) {
 
 	// 创建我们的日志器包装
 	logger := &zapLoggerInternal{
-		zap:        zapInst,
-		sugar:      zapInst.Sugar(),
-		level:      config.Level,
-		service:    config.ServiceName,
-		baseFields: baseFields,
+		zap:         zapInst,
+		sugar:       zapInst.Sugar(),
+		level:       config.Level,
+		service:     config.ServiceName,
+		baseFields:  baseFields,
+		atomicLevel: atomicLevel,
+		outputs:     outputManager,
+		redact:      config.RedactSensitiveFields,
+	}
+
+	// 可选的启动自检：验证每个输出目标都能真正接收日志
+	if config.SelfTest.Enabled {
+		if err := RunStartupSelfTest(logger, config); err != nil {
+			if config.SelfTest.FailOnError {
+				return nil, fmt.Errorf("logger self-test failed: %w", err)
+			}
+			fmt.Fprintf(os.Stderr, "Warning: logger self-test failed: %v\n", err)
+		}
 	}
 
 	return logger, nil
@@ -187,92 +329,189 @@ type zapLoggerInternal struct {
 	level      Level
 	service    string
 	baseFields []zap.Field
+
+	// atomicLevel 运行时可双向调整的级别开关，派生日志器共享同一实例
+	atomicLevel zap.AtomicLevel
+
+	// outputs 输出管理器，Close 时释放异步缓冲和远程连接；派生日志器共享
+	outputs *OutputManager
+
+	// redact 为 true 时字段经过 SanitizeFields 脱敏，与单输出路径行为一致
+	redact bool
 }
 
 // 实现 Logger 接口的所有方法
 func (l *zapLoggerInternal) Debug(msg string, fields ...zap.Field) {
-	l.zap.Debug(msg, fields...)
+	l.zap.Debug(msg, l.enhanceFields(fields...)...)
 }
 
 func (l *zapLoggerInternal) Info(msg string, fields ...zap.Field) {
-	l.zap.Info(msg, fields...)
+	l.zap.Info(msg, l.enhanceFields(fields...)...)
 }
 
 func (l *zapLoggerInternal) Warn(msg string, fields ...zap.Field) {
-	l.zap.Warn(msg, fields...)
+	l.zap.Warn(msg, l.enhanceFields(fields...)...)
 }
 
 func (l *zapLoggerInternal) Error(msg string, fields ...zap.Field) {
-	l.zap.Error(msg, fields...)
+	l.zap.Error(msg, l.enhanceFields(fields...)...)
 }
 
 func (l *zapLoggerInternal) Fatal(msg string, fields ...zap.Field) {
-	l.zap.Fatal(msg, fields...)
+	l.zap.Fatal(msg, l.enhanceFields(fields...)...)
+}
+
+func (l *zapLoggerInternal) Debugf(format string, args ...any) {
+	l.sugar.Debugf(format, args...)
+}
+
+func (l *zapLoggerInternal) Infof(format string, args ...any) {
+	l.sugar.Infof(format, args...)
+}
+
+func (l *zapLoggerInternal) Warnf(format string, args ...any) {
+	l.sugar.Warnf(format, args...)
+}
+
+func (l *zapLoggerInternal) Errorf(format string, args ...any) {
+	l.sugar.Errorf(format, args...)
 }
 
 func (l *zapLoggerInternal) DebugContext(ctx context.Context, msg string, fields ...zap.Field) {
-	// 简化实现，直接调用 Debug
-	l.Debug(msg, fields...)
+	contextFields := extractContextFields(ctx)
+	allFields := append(contextFields, fields...)
+	l.Debug(msg, allFields...)
 }
 
 func (l *zapLoggerInternal) InfoContext(ctx context.Context, msg string, fields ...zap.Field) {
-	l.Info(msg, fields...)
+	contextFields := extractContextFields(ctx)
+	allFields := append(contextFields, fields...)
+	l.Info(msg, allFields...)
 }
 
 func (l *zapLoggerInternal) WarnContext(ctx context.Context, msg string, fields ...zap.Field) {
-	l.Warn(msg, fields...)
+	contextFields := extractContextFields(ctx)
+	allFields := append(contextFields, fields...)
+	l.Warn(msg, allFields...)
 }
 
 func (l *zapLoggerInternal) ErrorContext(ctx context.Context, msg string, fields ...zap.Field) {
-	l.Error(msg, fields...)
+	contextFields := extractContextFields(ctx)
+	allFields := append(contextFields, fields...)
+	l.Error(msg, allFields...)
 }
 
 func (l *zapLoggerInternal) WithFields(fields ...zap.Field) Logger {
+	zapWithFields := l.zap.With(fields...)
 	return &zapLoggerInternal{
-		zap:        l.zap.With(fields...),
-		sugar:      l.sugar,
-		level:      l.level,
-		service:    l.service,
-		baseFields: l.baseFields,
+		zap:         zapWithFields,
+		sugar:       zapWithFields.Sugar(),
+		level:       l.level,
+		service:     l.service,
+		baseFields:  l.baseFields,
+		atomicLevel: l.atomicLevel,
+		outputs:     l.outputs,
+		redact:      l.redact,
 	}
 }
 
+// WithContext 提取上下文字段并绑定到子日志器，与 zapLogger.WithContext 行为一致
 func (l *zapLoggerInternal) WithContext(ctx context.Context) Logger {
-	return l // 简化实现
+	contextFields := extractContextFields(ctx)
+	if len(contextFields) == 0 {
+		return l
+	}
+	return l.WithFields(contextFields...)
 }
 
 func (l *zapLoggerInternal) WithService(service string) Logger {
+	zapWithService := l.zap.With(zap.String("service", service))
 	return &zapLoggerInternal{
-		zap:        l.zap.With(zap.String("service", service)),
-		sugar:      l.sugar,
-		level:      l.level,
-		service:    service,
-		baseFields: l.baseFields,
+		zap:         zapWithService,
+		sugar:       zapWithService.Sugar(),
+		level:       l.level,
+		service:     service,
+		baseFields:  l.baseFields,
+		atomicLevel: l.atomicLevel,
+		outputs:     l.outputs,
+		redact:      l.redact,
+	}
+}
+
+// enhanceFields 增强字段（敏感数据脱敏等），与 zapLogger.enhanceFields 行为一致
+func (l *zapLoggerInternal) enhanceFields(fields ...zap.Field) []zap.Field {
+	// 未开启脱敏时直接返回，保持高性能
+	if !l.redact {
+		return fields
 	}
+	return SanitizeFields(fields)
 }
 
 func (l *zapLoggerInternal) IfDebug() ConditionalLogger {
-	if l.level <= DebugLevel {
+	if l.atomicLevel.Enabled(zapcore.DebugLevel) {
 		return &conditionalLoggerInternal{logger: l, level: DebugLevel}
 	}
 	return &noopConditionalLoggerInternal{}
 }
 
 func (l *zapLoggerInternal) IfInfo() ConditionalLogger {
-	if l.level <= InfoLevel {
+	if l.atomicLevel.Enabled(zapcore.InfoLevel) {
 		return &conditionalLoggerInternal{logger: l, level: InfoLevel}
 	}
 	return &noopConditionalLoggerInternal{}
 }
 
+func (l *zapLoggerInternal) IfWarn() ConditionalLogger {
+	if l.atomicLevel.Enabled(zapcore.WarnLevel) {
+		return &conditionalLoggerInternal{logger: l, level: WarnLevel}
+	}
+	return &noopConditionalLoggerInternal{}
+}
+
+func (l *zapLoggerInternal) IfError() ConditionalLogger {
+	if l.atomicLevel.Enabled(zapcore.ErrorLevel) {
+		return &conditionalLoggerInternal{logger: l, level: ErrorLevel}
+	}
+	return &noopConditionalLoggerInternal{}
+}
+
+// Check 检查级别是否启用，启用时返回绑定到该级别的条件日志器
+func (l *zapLoggerInternal) Check(level Level) (ConditionalLogger, bool) {
+	if !l.atomicLevel.Enabled(levelToZapLevel(level)) {
+		return nil, false
+	}
+	return &conditionalLoggerInternal{logger: l, level: level}, true
+}
+
+// SetLevel 设置日志级别，通过共享的 AtomicLevel 双向调整
 func (l *zapLoggerInternal) SetLevel(level Level) {
 	l.level = level
+	l.atomicLevel.SetLevel(levelToZapLevel(level))
 }
 
 func (l *zapLoggerInternal) Sync() error {
 	return l.zap.Sync()
 }
 
+// SyncRemoteOutputs 刷新远程输出，供启动自检验证远程端点可达
+func (l *zapLoggerInternal) SyncRemoteOutputs() error {
+	if l.outputs == nil {
+		return nil
+	}
+	return l.outputs.SyncRemoteOutputs()
+}
+
+// Close 排空核心缓冲后关闭输出管理器持有的写入器
+func (l *zapLoggerInternal) Close() error {
+	syncErr := l.zap.Sync()
+	if l.outputs != nil {
+		if closeErr := l.outputs.Close(); syncErr == nil {
+			syncErr = closeErr
+		}
+	}
+	return syncErr
+}
+
 // 内部条件日志器
 type conditionalLoggerInternal struct {
 	logger Logger
@@ -285,6 +524,12 @@ func (c *conditionalLoggerInternal) Log(msg string, fields ...zap.Field) {
 		c.logger.Debug(msg, fields...)
 	case InfoLevel:
 		c.logger.Info(msg, fields...)
+	case WarnLevel:
+		c.logger.Warn(msg, fields...)
+	case ErrorLevel:
+		c.logger.Error(msg, fields...)
+	case FatalLevel:
+		c.logger.Fatal(msg, fields...)
 	}
 }
 
@@ -297,7 +542,7 @@ func (c *conditionalLoggerInternal) Logf(format string, args ...any) {
 type noopConditionalLoggerInternal struct{}
 
 func (n *noopConditionalLoggerInternal) Log(msg string, fields ...zap.Field) {}
-func (n *noopConditionalLoggerInternal) Logf(format string, args ...any)    {}
+func (n *noopConditionalLoggerInternal) Logf(format string, args ...any)     {}
 
 // shouldAddConsoleOutput 判断是否应该添加控制台输出
 func shouldAddConsoleOutput(config LoggerConfig) bool {
@@ -305,7 +550,7 @@ func shouldAddConsoleOutput(config LoggerConfig) bool {
 	if config.Format == "console" || config.Format == "" {
 		return true
 	}
-	
+
 	// 检查显式配置
 	return config.Output.Console.Enabled
 }
@@ -323,7 +568,7 @@ func shouldAddRemoteOutput(config LoggerConfig) bool {
 // createFileOutputConfig 创建文件输出配置
 func createFileOutputConfig(config LoggerConfig) InternalFileOutputConfig {
 	fileConfig := config.Output.File
-	
+
 	return InternalFileOutputConfig{
 		Filename:   fileConfig.Path,
 		MaxSizeMB:  fileConfig.MaxSize,
@@ -336,7 +581,7 @@ func createFileOutputConfig(config LoggerConfig) InternalFileOutputConfig {
 // createRemoteOutputConfig 创建远程输出配置
 func createRemoteOutputConfig(config LoggerConfig) InternalRemoteOutputConfig {
 	remoteConfig := config.Output.Remote
-	
+
 	return InternalRemoteOutputConfig{
 		Type:      remoteConfig.Protocol,
 		Endpoint:  remoteConfig.Endpoint,
@@ -367,4 +612,4 @@ func DefaultInternalRemoteOutputConfig() InternalRemoteOutputConfig {
 		TLS:       false,
 		BatchSize: 100,
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,145 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSplitConsoleOutputRoutesByLevel(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	om := NewOutputManager()
+	encoder := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+	om.addSplitConsoleCores(zapcore.DebugLevel, encoder,
+		zapcore.AddSync(&stdout), zapcore.AddSync(&stderr))
+
+	log := zap.New(om.CreateTeeCore())
+	log.Info("info goes to stdout")
+	log.Warn("warn goes to stdout")
+	log.Error("error goes to stderr")
+	_ = log.Sync()
+
+	if out := stdout.String(); !strings.Contains(out, "info goes to stdout") ||
+		!strings.Contains(out, "warn goes to stdout") {
+		t.Errorf("stdout missing info/warn entries: %q", out)
+	}
+	if out := stdout.String(); strings.Contains(out, "error goes to stderr") {
+		t.Errorf("stdout contains error entry: %q", out)
+	}
+	if out := stderr.String(); !strings.Contains(out, "error goes to stderr") {
+		t.Errorf("stderr missing error entry: %q", out)
+	}
+	if out := stderr.String(); strings.Contains(out, "info goes to stdout") {
+		t.Errorf("stderr contains info entry: %q", out)
+	}
+}
+
+// contextLoggerPair 分别构建接到独立 observer 的单输出与多输出日志器
+func contextLoggerPair() (Logger, Logger, *observer.ObservedLogs, *observer.ObservedLogs) {
+	singleCore, singleLogs := observer.New(zapcore.DebugLevel)
+	singleZap := zap.New(singleCore)
+	single := &zapLogger{
+		zap:         singleZap,
+		sugar:       singleZap.Sugar(),
+		level:       DebugLevel,
+		atomicLevel: zap.NewAtomicLevelAt(zapcore.DebugLevel),
+	}
+
+	multiCore, multiLogs := observer.New(zapcore.DebugLevel)
+	multiZap := zap.New(multiCore)
+	multi := &zapLoggerInternal{
+		zap:         multiZap,
+		sugar:       multiZap.Sugar(),
+		level:       DebugLevel,
+		atomicLevel: zap.NewAtomicLevelAt(zapcore.DebugLevel),
+	}
+
+	return single, multi, singleLogs, multiLogs
+}
+
+func TestSingleAndMultiOutputLoggersEmitSameContextFields(t *testing.T) {
+	single, multi, singleLogs, multiLogs := contextLoggerPair()
+
+	ctx := ContextWithRequestID(context.Background(), "req-abc")
+	ctx = ContextWithUserID(ctx, "user-42")
+
+	single.InfoContext(ctx, "handled request")
+	multi.InfoContext(ctx, "handled request")
+
+	singleFields := singleLogs.All()[0].ContextMap()
+	multiFields := multiLogs.All()[0].ContextMap()
+
+	for _, key := range []string{"request_id", "user_id"} {
+		if singleFields[key] != multiFields[key] {
+			t.Errorf("%s differs: single=%v multi=%v", key, singleFields[key], multiFields[key])
+		}
+		if multiFields[key] == nil {
+			t.Errorf("multi-output logger dropped context field %s", key)
+		}
+	}
+}
+
+func TestMultiOutputWithContextBindsFieldsLikeSingleOutput(t *testing.T) {
+	single, multi, singleLogs, multiLogs := contextLoggerPair()
+
+	ctx := ContextWithRequestID(context.Background(), "req-xyz")
+
+	single.WithContext(ctx).Info("bound context")
+	multi.WithContext(ctx).Info("bound context")
+
+	singleFields := singleLogs.All()[0].ContextMap()
+	multiFields := multiLogs.All()[0].ContextMap()
+
+	if singleFields["request_id"] != "req-xyz" {
+		t.Errorf("single-output request_id = %v, want req-xyz", singleFields["request_id"])
+	}
+	if multiFields["request_id"] != singleFields["request_id"] {
+		t.Errorf("multi-output request_id = %v, want same as single-output %v",
+			multiFields["request_id"], singleFields["request_id"])
+	}
+}
+
+func TestFileOutputHonorsConsoleFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	log, err := CreateLoggerWithOutputs(LoggerConfig{
+		Level:       InfoLevel,
+		Format:      "json",
+		ServiceName: "user-service",
+		Output: OutputConfig{
+			File: FileOutputConfig{
+				Enabled: true,
+				Path:    path,
+				Format:  "console",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateLoggerWithOutputs: %v", err)
+	}
+
+	log.Info("service started", zap.String("port", "8080"))
+	_ = log.Sync()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read log file: %v", err)
+	}
+	line := strings.TrimSpace(string(data))
+
+	// console 格式是可读文本行而不是 JSON 对象
+	if strings.HasPrefix(line, "{") {
+		t.Errorf("file content is JSON despite console format:\n%s", line)
+	}
+	if !strings.Contains(line, "service started") || !strings.Contains(line, "INFO") {
+		t.Errorf("console line missing message or level:\n%s", line)
+	}
+}
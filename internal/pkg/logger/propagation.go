@@ -0,0 +1,126 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"connectrpc.com/connect"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceparentHeader W3C Trace Context 标准头部（version-traceid-spanid-flags）
+const traceparentHeader = "traceparent"
+
+// ConnectPropagationInterceptor 跨服务传递请求关联信息的拦截器
+// 客户端侧把上下文中的 request_id 与追踪上下文写入出站请求头
+// （X-Request-ID 与 W3C traceparent），服务端侧把这些头部读回上下文，
+// 使网关与下游服务的日志能通过相同的 request_id / trace_id 关联
+type ConnectPropagationInterceptor struct{}
+
+// 确保 ConnectPropagationInterceptor 实现 connect.Interceptor 接口
+var _ connect.Interceptor = (*ConnectPropagationInterceptor)(nil)
+
+// NewConnectPropagationInterceptor 创建传播拦截器
+// 客户端与服务端共用同一个实现，按调用方向自动选择注入或提取
+func NewConnectPropagationInterceptor() *ConnectPropagationInterceptor {
+	return &ConnectPropagationInterceptor{}
+}
+
+// InjectTraceHeaders 把上下文中的关联信息写入出站请求头
+// 已存在的头部不覆盖，调用方显式设置的值优先
+func InjectTraceHeaders(ctx context.Context, header http.Header) {
+	if header.Get(requestIDHeader) == "" {
+		if requestID := RequestIDFromContext(ctx); requestID != "" {
+			header.Set(requestIDHeader, requestID)
+		}
+	}
+
+	if header.Get(traceparentHeader) != "" {
+		return
+	}
+
+	// 优先使用 OTel span 的追踪上下文，未启用追踪时回退到上下文键
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		header.Set(traceparentHeader, fmt.Sprintf("00-%s-%s-%s",
+			sc.TraceID().String(), sc.SpanID().String(), sc.TraceFlags().String()))
+		return
+	}
+
+	traceID := getContextValue(ctx, string(traceIDKey))
+	if len(traceID) != 32 {
+		return
+	}
+	spanID := getContextValue(ctx, string(spanIDKey))
+	if len(spanID) != 16 {
+		spanID = GenerateSpanID()
+	}
+	header.Set(traceparentHeader, fmt.Sprintf("00-%s-%s-01", traceID, spanID))
+}
+
+// ExtractTraceContext 把入站请求头中的关联信息读回上下文
+// 上下文中已有的值不覆盖，外层拦截器的处理结果优先
+func ExtractTraceContext(ctx context.Context, header http.Header) context.Context {
+	if RequestIDFromContext(ctx) == "" {
+		if requestID := header.Get(requestIDHeader); requestID != "" {
+			ctx = ContextWithRequestID(ctx, requestID)
+		}
+	}
+
+	traceID, spanID, ok := parseTraceparent(header.Get(traceparentHeader))
+	if !ok {
+		return ctx
+	}
+	if getContextValue(ctx, string(traceIDKey)) == "" {
+		ctx = ContextWithTraceID(ctx, traceID)
+	}
+	if getContextValue(ctx, string(spanIDKey)) == "" {
+		ctx = ContextWithSpanID(ctx, spanID)
+	}
+	return ctx
+}
+
+// parseTraceparent 解析 W3C traceparent 头部
+// 只接受 version 00、ID 长度合法且 trace ID 非全零的值
+func parseTraceparent(value string) (traceID, spanID string, ok bool) {
+	parts := strings.Split(value, "-")
+	if len(parts) != 4 || parts[0] != "00" {
+		return "", "", false
+	}
+	if len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return "", "", false
+	}
+	if parts[1] == strings.Repeat("0", 32) {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+// WrapUnary 包装一元 RPC 调用：客户端注入头部，服务端提取回上下文
+func (i *ConnectPropagationInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			InjectTraceHeaders(ctx, req.Header())
+		} else {
+			ctx = ExtractTraceContext(ctx, req.Header())
+		}
+		return next(ctx, req)
+	}
+}
+
+// WrapStreamingClient 包装流式客户端调用，在发起连接后注入头部
+func (i *ConnectPropagationInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		conn := next(ctx, spec)
+		InjectTraceHeaders(ctx, conn.RequestHeader())
+		return conn
+	}
+}
+
+// WrapStreamingHandler 包装流式处理器，提取头部后交给下游处理
+func (i *ConnectPropagationInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		return next(ExtractTraceContext(ctx, conn.RequestHeader()), conn)
+	}
+}
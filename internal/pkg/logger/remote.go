@@ -0,0 +1,188 @@
+package logger
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log/syslog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// httpRemoteWriter 通过 HTTP 批量发送日志的写入器
+// 日志条目按 NDJSON 缓冲，达到 BatchSize 或 Sync 时整批 POST 到远程端点
+type httpRemoteWriter struct {
+	mu        sync.Mutex
+	endpoint  string
+	batchSize int
+	client    *http.Client
+	buf       bytes.Buffer
+	count     int
+}
+
+// newHTTPRemoteWriter 创建 HTTP 远程写入器
+func newHTTPRemoteWriter(config InternalRemoteOutputConfig) *httpRemoteWriter {
+	endpoint := config.Endpoint
+	if !strings.Contains(endpoint, "://") {
+		if config.TLS {
+			endpoint = "https://" + endpoint
+		} else {
+			endpoint = "http://" + endpoint
+		}
+	}
+
+	timeout := time.Duration(config.Timeout) * time.Millisecond
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	batchSize := config.BatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+
+	client := &http.Client{Timeout: timeout}
+	if config.TLS {
+		client.Transport = &http.Transport{
+			TLSClientConfig: &tls.Config{MinVersion: tls.VersionTLS12},
+		}
+	}
+
+	return &httpRemoteWriter{
+		endpoint:  endpoint,
+		batchSize: batchSize,
+		client:    client,
+	}
+}
+
+func (w *httpRemoteWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf.Write(p)
+	w.count++
+
+	if w.count >= w.batchSize {
+		return len(p), w.flushLocked()
+	}
+	return len(p), nil
+}
+
+func (w *httpRemoteWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.flushLocked()
+}
+
+// Close 发送剩余批次并关闭空闲连接
+func (w *httpRemoteWriter) Close() error {
+	err := w.Sync()
+	w.client.CloseIdleConnections()
+	return err
+}
+
+// flushLocked 发送当前缓冲的整批日志，调用方必须持有锁
+func (w *httpRemoteWriter) flushLocked() error {
+	if w.count == 0 {
+		return nil
+	}
+
+	body := make([]byte, w.buf.Len())
+	copy(body, w.buf.Bytes())
+	w.buf.Reset()
+	w.count = 0
+
+	resp, err := w.client.Post(w.endpoint, "application/x-ndjson", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to post log batch: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("remote log endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// syslogCore 把日志写入 syslog 的 zapcore.Core，按 zap 级别映射 syslog 严重等级
+type syslogCore struct {
+	zapcore.LevelEnabler
+	writer  *syslog.Writer
+	encoder zapcore.Encoder
+}
+
+// newSyslogCore 拨号 syslog 端点并创建 Core，连接失败时返回错误由调用方降级处理
+func newSyslogCore(config InternalRemoteOutputConfig, level zapcore.LevelEnabler, encoder zapcore.Encoder) (*syslogCore, error) {
+	network := config.Type
+	if network == "syslog" {
+		network = "tcp"
+	}
+
+	writer, err := syslog.Dial(network, config.Endpoint, syslog.LOG_INFO|syslog.LOG_LOCAL0, "micro-holtye")
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial syslog endpoint %s: %w", config.Endpoint, err)
+	}
+
+	return &syslogCore{
+		LevelEnabler: level,
+		writer:       writer,
+		encoder:      encoder,
+	}, nil
+}
+
+func (c *syslogCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := c.encoder.Clone()
+	for i := range fields {
+		fields[i].AddTo(clone)
+	}
+	return &syslogCore{
+		LevelEnabler: c.LevelEnabler,
+		writer:       c.writer,
+		encoder:      clone,
+	}
+}
+
+func (c *syslogCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *syslogCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return err
+	}
+	msg := buf.String()
+	buf.Free()
+
+	switch entry.Level {
+	case zapcore.DebugLevel:
+		return c.writer.Debug(msg)
+	case zapcore.InfoLevel:
+		return c.writer.Info(msg)
+	case zapcore.WarnLevel:
+		return c.writer.Warning(msg)
+	case zapcore.ErrorLevel:
+		return c.writer.Err(msg)
+	default:
+		// dpanic、panic、fatal
+		return c.writer.Crit(msg)
+	}
+}
+
+func (c *syslogCore) Sync() error {
+	return nil
+}
+
+// Close 关闭 syslog 连接
+func (c *syslogCore) Close() error {
+	return c.writer.Close()
+}
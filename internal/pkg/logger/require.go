@@ -0,0 +1,170 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// RequireMode 必填字段缺失时的处理方式
+type RequireMode int
+
+const (
+	// RequireModeWarn 缺失时附带记录一条警告，原日志照常输出（适合生产环境）
+	RequireModeWarn RequireMode = iota
+
+	// RequireModePanic 缺失时直接 panic，便于开发阶段尽早暴露不合规的日志调用
+	RequireModePanic
+)
+
+// RequireModeForEnvironment 根据环境名选择处理方式
+// 开发环境 panic，其余环境只告警
+func RequireModeForEnvironment(environment string) RequireMode {
+	switch environment {
+	case "development", "dev":
+		return RequireModePanic
+	default:
+		return RequireModeWarn
+	}
+}
+
+// Require 包装日志器，强制每条结构化日志携带指定字段
+// 通过 WithFields 预先绑定的字段视为已满足，Printf 风格方法不做校验
+func Require(base Logger, mode RequireMode, keys ...string) Logger {
+	return &requiredFieldsLogger{
+		Logger:    base,
+		mode:      mode,
+		keys:      keys,
+		satisfied: map[string]bool{},
+	}
+}
+
+// requiredFieldsLogger 校验每条日志是否带有必填字段的包装日志器
+type requiredFieldsLogger struct {
+	Logger
+	mode RequireMode
+	keys []string
+
+	// satisfied 通过 WithFields 预先绑定、无需逐条携带的字段
+	satisfied map[string]bool
+}
+
+// 确保 requiredFieldsLogger 实现 Logger 接口
+var _ Logger = (*requiredFieldsLogger)(nil)
+
+func (l *requiredFieldsLogger) Debug(msg string, fields ...zap.Field) {
+	l.enforce(msg, fields)
+	l.Logger.Debug(msg, fields...)
+}
+
+func (l *requiredFieldsLogger) Info(msg string, fields ...zap.Field) {
+	l.enforce(msg, fields)
+	l.Logger.Info(msg, fields...)
+}
+
+func (l *requiredFieldsLogger) Warn(msg string, fields ...zap.Field) {
+	l.enforce(msg, fields)
+	l.Logger.Warn(msg, fields...)
+}
+
+func (l *requiredFieldsLogger) Error(msg string, fields ...zap.Field) {
+	l.enforce(msg, fields)
+	l.Logger.Error(msg, fields...)
+}
+
+func (l *requiredFieldsLogger) Fatal(msg string, fields ...zap.Field) {
+	l.enforce(msg, fields)
+	l.Logger.Fatal(msg, fields...)
+}
+
+func (l *requiredFieldsLogger) DebugContext(ctx context.Context, msg string, fields ...zap.Field) {
+	l.enforce(msg, fields)
+	l.Logger.DebugContext(ctx, msg, fields...)
+}
+
+func (l *requiredFieldsLogger) InfoContext(ctx context.Context, msg string, fields ...zap.Field) {
+	l.enforce(msg, fields)
+	l.Logger.InfoContext(ctx, msg, fields...)
+}
+
+func (l *requiredFieldsLogger) WarnContext(ctx context.Context, msg string, fields ...zap.Field) {
+	l.enforce(msg, fields)
+	l.Logger.WarnContext(ctx, msg, fields...)
+}
+
+func (l *requiredFieldsLogger) ErrorContext(ctx context.Context, msg string, fields ...zap.Field) {
+	l.enforce(msg, fields)
+	l.Logger.ErrorContext(ctx, msg, fields...)
+}
+
+// WithFields 绑定字段并将其记为已满足的必填字段
+func (l *requiredFieldsLogger) WithFields(fields ...zap.Field) Logger {
+	child := l.clone(l.Logger.WithFields(fields...))
+	for _, field := range fields {
+		child.satisfied[field.Key] = true
+	}
+	return child
+}
+
+// WithContext 保持包装语义的上下文派生
+func (l *requiredFieldsLogger) WithContext(ctx context.Context) Logger {
+	return l.clone(l.Logger.WithContext(ctx))
+}
+
+// WithService 保持包装语义的服务名派生
+func (l *requiredFieldsLogger) WithService(service string) Logger {
+	return l.clone(l.Logger.WithService(service))
+}
+
+// clone 基于新的底层日志器复制包装器，拷贝已满足字段集合
+func (l *requiredFieldsLogger) clone(base Logger) *requiredFieldsLogger {
+	satisfied := make(map[string]bool, len(l.satisfied))
+	for key := range l.satisfied {
+		satisfied[key] = true
+	}
+	return &requiredFieldsLogger{
+		Logger:    base,
+		mode:      l.mode,
+		keys:      l.keys,
+		satisfied: satisfied,
+	}
+}
+
+// enforce 检查必填字段，按配置的模式处理缺失
+func (l *requiredFieldsLogger) enforce(msg string, fields []zap.Field) {
+	missing := l.missingKeys(fields)
+	if len(missing) == 0 {
+		return
+	}
+
+	if l.mode == RequireModePanic {
+		panic(fmt.Sprintf("logger: log call %q missing required fields %v", msg, missing))
+	}
+
+	l.Logger.Warn("Log call missing required fields",
+		zap.String("log_msg", msg),
+		zap.Strings("missing_fields", missing),
+	)
+}
+
+// missingKeys 返回既未预先绑定也未在本次调用中携带的必填字段
+func (l *requiredFieldsLogger) missingKeys(fields []zap.Field) []string {
+	var missing []string
+	for _, key := range l.keys {
+		if l.satisfied[key] {
+			continue
+		}
+		found := false
+		for _, field := range fields {
+			if field.Key == key {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestRequirePanicsOnMissingFieldInPanicMode(t *testing.T) {
+	log := Require(NewMemoryLogger(), RequireModePanic, "operation", "component")
+
+	defer func() {
+		if recover() == nil {
+			t.Error("log call without required fields did not panic in panic mode")
+		}
+	}()
+	log.Info("order created", zap.String("operation", "create_order"))
+}
+
+func TestRequireWarnsOnMissingFieldInWarnMode(t *testing.T) {
+	mem := NewMemoryLogger()
+	log := Require(mem, RequireModeWarn, "operation")
+
+	log.Info("order created")
+
+	entries := mem.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want warning plus the original log", len(entries))
+	}
+	warning := entries[0]
+	if warning.Level != WarnLevel {
+		t.Errorf("first entry level = %v, want WarnLevel", warning.Level)
+	}
+	if !warning.HasField("missing_fields") {
+		t.Error("warning entry does not list the missing fields")
+	}
+	if entries[1].Message != "order created" {
+		t.Errorf("original log = %q, want it emitted despite the violation", entries[1].Message)
+	}
+}
+
+func TestRequireSatisfiedByBoundOrCallFields(t *testing.T) {
+	mem := NewMemoryLogger()
+	log := Require(mem, RequireModePanic, "operation", "component").
+		WithFields(zap.String("component", "order-handler"))
+
+	// component 已预先绑定，operation 随调用携带，不应触发任何处理
+	log.Info("order created", zap.String("operation", "create_order"))
+
+	if len(mem.Entries()) != 1 {
+		t.Fatalf("got %d entries, want exactly the original log", len(mem.Entries()))
+	}
+}
@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+
+	"go.uber.org/zap"
+)
+
+// RunStartupSelfTest 通过所有已配置的输出目标写入一条测试日志并校验是否被接收：
+// 文件输出要求日志文件存在且大小增长，远程输出要求刷新成功。
+// 用于在流量到达前把静默的输出故障（如错误的文件路径）变成显式的启动错误。
+func RunStartupSelfTest(log Logger, config LoggerConfig) error {
+	// 记录写入前的文件大小，用于校验文件确实增长
+	var sizeBefore int64 = -1
+	if shouldAddFileOutput(config) {
+		info, err := os.Stat(config.Output.File.Path)
+		switch {
+		case err == nil:
+			sizeBefore = info.Size()
+		case os.IsNotExist(err):
+			sizeBefore = 0
+		default:
+			return fmt.Errorf("cannot stat log file %s: %w", config.Output.File.Path, err)
+		}
+	}
+
+	// 按配置的级别写入测试日志，保证不会被级别过滤掉
+	msg := "logger startup self-test entry"
+	fields := []zap.Field{zap.String("component", "logger-selftest")}
+	switch {
+	case config.Level <= InfoLevel:
+		log.Info(msg, fields...)
+	case config.Level == WarnLevel:
+		log.Warn(msg, fields...)
+	default:
+		log.Error(msg, fields...)
+	}
+
+	// 校验远程输出：先于整体 Sync 单独刷新远程写入器，把发送失败变成显式的启动错误
+	// （整体 Sync 混杂 stdout 的平台相关错误且会清空批次缓冲，无法据其判定远程故障）
+	if shouldAddRemoteOutput(config) {
+		if rv, ok := log.(interface{ SyncRemoteOutputs() error }); ok {
+			if err := rv.SyncRemoteOutputs(); err != nil {
+				return fmt.Errorf("remote output %s did not accept test entry: %w", config.Output.Remote.Endpoint, err)
+			}
+		}
+		// 不经 OutputManager 构建的日志器没有远程写入器可验证，跳过
+	}
+
+	// Sync 在 stdout/stderr 上可能返回平台相关错误，不作为自检失败依据
+	_ = log.Sync()
+
+	// 校验文件输出：文件必须存在且比写入前更大
+	if shouldAddFileOutput(config) {
+		info, err := os.Stat(config.Output.File.Path)
+		if err != nil {
+			return fmt.Errorf("log file %s not found after test entry: %w", config.Output.File.Path, err)
+		}
+		if info.Size() <= sizeBefore {
+			return fmt.Errorf("log file %s did not grow after test entry", config.Output.File.Path)
+		}
+	}
+
+	return nil
+}
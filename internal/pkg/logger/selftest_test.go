@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestStartupSelfTestFailsOnDeadFileSink(t *testing.T) {
+	// MemoryLogger 不写文件，自检必须发现文件没有增长/不存在
+	config := LoggerConfig{Level: InfoLevel}
+	config.Output.File.Enabled = true
+	config.Output.File.Path = filepath.Join(t.TempDir(), "missing", "app.log")
+
+	if err := RunStartupSelfTest(NewMemoryLogger(), config); err == nil {
+		t.Fatal("self-test passed although the file sink never received the entry")
+	}
+}
+
+func TestStartupSelfTestPassesWithWorkingFileSink(t *testing.T) {
+	config := LoggerConfig{
+		ServiceName: "selftest",
+		Level:       InfoLevel,
+		Format:      "json",
+	}
+	config.Output.File.Enabled = true
+	config.Output.File.Path = filepath.Join(t.TempDir(), "app.log")
+
+	log, err := CreateLoggerWithOutputs(config)
+	if err != nil {
+		t.Fatalf("CreateLoggerWithOutputs: %v", err)
+	}
+	defer log.Close()
+
+	if err := RunStartupSelfTest(log, config); err != nil {
+		t.Fatalf("self-test failed with a working file sink: %v", err)
+	}
+}
+
+func TestStartupSelfTestVerifiesRemoteSink(t *testing.T) {
+	// 接受日志批次的远程端点：自检通过
+	accepting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(accepting.Close)
+
+	config := LoggerConfig{
+		ServiceName: "selftest",
+		Level:       InfoLevel,
+		Format:      "json",
+	}
+	config.Output.Remote.Enabled = true
+	config.Output.Remote.Protocol = "http"
+	config.Output.Remote.Endpoint = accepting.URL
+
+	log, err := CreateLoggerWithOutputs(config)
+	if err != nil {
+		t.Fatalf("CreateLoggerWithOutputs: %v", err)
+	}
+	defer log.Close()
+
+	if err := RunStartupSelfTest(log, config); err != nil {
+		t.Fatalf("self-test failed with an accepting remote sink: %v", err)
+	}
+}
+
+func TestStartupSelfTestFailsOnRejectingRemoteSink(t *testing.T) {
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(rejecting.Close)
+
+	config := LoggerConfig{
+		ServiceName: "selftest",
+		Level:       InfoLevel,
+		Format:      "json",
+	}
+	config.Output.Remote.Enabled = true
+	config.Output.Remote.Protocol = "http"
+	config.Output.Remote.Endpoint = rejecting.URL
+
+	log, err := CreateLoggerWithOutputs(config)
+	if err != nil {
+		t.Fatalf("CreateLoggerWithOutputs: %v", err)
+	}
+	defer log.Close()
+
+	if err := RunStartupSelfTest(log, config); err == nil {
+		t.Fatal("self-test passed although the remote sink rejects every batch")
+	}
+}
@@ -2,15 +2,22 @@ package logger
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
 	"strings"
+	"time"
 
-	"go.uber.org/zap"
 	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
 )
 
 // TracingExtractor 追踪信息提取器
 type TracingExtractor struct {
 	enabled bool
+
+	// customKeys 额外提取的上下文键，映射为 上下文键 -> 日志字段名
+	customKeys map[string]string
 }
 
 // NewTracingExtractor 创建追踪提取器
@@ -18,6 +25,18 @@ func NewTracingExtractor(enabled bool) *TracingExtractor {
 	return &TracingExtractor{enabled: enabled}
 }
 
+// RegisterContextKey 注册自定义上下文键，提取后以 fieldName 作为日志字段名
+// 默认的业务键（request_id、user_id 等）始终保留
+func (t *TracingExtractor) RegisterContextKey(contextKey, fieldName string) {
+	if contextKey == "" || fieldName == "" {
+		return
+	}
+	if t.customKeys == nil {
+		t.customKeys = make(map[string]string)
+	}
+	t.customKeys[contextKey] = fieldName
+}
+
 // ExtractTraceFields 从上下文中提取追踪字段
 func (t *TracingExtractor) ExtractTraceFields(ctx context.Context) []zap.Field {
 	if !t.enabled {
@@ -30,17 +49,17 @@ func (t *TracingExtractor) ExtractTraceFields(ctx context.Context) []zap.Field {
 	span := trace.SpanFromContext(ctx)
 	if span != nil && span.SpanContext().IsValid() {
 		spanContext := span.SpanContext()
-		
+
 		// 添加 trace ID
 		if spanContext.HasTraceID() {
 			fields = append(fields, zap.String("trace_id", spanContext.TraceID().String()))
 		}
-		
+
 		// 添加 span ID
 		if spanContext.HasSpanID() {
 			fields = append(fields, zap.String("span_id", spanContext.SpanID().String()))
 		}
-		
+
 		// 添加追踪标志
 		if spanContext.IsSampled() {
 			fields = append(fields, zap.Bool("trace_sampled", true))
@@ -62,38 +81,45 @@ func (t *TracingExtractor) ExtractAllContextFields(ctx context.Context) []zap.Fi
 	businessFields := extractBusinessContextFields(ctx)
 	fields = append(fields, businessFields...)
 
+	// 添加自定义注册的字段
+	for contextKey, fieldName := range t.customKeys {
+		if value := getContextValue(ctx, contextKey); value != "" {
+			fields = append(fields, zap.String(fieldName, value))
+		}
+	}
+
 	return fields
 }
 
 // extractBusinessContextFields 从上下文中提取业务字段
 func extractBusinessContextFields(ctx context.Context) []zap.Field {
 	var fields []zap.Field
-	
+
 	// 提取请求 ID
 	if requestID := getContextValue(ctx, "request_id", "requestID", "x-request-id"); requestID != "" {
 		fields = append(fields, zap.String("request_id", requestID))
 	}
-	
+
 	// 提取用户 ID
 	if userID := getContextValue(ctx, "user_id", "userID", "user"); userID != "" {
 		fields = append(fields, zap.String("user_id", userID))
 	}
-	
+
 	// 提取会话 ID
 	if sessionID := getContextValue(ctx, "session_id", "sessionID", "session"); sessionID != "" {
 		fields = append(fields, zap.String("session_id", sessionID))
 	}
-	
+
 	// 提取租户 ID
 	if tenantID := getContextValue(ctx, "tenant_id", "tenantID", "tenant"); tenantID != "" {
 		fields = append(fields, zap.String("tenant_id", tenantID))
 	}
-	
+
 	// 提取客户端 IP
 	if clientIP := getContextValue(ctx, "client_ip", "clientIP", "ip", "remote_addr"); clientIP != "" {
 		fields = append(fields, zap.String("client_ip", clientIP))
 	}
-	
+
 	// 提取用户代理
 	if userAgent := getContextValue(ctx, "user_agent", "userAgent", "User-Agent"); userAgent != "" {
 		fields = append(fields, zap.String("user_agent", userAgent))
@@ -103,9 +129,16 @@ func extractBusinessContextFields(ctx context.Context) []zap.Field {
 }
 
 // getContextValue 从上下文中获取值，支持多个键名
+// 优先查找类型化键，兼容期内回退到旧的字符串键
 func getContextValue(ctx context.Context, keys ...string) string {
 	for _, key := range keys {
-		if val := ctx.Value(key); val != nil {
+		if val := ctx.Value(contextKey(key)); val != nil {
+			if str, ok := val.(string); ok && str != "" {
+				return str
+			}
+		}
+		// Deprecated: 字符串键仅为兼容保留，迁移完成后移除
+		if val := ctx.Value(key); val != nil { //nolint:staticcheck
 			if str, ok := val.(string); ok && str != "" {
 				return str
 			}
@@ -118,11 +151,11 @@ func getContextValue(ctx context.Context, keys ...string) string {
 func WithTraceContext(ctx context.Context, logger Logger) Logger {
 	extractor := NewTracingExtractor(true)
 	fields := extractor.ExtractAllContextFields(ctx)
-	
+
 	if len(fields) == 0 {
 		return logger
 	}
-	
+
 	return logger.WithFields(fields...)
 }
 
@@ -144,28 +177,33 @@ func CreateTracedLogger(config LoggerConfig) (Logger, error) {
 	}
 
 	// 包装为支持追踪的日志器
+	extractor := NewTracingExtractor(config.Tracing.Enabled)
+	for contextKey, fieldName := range config.Tracing.ContextKeys {
+		extractor.RegisterContextKey(contextKey, fieldName)
+	}
+
 	return &tracedLogger{
 		logger:    logger,
-		extractor: NewTracingExtractor(config.Tracing.Enabled),
+		extractor: extractor,
 	}, nil
 }
 
 // hasMultipleOutputs 检查是否配置了多种输出
 func hasMultipleOutputs(config LoggerConfig) bool {
 	outputCount := 0
-	
+
 	if config.Output.Console.Enabled || config.Format == "console" || config.Format == "" {
 		outputCount++
 	}
-	
+
 	if config.Output.File.Enabled && config.Output.File.Path != "" {
 		outputCount++
 	}
-	
+
 	if config.Output.Remote.Enabled && config.Output.Remote.Endpoint != "" {
 		outputCount++
 	}
-	
+
 	return outputCount > 1
 }
 
@@ -196,6 +234,22 @@ func (t *tracedLogger) Fatal(msg string, fields ...zap.Field) {
 	t.logger.Fatal(msg, fields...)
 }
 
+func (t *tracedLogger) Debugf(format string, args ...any) {
+	t.logger.Debugf(format, args...)
+}
+
+func (t *tracedLogger) Infof(format string, args ...any) {
+	t.logger.Infof(format, args...)
+}
+
+func (t *tracedLogger) Warnf(format string, args ...any) {
+	t.logger.Warnf(format, args...)
+}
+
+func (t *tracedLogger) Errorf(format string, args ...any) {
+	t.logger.Errorf(format, args...)
+}
+
 // 上下文日志方法 - 这是核心功能，自动提取追踪信息
 func (t *tracedLogger) DebugContext(ctx context.Context, msg string, fields ...zap.Field) {
 	allFields := t.mergeWithContextFields(ctx, fields...)
@@ -220,17 +274,17 @@ func (t *tracedLogger) ErrorContext(ctx context.Context, msg string, fields ...z
 // mergeWithContextFields 合并上下文字段和传入字段
 func (t *tracedLogger) mergeWithContextFields(ctx context.Context, fields ...zap.Field) []zap.Field {
 	contextFields := t.extractor.ExtractAllContextFields(ctx)
-	
+
 	// 如果没有上下文字段，直接返回原字段
 	if len(contextFields) == 0 {
 		return fields
 	}
-	
+
 	// 合并字段，传入的字段优先级更高
 	allFields := make([]zap.Field, 0, len(contextFields)+len(fields))
 	allFields = append(allFields, contextFields...)
 	allFields = append(allFields, fields...)
-	
+
 	return allFields
 }
 
@@ -265,6 +319,22 @@ func (t *tracedLogger) IfInfo() ConditionalLogger {
 	return t.logger.IfInfo()
 }
 
+func (t *tracedLogger) IfWarn() ConditionalLogger {
+	return t.logger.IfWarn()
+}
+
+func (t *tracedLogger) IfError() ConditionalLogger {
+	return t.logger.IfError()
+}
+
+func (t *tracedLogger) Close() error {
+	return t.logger.Close()
+}
+
+func (t *tracedLogger) Check(level Level) (ConditionalLogger, bool) {
+	return t.logger.Check(level)
+}
+
 func (t *tracedLogger) SetLevel(level Level) {
 	t.logger.SetLevel(level)
 }
@@ -277,13 +347,13 @@ func (t *tracedLogger) Sync() error {
 type TraceableContext interface {
 	// WithTraceID 添加追踪ID
 	WithTraceID(traceID string) context.Context
-	
-	// WithSpanID 添加SpanID  
+
+	// WithSpanID 添加SpanID
 	WithSpanID(spanID string) context.Context
-	
+
 	// WithRequestID 添加请求ID
 	WithRequestID(requestID string) context.Context
-	
+
 	// WithUserID 添加用户ID
 	WithUserID(userID string) context.Context
 }
@@ -303,40 +373,40 @@ func NewTraceableContext(parent context.Context) TraceableContext {
 }
 
 func (c *contextWithValues) WithTraceID(traceID string) context.Context {
-	return context.WithValue(c.Context, "trace_id", traceID)
+	return ContextWithTraceID(c.Context, traceID)
 }
 
 func (c *contextWithValues) WithSpanID(spanID string) context.Context {
-	return context.WithValue(c.Context, "span_id", spanID)
+	return ContextWithSpanID(c.Context, spanID)
 }
 
 func (c *contextWithValues) WithRequestID(requestID string) context.Context {
-	return context.WithValue(c.Context, "request_id", requestID)
+	return ContextWithRequestID(c.Context, requestID)
 }
 
 func (c *contextWithValues) WithUserID(userID string) context.Context {
-	return context.WithValue(c.Context, "user_id", userID)
+	return ContextWithUserID(c.Context, userID)
 }
 
-// GenerateTraceID 生成追踪ID（简化版本，生产环境应使用更规范的实现）
+// GenerateTraceID 生成追踪ID（32字符十六进制）
 func GenerateTraceID() string {
-	// 这是一个简化实现，实际应该使用 OpenTelemetry 的 trace ID 生成器
-	return generateRandomHex(32) // 32字符的十六进制字符串
+	return generateRandomHex(32)
 }
 
-// GenerateSpanID 生成SpanID
+// GenerateSpanID 生成SpanID（16字符十六进制）
 func GenerateSpanID() string {
-	return generateRandomHex(16) // 16字符的十六进制字符串
+	return generateRandomHex(16)
 }
 
-// generateRandomHex 生成随机十六进制字符串
+// generateRandomHex 生成指定长度的随机十六进制字符串
+// 使用 crypto/rand 保证不可预测；读取失败时回退到基于时间戳的值，保证长度契约不变
 func generateRandomHex(length int) string {
-	const charset = "0123456789abcdef"
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = charset[len(charset)/2] // 简化实现，实际应该使用随机数
+	b := make([]byte, (length+1)/2)
+	if _, err := rand.Read(b); err != nil {
+		// 极端情况下的回退：时间戳保证可用性，但不保证唯一性
+		return fmt.Sprintf("%0*x", length, time.Now().UnixNano())[:length]
 	}
-	return string(b)
+	return hex.EncodeToString(b)[:length]
 }
 
 // IsTracingEnabled 检查追踪是否启用
@@ -348,4 +418,4 @@ func IsTracingEnabled(config LoggerConfig) bool {
 func SanitizeTraceValue(value string) string {
 	// 追踪ID通常不包含敏感信息，但可以在这里添加额外的清理逻辑
 	return strings.TrimSpace(value)
-}
\ No newline at end of file
+}
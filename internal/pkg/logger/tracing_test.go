@@ -0,0 +1,68 @@
+package logger
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRegisterContextKeyExtractsCustomField(t *testing.T) {
+	extractor := NewTracingExtractor(true)
+	extractor.RegisterContextKey("device_id", "device_id")
+
+	mem := NewMemoryLogger()
+	traced := &tracedLogger{logger: mem, extractor: extractor}
+
+	ctx := context.WithValue(context.Background(), contextKey("device_id"), "dev-42")
+	ctx = ContextWithRequestID(ctx, "req-1")
+
+	traced.InfoContext(ctx, "request handled")
+
+	entry, ok := mem.LastEntry()
+	if !ok {
+		t.Fatal("no log entry recorded")
+	}
+	if !entry.FieldEquals("device_id", "dev-42") {
+		t.Errorf("device_id field = %v, want %q", entry.FieldMap()["device_id"], "dev-42")
+	}
+	// 默认的业务键不受自定义注册影响
+	if !entry.FieldEquals("request_id", "req-1") {
+		t.Errorf("request_id field = %v, want %q", entry.FieldMap()["request_id"], "req-1")
+	}
+}
+
+func TestGeneratedIDsAreUniqueAndRightLength(t *testing.T) {
+	const iterations = 1000
+
+	traceSeen := make(map[string]bool, iterations)
+	spanSeen := make(map[string]bool, iterations)
+	for i := 0; i < iterations; i++ {
+		traceID := GenerateTraceID()
+		if len(traceID) != 32 {
+			t.Fatalf("len(GenerateTraceID()) = %d, want 32", len(traceID))
+		}
+		if traceSeen[traceID] {
+			t.Fatalf("duplicate trace ID %q after %d calls", traceID, i+1)
+		}
+		traceSeen[traceID] = true
+
+		spanID := GenerateSpanID()
+		if len(spanID) != 16 {
+			t.Fatalf("len(GenerateSpanID()) = %d, want 16", len(spanID))
+		}
+		if spanSeen[spanID] {
+			t.Fatalf("duplicate span ID %q after %d calls", spanID, i+1)
+		}
+		spanSeen[spanID] = true
+	}
+}
+
+func TestRegisterContextKeyIgnoresEmptyMapping(t *testing.T) {
+	extractor := NewTracingExtractor(true)
+	extractor.RegisterContextKey("", "field")
+	extractor.RegisterContextKey("key", "")
+
+	ctx := context.WithValue(context.Background(), contextKey("key"), "value")
+	if fields := extractor.ExtractAllContextFields(ctx); len(fields) != 0 {
+		t.Errorf("extracted %d fields from empty mappings, want 0", len(fields))
+	}
+}
@@ -2,7 +2,7 @@ package logger
 
 import (
 	"context"
-	
+
 	"go.uber.org/zap"
 )
 
@@ -15,6 +15,12 @@ type Logger interface {
 	Error(msg string, fields ...zap.Field)
 	Fatal(msg string, fields ...zap.Field)
 
+	// Printf 风格日志方法 - 便于从标准库 log/fmt 迁移的调用点
+	Debugf(format string, args ...any)
+	Infof(format string, args ...any)
+	Warnf(format string, args ...any)
+	Errorf(format string, args ...any)
+
 	// 上下文日志方法
 	DebugContext(ctx context.Context, msg string, fields ...zap.Field)
 	InfoContext(ctx context.Context, msg string, fields ...zap.Field)
@@ -26,13 +32,24 @@ type Logger interface {
 	WithContext(ctx context.Context) Logger
 	WithService(service string) Logger
 
-	// 条件日志方法
+	// 条件日志方法：级别未启用时返回空操作实现，
+	// 调用方可把昂贵的字段构造放进 Log 调用处，级别关闭时完全不执行
 	IfDebug() ConditionalLogger
 	IfInfo() ConditionalLogger
+	IfWarn() ConditionalLogger
+	IfError() ConditionalLogger
+
+	// Check 检查任意级别是否启用，未启用时返回 false
+	// 调用方可据此完全跳过字段构造，比 IfDebug/IfInfo 更通用
+	Check(level Level) (ConditionalLogger, bool)
 
 	// 配置和管理
 	SetLevel(level Level)
 	Sync() error
+
+	// Close 关闭日志器：排空异步缓冲、关闭远程连接并执行最后一次 Sync
+	// 进程退出前应调用，Sync 无法保证自定义写入器的后台资源被释放
+	Close() error
 }
 
 // ConditionalLogger 条件日志记录接口
@@ -88,7 +105,6 @@ func ParseLevel(s string) Level {
 	}
 }
 
-
 // ConnectInterceptor Connect RPC 日志中间件
 type ConnectInterceptor struct {
 	logger Logger
@@ -104,20 +120,32 @@ type InterceptorConfig struct {
 	MaxBodySize     int
 }
 
-
 // OutputConfig 输出配置
 type OutputConfig struct {
 	Console ConsoleOutputConfig
-	File    FileOutputConfig  
+	File    FileOutputConfig
 	Remote  RemoteOutputConfig
 }
 
 // ConsoleOutputConfig 控制台输出配置
 type ConsoleOutputConfig struct {
 	Enabled bool
+
+	// SplitErrorOutput 为 true 时 Error 及以上级别写入 stderr，其余级别写入 stdout
+	SplitErrorOutput bool
+
+	// Format 控制台编码格式（json / console），为空时沿用顶层 Format
+	Format string
+
+	// Colorized 为 true 时强制启用 ANSI 颜色；
+	// 为 false 时仅在 stdout 连接终端时着色，重定向到文件或管道不着色
+	Colorized bool
+
+	// TimeFormat 自定义时间布局（Go 时间格式），为空时使用 "2006-01-02 15:04:05"
+	TimeFormat string
 }
 
-// FileOutputConfig 文件输出配置  
+// FileOutputConfig 文件输出配置
 type FileOutputConfig struct {
 	Enabled    bool
 	Path       string
@@ -125,6 +153,9 @@ type FileOutputConfig struct {
 	MaxBackups int
 	MaxAge     int
 	Compress   bool
+
+	// Format 文件编码格式（json / console），为空时默认 json
+	Format string
 }
 
 // RemoteOutputConfig 远程输出配置
@@ -135,11 +166,47 @@ type RemoteOutputConfig struct {
 	BatchSize int
 	Timeout   int
 	TLS       bool
+
+	// Format 远程编码格式（json / console），为空时默认 json
+	Format string
+}
+
+// PerformanceConfig 性能配置
+type PerformanceConfig struct {
+	// AsyncWrite 为 true 时文件和远程输出经过异步缓冲，降低写入对请求路径的影响
+	AsyncWrite bool
+	// BufferSize 异步缓冲区大小（字节）
+	BufferSize int
+	// FlushInterval 后台刷新间隔（毫秒）
+	FlushInterval int
 }
 
+// SelfTestConfig 启动自检配置
+type SelfTestConfig struct {
+	// Enabled 为 true 时在日志器创建后写入一条测试日志并校验各输出目标
+	Enabled bool
+	// FailOnError 为 true 时自检失败导致日志器创建失败，否则仅输出警告
+	FailOnError bool
+}
+
+// StacktraceMode 堆栈跟踪采集模式
+type StacktraceMode int
+
+const (
+	// StacktraceManual 在 Error/Fatal 日志中附加手动采集的 stack_trace 字段（默认，保持原有行为）
+	StacktraceManual StacktraceMode = iota
+	// StacktraceZap 不附加手动字段，改用 zap 的 AddStacktrace 仅在 Error 及以上级别采集
+	StacktraceZap
+	// StacktraceDisabled 完全关闭堆栈跟踪
+	StacktraceDisabled
+)
+
 // TracingConfig 追踪配置
 type TracingConfig struct {
 	Enabled bool
+
+	// ContextKeys 额外提取的上下文键，映射为 上下文键 -> 日志字段名
+	ContextKeys map[string]string
 }
 
 // LoggerConfig 日志器配置
@@ -151,4 +218,20 @@ type LoggerConfig struct {
 	Environment string
 	Output      OutputConfig
 	Tracing     TracingConfig
-}
\ No newline at end of file
+	SelfTest    SelfTestConfig
+	Performance PerformanceConfig
+
+	// Stacktrace 堆栈跟踪采集模式，按环境选择：开发环境手动采集、
+	// 生产环境交给 zap 仅在 Error 级别采集、测试环境完全关闭
+	Stacktrace StacktraceMode
+
+	// IncludeHostInfo 为 true 时在基础字段中加入主机名和进程 PID，便于多副本排查
+	IncludeHostInfo bool
+
+	// RedactSensitiveFields 为 true 时日志字段经过 SanitizeFields 脱敏后再输出
+	RedactSensitiveFields bool
+
+	// FlattenFields 为 true 时 JSON 输出把嵌套对象展平为点号连接的键，
+	// 并按键名排序保证字段顺序稳定，适配要求严格 schema 的日志管道
+	FlattenFields bool
+}
@@ -0,0 +1,231 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Gauge 可增减的瞬时值
+type Gauge struct {
+	value atomic.Int64
+}
+
+// Inc 瞬时值加一
+func (g *Gauge) Inc() {
+	g.value.Add(1)
+}
+
+// Dec 瞬时值减一
+func (g *Gauge) Dec() {
+	g.value.Add(-1)
+}
+
+// Set 设置瞬时值
+func (g *Gauge) Set(v int64) {
+	g.value.Store(v)
+}
+
+// Value 返回当前瞬时值
+func (g *Gauge) Value() int64 {
+	return g.value.Load()
+}
+
+// GaugeSnapshot 瞬时值的时间点快照
+type GaugeSnapshot struct {
+	Name   string
+	Labels map[string]string
+	Value  int64
+}
+
+type gaugeEntry struct {
+	name   string
+	labels map[string]string
+	gauge  *Gauge
+}
+
+// DefaultLatencyBuckets 延迟直方图的默认桶上界（秒）
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Histogram 固定桶直方图，最后一桶为 +Inf
+type Histogram struct {
+	mu     sync.Mutex
+	bounds []float64
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func newHistogram(bounds []float64) *Histogram {
+	copied := make([]float64, len(bounds))
+	copy(copied, bounds)
+	return &Histogram{
+		bounds: copied,
+		counts: make([]uint64, len(copied)+1),
+	}
+}
+
+// Observe 记录一个样本
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	idx := sort.SearchFloat64s(h.bounds, v)
+	h.counts[idx]++
+	h.sum += v
+	h.count++
+}
+
+// BucketCount 单个桶的上界与累计计数
+type BucketCount struct {
+	// UpperBound 桶的上界（含），最后一桶为 +Inf
+	UpperBound float64
+	// Count 累计计数，包含所有更小的桶
+	Count uint64
+}
+
+// HistogramSnapshot 直方图的时间点快照
+type HistogramSnapshot struct {
+	Name    string
+	Labels  map[string]string
+	Count   uint64
+	Sum     float64
+	Buckets []BucketCount
+}
+
+type histogramEntry struct {
+	name      string
+	labels    map[string]string
+	histogram *Histogram
+}
+
+// Gauge 返回指定名称和标签组合的瞬时值，不存在时创建
+func (r *Registry) Gauge(name string, labels map[string]string) *Gauge {
+	key := counterKey(name, labels)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.gauges[key]
+	if !ok {
+		copied := make(map[string]string, len(labels))
+		for k, v := range labels {
+			copied[k] = v
+		}
+		entry = &gaugeEntry{
+			name:   name,
+			labels: copied,
+			gauge:  &Gauge{},
+		}
+		r.gauges[key] = entry
+	}
+
+	return entry.gauge
+}
+
+// Histogram 返回指定名称和标签组合的直方图，不存在时创建
+// bounds 为空时使用 DefaultLatencyBuckets
+func (r *Registry) Histogram(name string, labels map[string]string, bounds []float64) *Histogram {
+	if len(bounds) == 0 {
+		bounds = DefaultLatencyBuckets
+	}
+	key := counterKey(name, labels)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.histograms[key]
+	if !ok {
+		copied := make(map[string]string, len(labels))
+		for k, v := range labels {
+			copied[k] = v
+		}
+		entry = &histogramEntry{
+			name:      name,
+			labels:    copied,
+			histogram: newHistogram(bounds),
+		}
+		r.histograms[key] = entry
+	}
+
+	return entry.histogram
+}
+
+// GaugeSnapshots 返回所有瞬时值的当前快照，按名称和标签排序保证输出稳定
+func (r *Registry) GaugeSnapshots() []GaugeSnapshot {
+	r.mu.Lock()
+	keys := make([]string, 0, len(r.gauges))
+	for key := range r.gauges {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	snapshots := make([]GaugeSnapshot, 0, len(keys))
+	for _, key := range keys {
+		entry := r.gauges[key]
+		labels := make(map[string]string, len(entry.labels))
+		for k, v := range entry.labels {
+			labels[k] = v
+		}
+		snapshots = append(snapshots, GaugeSnapshot{
+			Name:   entry.name,
+			Labels: labels,
+			Value:  entry.gauge.Value(),
+		})
+	}
+	r.mu.Unlock()
+
+	return snapshots
+}
+
+// HistogramSnapshots 返回所有直方图的当前快照，按名称和标签排序保证输出稳定
+func (r *Registry) HistogramSnapshots() []HistogramSnapshot {
+	r.mu.Lock()
+	keys := make([]string, 0, len(r.histograms))
+	for key := range r.histograms {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	snapshots := make([]HistogramSnapshot, 0, len(keys))
+	for _, key := range keys {
+		entry := r.histograms[key]
+		labels := make(map[string]string, len(entry.labels))
+		for k, v := range entry.labels {
+			labels[k] = v
+		}
+		snapshots = append(snapshots, entry.histogram.snapshot(entry.name, labels))
+	}
+	r.mu.Unlock()
+
+	return snapshots
+}
+
+// snapshot 构建直方图快照，桶计数按累计方式输出
+func (h *Histogram) snapshot(name string, labels map[string]string) HistogramSnapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	buckets := make([]BucketCount, 0, len(h.counts))
+	var cumulative uint64
+	for idx, count := range h.counts {
+		cumulative += count
+		upperBound := math.Inf(1)
+		if idx < len(h.bounds) {
+			upperBound = h.bounds[idx]
+		}
+		buckets = append(buckets, BucketCount{
+			UpperBound: upperBound,
+			Count:      cumulative,
+		})
+	}
+
+	return HistogramSnapshot{
+		Name:    name,
+		Labels:  labels,
+		Count:   h.count,
+		Sum:     h.sum,
+		Buckets: buckets,
+	}
+}
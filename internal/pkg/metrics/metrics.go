@@ -0,0 +1,167 @@
+// Package metrics 提供轻量的进程内指标注册表
+// 支持带标签的计数器、瞬时值和直方图，通过快照接口暴露给日志或监控端点
+package metrics
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Counter 单调递增计数器
+type Counter struct {
+	value atomic.Uint64
+}
+
+// Inc 计数加一
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+// Add 计数增加 n
+func (c *Counter) Add(n uint64) {
+	c.value.Add(n)
+}
+
+// Value 返回当前计数
+func (c *Counter) Value() uint64 {
+	return c.value.Load()
+}
+
+// CounterSnapshot 计数器的时间点快照
+type CounterSnapshot struct {
+	Name   string
+	Labels map[string]string
+	Value  uint64
+}
+
+type counterEntry struct {
+	name    string
+	labels  map[string]string
+	counter *Counter
+}
+
+// Registry 进程内指标注册表
+type Registry struct {
+	mu         sync.Mutex
+	counters   map[string]*counterEntry
+	gauges     map[string]*gaugeEntry
+	histograms map[string]*histogramEntry
+}
+
+// NewRegistry 创建空的指标注册表
+func NewRegistry() *Registry {
+	return &Registry{
+		counters:   make(map[string]*counterEntry),
+		gauges:     make(map[string]*gaugeEntry),
+		histograms: make(map[string]*histogramEntry),
+	}
+}
+
+// Counter 返回指定名称和标签组合的计数器，不存在时创建
+func (r *Registry) Counter(name string, labels map[string]string) *Counter {
+	key := counterKey(name, labels)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.counters[key]
+	if !ok {
+		copied := make(map[string]string, len(labels))
+		for k, v := range labels {
+			copied[k] = v
+		}
+		entry = &counterEntry{
+			name:    name,
+			labels:  copied,
+			counter: &Counter{},
+		}
+		r.counters[key] = entry
+	}
+
+	return entry.counter
+}
+
+// Snapshot 返回所有计数器的当前快照，按名称和标签排序保证输出稳定
+func (r *Registry) Snapshot() []CounterSnapshot {
+	r.mu.Lock()
+	keys := make([]string, 0, len(r.counters))
+	for key := range r.counters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	snapshots := make([]CounterSnapshot, 0, len(keys))
+	for _, key := range keys {
+		entry := r.counters[key]
+		labels := make(map[string]string, len(entry.labels))
+		for k, v := range entry.labels {
+			labels[k] = v
+		}
+		snapshots = append(snapshots, CounterSnapshot{
+			Name:   entry.name,
+			Labels: labels,
+			Value:  entry.counter.Value(),
+		})
+	}
+	r.mu.Unlock()
+
+	return snapshots
+}
+
+// StartFlush 启动后台协程，每隔 interval 将当前快照交给 flush 处理
+// 返回停止函数；注册表为空时不触发 flush
+func (r *Registry) StartFlush(interval time.Duration, flush func([]CounterSnapshot)) func() {
+	stop := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if snapshots := r.Snapshot(); len(snapshots) > 0 {
+					flush(snapshots)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return func() { close(stop) }
+}
+
+// counterKey 构建计数器的唯一键，标签按名称排序保证稳定
+func counterKey(name string, labels map[string]string) string {
+	if len(labels) == 0 {
+		return name
+	}
+
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString(name)
+	for _, k := range keys {
+		sb.WriteString("|")
+		sb.WriteString(k)
+		sb.WriteString("=")
+		sb.WriteString(labels[k])
+	}
+	return sb.String()
+}
+
+// 全局默认注册表，供各服务的插桩代码共享
+var defaultRegistry = NewRegistry()
+
+// Default 返回全局默认注册表
+func Default() *Registry {
+	return defaultRegistry
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"micro-holtye/internal/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// AccessLogHandler 为非 Connect 路由（如 /health 和管理端点）记录访问日志
+// Connect 处理器已有自己的日志拦截器，挂载在 mux 上的普通 HTTP 端点用此包装
+// 记录方法、匹配的路由模板、状态码和耗时
+func AccessLogHandler(log logger.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		startTime := time.Now()
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		// Go 1.22+ 的 ServeMux 会在匹配后填充 Pattern
+		pattern := r.Pattern
+		if pattern == "" {
+			pattern = r.URL.Path
+		}
+
+		log.InfoContext(r.Context(), "HTTP request completed",
+			zap.String("method", r.Method),
+			zap.String("pattern", pattern),
+			zap.Int("status", recorder.status),
+			zap.Duration("duration", time.Since(startTime)),
+			zap.String("remote_addr", r.RemoteAddr),
+		)
+	})
+}
+
+// statusRecorder 记录写入的状态码
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
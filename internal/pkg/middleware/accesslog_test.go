@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"micro-holtye/internal/pkg/logger"
+)
+
+func TestAccessLogHandlerLogsHealthEndpoint(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	mem := logger.NewMemoryLogger()
+	server := httptest.NewServer(AccessLogHandler(mem, mux))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/health")
+	if err != nil {
+		t.Fatalf("GET /health: %v", err)
+	}
+	resp.Body.Close()
+
+	entries := mem.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(entries))
+	}
+	entry := entries[0]
+	if entry.Message != "HTTP request completed" {
+		t.Errorf("message = %q, want HTTP request completed", entry.Message)
+	}
+	if !entry.FieldEquals("method", "GET") {
+		t.Errorf("method field = %v, want GET", entry.FieldMap()["method"])
+	}
+	if !entry.FieldEquals("pattern", "/health") {
+		t.Errorf("pattern field = %v, want /health", entry.FieldMap()["pattern"])
+	}
+	if !entry.FieldEquals("status", int64(http.StatusOK)) {
+		t.Errorf("status field = %v, want 200", entry.FieldMap()["status"])
+	}
+}
+
+func TestAccessLogHandlerRecordsErrorStatus(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/admin/loglevel", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad request", http.StatusBadRequest)
+	})
+
+	mem := logger.NewMemoryLogger()
+	server := httptest.NewServer(AccessLogHandler(mem, mux))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/admin/loglevel")
+	if err != nil {
+		t.Fatalf("GET /admin/loglevel: %v", err)
+	}
+	resp.Body.Close()
+
+	entry, ok := mem.LastEntry()
+	if !ok {
+		t.Fatal("no log entry recorded")
+	}
+	if !entry.FieldEquals("status", int64(http.StatusBadRequest)) {
+		t.Errorf("status field = %v, want 400", entry.FieldMap()["status"])
+	}
+}
@@ -0,0 +1,223 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"micro-holtye/internal/pkg/logger"
+
+	"connectrpc.com/connect"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+// ClientLimiter 客户端维度限流器的抽象
+// retryAfter 表示建议的重试等待时间，仅在拒绝时有意义；
+// err 非空表示限流器自身不可用（如 Redis 故障），调用方据此放行
+type ClientLimiter interface {
+	Allow(ctx context.Context, key string) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// MemoryClientLimiter 进程内的令牌桶限流器，按 key 惰性创建
+// 单实例部署的默认选择；多实例部署需共享配额时使用 RedisClientLimiter
+type MemoryClientLimiter struct {
+	mu       sync.Mutex
+	rate     float64
+	burst    int
+	limiters map[string]*rate.Limiter
+}
+
+// NewMemoryClientLimiter 创建进程内令牌桶限流器
+// ratePerSecond 为每秒补充的令牌数，burst 为桶容量，非法值回退到默认值
+func NewMemoryClientLimiter(ratePerSecond float64, burst int) *MemoryClientLimiter {
+	if ratePerSecond <= 0 {
+		ratePerSecond = 10
+	}
+	if burst <= 0 {
+		burst = int(ratePerSecond)
+	}
+	return &MemoryClientLimiter{
+		rate:     ratePerSecond,
+		burst:    burst,
+		limiters: make(map[string]*rate.Limiter),
+	}
+}
+
+// Allow 消耗一个令牌，令牌不足时拒绝并给出下一个令牌的等待时间
+func (l *MemoryClientLimiter) Allow(_ context.Context, key string) (bool, time.Duration, error) {
+	l.mu.Lock()
+	limiter, ok := l.limiters[key]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Limit(l.rate), l.burst)
+		l.limiters[key] = limiter
+	}
+	l.mu.Unlock()
+
+	reservation := limiter.Reserve()
+	if !reservation.OK() {
+		return false, time.Second, nil
+	}
+	if delay := reservation.Delay(); delay > 0 {
+		// 有等待时间说明当前令牌不足，取消预约避免占用后续配额
+		reservation.Cancel()
+		return false, delay, nil
+	}
+	return true, 0, nil
+}
+
+// RedisClientLimiter 基于 Redis 固定窗口计数的限流器
+// 以 1 秒为窗口对每个 key 计数，是令牌桶的近似实现，
+// 多实例部署时各实例共享同一配额
+type RedisClientLimiter struct {
+	client *redis.Client
+	prefix string
+	limit  int64
+}
+
+// NewRedisClientLimiter 创建 Redis 限流器
+// ratePerSecond 与 burst 合并为每秒窗口内的请求上限（取两者较大值）
+func NewRedisClientLimiter(client *redis.Client, prefix string, ratePerSecond float64, burst int) *RedisClientLimiter {
+	limit := int64(math.Max(ratePerSecond, float64(burst)))
+	if limit <= 0 {
+		limit = 10
+	}
+	return &RedisClientLimiter{
+		client: client,
+		prefix: prefix,
+		limit:  limit,
+	}
+}
+
+// Allow 在当前秒窗口内计数，超过上限时拒绝到下一个窗口
+func (l *RedisClientLimiter) Allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	now := time.Now()
+	windowKey := fmt.Sprintf("%s:ratelimit:%s:%d", l.prefix, key, now.Unix())
+
+	pipe := l.client.TxPipeline()
+	countCmd := pipe.Incr(ctx, windowKey)
+	// 过期时间略长于窗口，保证跨窗口边界的请求也能读到计数
+	pipe.Expire(ctx, windowKey, 2*time.Second)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, 0, err
+	}
+
+	if countCmd.Val() > l.limit {
+		// 等到下一个窗口开始
+		retryAfter := time.Second - time.Duration(now.Nanosecond())
+		return false, retryAfter, nil
+	}
+	return true, 0, nil
+}
+
+// ConnectRateLimitInterceptor 按客户端限流的拦截器
+// 已认证请求以上下文中的 user_id 为键，匿名请求以客户端 IP 为键，
+// 超限时返回 CodeResourceExhausted 并附带 Retry-After 响应头
+// 与按租户限流的 RateLimitInterceptor 互补，用于基础的滥用防护
+type ConnectRateLimitInterceptor struct {
+	limiter ClientLimiter
+	rate    float64
+}
+
+// 确保 ConnectRateLimitInterceptor 实现 connect.Interceptor 接口
+var _ connect.Interceptor = (*ConnectRateLimitInterceptor)(nil)
+
+var errClientRateLimited = errors.New("request rate limit exceeded for client")
+
+// NewConnectRateLimitInterceptor 创建按客户端限流的拦截器
+// ratePerSecond 仅用于日志展示当前配额，实际限流由 limiter 决定
+func NewConnectRateLimitInterceptor(limiter ClientLimiter, ratePerSecond float64) *ConnectRateLimitInterceptor {
+	return &ConnectRateLimitInterceptor{
+		limiter: limiter,
+		rate:    ratePerSecond,
+	}
+}
+
+// rateLimitKey 构造限流键：优先使用已认证的 user_id，否则回退到客户端 IP
+func rateLimitKey(ctx context.Context, header http.Header, peerAddr string) string {
+	if userID := logger.UserIDFromContext(ctx); userID != "" {
+		return "user:" + userID
+	}
+	return "ip:" + clientIP(header, peerAddr)
+}
+
+// clientIP 提取客户端 IP：优先取 X-Forwarded-For 的首个地址（代理场景），
+// 否则取对端地址的主机部分
+func clientIP(header http.Header, peerAddr string) string {
+	if forwarded := header.Get("X-Forwarded-For"); forwarded != "" {
+		if idx := strings.Index(forwarded, ","); idx >= 0 {
+			forwarded = forwarded[:idx]
+		}
+		return strings.TrimSpace(forwarded)
+	}
+	if host, _, err := net.SplitHostPort(peerAddr); err == nil {
+		return host
+	}
+	return peerAddr
+}
+
+// check 执行一次限流判定，拒绝时返回带 Retry-After 的错误
+// 限流器自身不可用时放行（fail-open），避免限流组件故障放大为全站不可用
+func (i *ConnectRateLimitInterceptor) check(ctx context.Context, header http.Header, peerAddr string) error {
+	key := rateLimitKey(ctx, header, peerAddr)
+
+	allowed, retryAfter, err := i.limiter.Allow(ctx, key)
+	if err != nil {
+		logger.Warn("Rate limiter unavailable, failing open",
+			zap.String("rate_limit_key", key),
+			zap.Error(err),
+		)
+		return nil
+	}
+	if allowed {
+		return nil
+	}
+
+	retrySeconds := int(math.Ceil(retryAfter.Seconds()))
+	if retrySeconds < 1 {
+		retrySeconds = 1
+	}
+
+	logger.Warn("Request throttled by client rate limit",
+		zap.String("rate_limit_key", key),
+		zap.Float64("rate_per_second", i.rate),
+		zap.Int("retry_after_seconds", retrySeconds),
+	)
+
+	cerr := connect.NewError(connect.CodeResourceExhausted, errClientRateLimited)
+	cerr.Meta().Set("Retry-After", strconv.Itoa(retrySeconds))
+	return cerr
+}
+
+// WrapUnary 包装一元 RPC 调用
+func (i *ConnectRateLimitInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if err := i.check(ctx, req.Header(), req.Peer().Addr); err != nil {
+			return nil, err
+		}
+		return next(ctx, req)
+	}
+}
+
+// WrapStreamingClient 包装流式客户端调用（客户端侧不做限制）
+func (i *ConnectRateLimitInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+// WrapStreamingHandler 包装流式处理器，按流计一次配额
+func (i *ConnectRateLimitInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		if err := i.check(ctx, conn.RequestHeader(), conn.Peer().Addr); err != nil {
+			return err
+		}
+		return next(ctx, conn)
+	}
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"micro-holtye/internal/pkg/logger"
+
+	"connectrpc.com/connect"
+)
+
+// clientLimitPass 以指定的请求头和对端地址连续判定 n 次，返回被放行的次数
+func clientLimitPass(i *ConnectRateLimitInterceptor, ctx context.Context, header http.Header, peerAddr string, n int) int {
+	allowed := 0
+	for j := 0; j < n; j++ {
+		if err := i.check(ctx, header, peerAddr); err == nil {
+			allowed++
+		}
+	}
+	return allowed
+}
+
+func TestClientRateLimitAllowsBurstThenThrottles(t *testing.T) {
+	interceptor := NewConnectRateLimitInterceptor(NewMemoryClientLimiter(1, 5), 1)
+	header := http.Header{}
+
+	// 突发：桶容量内的请求全部放行，之后立刻被限
+	if allowed := clientLimitPass(interceptor, context.Background(), header, "10.0.0.1:1234", 10); allowed != 5 {
+		t.Errorf("burst allowed %d of 10 requests, want 5 (bucket capacity)", allowed)
+	}
+
+	// 不同客户端 IP 各有独立的桶
+	if allowed := clientLimitPass(interceptor, context.Background(), header, "10.0.0.2:1234", 3); allowed != 3 {
+		t.Errorf("second client allowed %d of 3 requests, want all 3", allowed)
+	}
+}
+
+func TestClientRateLimitSteadyStateRefills(t *testing.T) {
+	// 每秒 100 个令牌：耗尽桶后稳定速率下很快恢复放行
+	interceptor := NewConnectRateLimitInterceptor(NewMemoryClientLimiter(100, 1), 100)
+	header := http.Header{}
+	ctx := context.Background()
+
+	if err := interceptor.check(ctx, header, "10.0.0.1:1234"); err != nil {
+		t.Fatalf("first request rejected: %v", err)
+	}
+	if err := interceptor.check(ctx, header, "10.0.0.1:1234"); err == nil {
+		t.Fatal("request allowed with an empty bucket")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if err := interceptor.check(ctx, header, "10.0.0.1:1234"); err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("bucket never refilled at steady rate")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestClientRateLimitRejectionCarriesRetryAfter(t *testing.T) {
+	interceptor := NewConnectRateLimitInterceptor(NewMemoryClientLimiter(1, 1), 1)
+	header := http.Header{}
+	ctx := context.Background()
+
+	if err := interceptor.check(ctx, header, "10.0.0.1:1234"); err != nil {
+		t.Fatalf("first request rejected: %v", err)
+	}
+	err := interceptor.check(ctx, header, "10.0.0.1:1234")
+	if code := connect.CodeOf(err); code != connect.CodeResourceExhausted {
+		t.Fatalf("CodeOf(err) = %v, want %v", code, connect.CodeResourceExhausted)
+	}
+	var connectErr *connect.Error
+	if !errors.As(err, &connectErr) {
+		t.Fatalf("error is not a *connect.Error: %v", err)
+	}
+	if connectErr.Meta().Get("Retry-After") == "" {
+		t.Error("throttled response missing Retry-After header")
+	}
+}
+
+func TestClientRateLimitKeysOnAuthenticatedUser(t *testing.T) {
+	interceptor := NewConnectRateLimitInterceptor(NewMemoryClientLimiter(1, 1), 1)
+	header := http.Header{}
+	ctx := logger.ContextWithUserID(context.Background(), "user-1")
+
+	// 同一用户换 IP 仍共享配额
+	if err := interceptor.check(ctx, header, "10.0.0.1:1234"); err != nil {
+		t.Fatalf("first request rejected: %v", err)
+	}
+	if err := interceptor.check(ctx, header, "10.0.0.99:1234"); err == nil {
+		t.Error("authenticated user escaped the limit by changing IP")
+	}
+}
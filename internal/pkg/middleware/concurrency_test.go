@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"micro-holtye/internal/pkg/metrics"
+
+	"connectrpc.com/connect"
+)
+
+func TestConcurrencyLimitRejectsWhenSaturated(t *testing.T) {
+	interceptor := NewConcurrencyLimitInterceptor(1)
+
+	blockerStarted := make(chan struct{})
+	releaseBlocker := make(chan struct{})
+	blocker := interceptor.WrapUnary(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		close(blockerStarted)
+		<-releaseBlocker
+		return nil, nil
+	})
+
+	blockerDone := make(chan struct{})
+	go func() {
+		defer close(blockerDone)
+		_, _ = blocker(context.Background(), nil)
+	}()
+	<-blockerStarted
+
+	if got := interceptor.InFlight(); got != 1 {
+		t.Errorf("InFlight = %d, want 1", got)
+	}
+
+	// 名额已被占满，带超时的第二个请求在排队中耗尽上下文后被拒绝
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	rejected := interceptor.WrapUnary(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		t.Error("handler ran although the limiter was saturated")
+		return nil, nil
+	})
+	_, err := rejected(ctx, nil)
+	if err == nil {
+		t.Fatal("saturated limiter let the request through, want CodeResourceExhausted")
+	}
+	if code := connect.CodeOf(err); code != connect.CodeResourceExhausted {
+		t.Errorf("CodeOf(err) = %v, want %v", code, connect.CodeResourceExhausted)
+	}
+
+	close(releaseBlocker)
+	<-blockerDone
+
+	if got := interceptor.InFlight(); got != 0 {
+		t.Errorf("InFlight after release = %d, want 0", got)
+	}
+}
+
+func TestConcurrencyLimitQueuesUntilSlotFrees(t *testing.T) {
+	interceptor := NewConcurrencyLimitInterceptor(1)
+
+	blockerStarted := make(chan struct{})
+	releaseBlocker := make(chan struct{})
+	blocker := interceptor.WrapUnary(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		close(blockerStarted)
+		<-releaseBlocker
+		return nil, nil
+	})
+	go func() { _, _ = blocker(context.Background(), nil) }()
+	<-blockerStarted
+
+	// 排队的请求在名额释放后继续执行，而不是立刻失败
+	queuedDone := make(chan error, 1)
+	queued := interceptor.WrapUnary(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		return nil, nil
+	})
+	go func() {
+		_, err := queued(context.Background(), nil)
+		queuedDone <- err
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	close(releaseBlocker)
+
+	select {
+	case err := <-queuedDone:
+		if err != nil {
+			t.Errorf("queued request failed: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("queued request did not run after the slot freed")
+	}
+}
+
+func queueWaitSnapshot() metrics.HistogramSnapshot {
+	for _, snap := range metrics.Default().HistogramSnapshots() {
+		if snap.Name == "rpc_queue_wait_seconds" {
+			return snap
+		}
+	}
+	return metrics.HistogramSnapshot{}
+}
+
+func TestConcurrencyLimitRecordsQueueWaitSamples(t *testing.T) {
+	interceptor := NewConcurrencyLimitInterceptor(1)
+	before := queueWaitSnapshot()
+
+	blockerStarted := make(chan struct{})
+	releaseBlocker := make(chan struct{})
+	blocker := interceptor.WrapUnary(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		close(blockerStarted)
+		<-releaseBlocker
+		return nil, nil
+	})
+	go func() { _, _ = blocker(context.Background(), nil) }()
+	<-blockerStarted
+
+	queuedDone := make(chan error, 1)
+	queued := interceptor.WrapUnary(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		return nil, nil
+	})
+	go func() {
+		_, err := queued(context.Background(), nil)
+		queuedDone <- err
+	}()
+
+	// 让第二个请求实际排上一段可测量的队
+	time.Sleep(20 * time.Millisecond)
+	if got := interceptor.QueueDepth(); got != 1 {
+		t.Errorf("QueueDepth while saturated = %d, want 1", got)
+	}
+	close(releaseBlocker)
+
+	if err := <-queuedDone; err != nil {
+		t.Fatalf("queued request failed: %v", err)
+	}
+
+	after := queueWaitSnapshot()
+	if after.Count != before.Count+1 {
+		t.Errorf("wait histogram count = %d, want %d", after.Count, before.Count+1)
+	}
+	if after.Sum <= before.Sum {
+		t.Errorf("wait histogram sum did not grow (%f -> %f), want non-zero wait recorded", before.Sum, after.Sum)
+	}
+	if got := interceptor.QueueDepth(); got != 0 {
+		t.Errorf("QueueDepth after drain = %d, want 0", got)
+	}
+}
@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"micro-holtye/internal/pkg/logger"
+
+	"connectrpc.com/connect"
+	"golang.org/x/time/rate"
+)
+
+// TenantQuota 单个租户的限流配额
+type TenantQuota struct {
+	Rate  float64 // 每秒允许的请求数
+	Burst int     // 突发容量
+}
+
+// RateLimitInterceptor 按租户限流的拦截器
+// 从上下文中取租户 ID 查找配额，未配置的租户使用默认配额，超限时返回 CodeResourceExhausted
+// 配额支持热更新：UpdateQuotas 替换配额表并重建限流器
+type RateLimitInterceptor struct {
+	mu           sync.Mutex
+	defaultQuota TenantQuota
+	quotas       map[string]TenantQuota
+	limiters     map[string]*rate.Limiter
+}
+
+// 确保 RateLimitInterceptor 实现 connect.Interceptor 接口
+var _ connect.Interceptor = (*RateLimitInterceptor)(nil)
+
+var errRateLimited = errors.New("request rate limit exceeded for tenant")
+
+// NewRateLimitInterceptor 创建按租户限流的拦截器
+func NewRateLimitInterceptor(defaultQuota TenantQuota, quotas map[string]TenantQuota) *RateLimitInterceptor {
+	if defaultQuota.Rate <= 0 {
+		defaultQuota.Rate = 100
+	}
+	if defaultQuota.Burst <= 0 {
+		defaultQuota.Burst = int(defaultQuota.Rate)
+	}
+
+	i := &RateLimitInterceptor{
+		defaultQuota: defaultQuota,
+		limiters:     make(map[string]*rate.Limiter),
+	}
+	i.setQuotas(quotas)
+	return i
+}
+
+// UpdateQuotas 热更新租户配额，已建的限流器被丢弃并按新配额重建
+func (i *RateLimitInterceptor) UpdateQuotas(quotas map[string]TenantQuota) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.setQuotasLocked(quotas)
+}
+
+func (i *RateLimitInterceptor) setQuotas(quotas map[string]TenantQuota) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.setQuotasLocked(quotas)
+}
+
+func (i *RateLimitInterceptor) setQuotasLocked(quotas map[string]TenantQuota) {
+	i.quotas = make(map[string]TenantQuota, len(quotas))
+	for id, quota := range quotas {
+		if quota.Rate <= 0 {
+			continue
+		}
+		if quota.Burst <= 0 {
+			quota.Burst = int(quota.Rate)
+		}
+		i.quotas[id] = quota
+	}
+	i.limiters = make(map[string]*rate.Limiter)
+}
+
+// limiterFor 返回租户对应的限流器，按需创建
+func (i *RateLimitInterceptor) limiterFor(tenantID string) *rate.Limiter {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if limiter, ok := i.limiters[tenantID]; ok {
+		return limiter
+	}
+
+	quota, ok := i.quotas[tenantID]
+	if !ok {
+		quota = i.defaultQuota
+	}
+
+	limiter := rate.NewLimiter(rate.Limit(quota.Rate), quota.Burst)
+	i.limiters[tenantID] = limiter
+	return limiter
+}
+
+// allow 判断本次请求是否放行
+func (i *RateLimitInterceptor) allow(ctx context.Context) error {
+	if !i.limiterFor(tenantFromContext(ctx)).Allow() {
+		return connect.NewError(connect.CodeResourceExhausted, errRateLimited)
+	}
+	return nil
+}
+
+// tenantFromContext 从上下文提取租户 ID，未设置时归入默认租户
+func tenantFromContext(ctx context.Context) string {
+	if tenantID := logger.TenantIDFromContext(ctx); tenantID != "" {
+		return tenantID
+	}
+	return "default"
+}
+
+// WrapUnary 包装一元 RPC 调用
+func (i *RateLimitInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if err := i.allow(ctx); err != nil {
+			return nil, err
+		}
+		return next(ctx, req)
+	}
+}
+
+// WrapStreamingClient 包装流式客户端调用（客户端侧不做限制）
+func (i *RateLimitInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+// WrapStreamingHandler 包装流式处理器，按流计一次配额
+func (i *RateLimitInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		if err := i.allow(ctx); err != nil {
+			return err
+		}
+		return next(ctx, conn)
+	}
+}
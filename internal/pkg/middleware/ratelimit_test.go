@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"micro-holtye/internal/pkg/logger"
+
+	"connectrpc.com/connect"
+)
+
+// rateLimitPass 以指定租户连续调用 n 次，返回被放行的次数
+func rateLimitPass(interceptor *RateLimitInterceptor, tenantID string, n int) int {
+	wrapped := interceptor.WrapUnary(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		return nil, nil
+	})
+	ctx := logger.ContextWithTenantID(context.Background(), tenantID)
+
+	allowed := 0
+	for i := 0; i < n; i++ {
+		if _, err := wrapped(ctx, nil); err == nil {
+			allowed++
+		}
+	}
+	return allowed
+}
+
+func TestRateLimitThrottlesLowQuotaTenantFirst(t *testing.T) {
+	interceptor := NewRateLimitInterceptor(
+		TenantQuota{Rate: 100, Burst: 100},
+		map[string]TenantQuota{
+			"small": {Rate: 1, Burst: 2},
+			"large": {Rate: 100, Burst: 50},
+		},
+	)
+
+	if allowed := rateLimitPass(interceptor, "small", 10); allowed != 2 {
+		t.Errorf("low-quota tenant allowed %d of 10 requests, want 2 (burst)", allowed)
+	}
+	if allowed := rateLimitPass(interceptor, "large", 10); allowed != 10 {
+		t.Errorf("high-quota tenant allowed %d of 10 requests, want all 10", allowed)
+	}
+}
+
+func TestRateLimitRejectionCode(t *testing.T) {
+	interceptor := NewRateLimitInterceptor(
+		TenantQuota{Rate: 1, Burst: 1}, nil)
+
+	wrapped := interceptor.WrapUnary(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		return nil, nil
+	})
+	ctx := logger.ContextWithTenantID(context.Background(), "t-1")
+
+	if _, err := wrapped(ctx, nil); err != nil {
+		t.Fatalf("first request rejected: %v", err)
+	}
+	_, err := wrapped(ctx, nil)
+	if code := connect.CodeOf(err); code != connect.CodeResourceExhausted {
+		t.Errorf("CodeOf(err) = %v, want %v", code, connect.CodeResourceExhausted)
+	}
+}
+
+func TestRateLimitQuotaHotReload(t *testing.T) {
+	interceptor := NewRateLimitInterceptor(
+		TenantQuota{Rate: 100, Burst: 100},
+		map[string]TenantQuota{"t-1": {Rate: 1, Burst: 1}},
+	)
+
+	if allowed := rateLimitPass(interceptor, "t-1", 5); allowed != 1 {
+		t.Fatalf("pre-reload allowed %d of 5 requests, want 1", allowed)
+	}
+
+	// 热更新后限流器按新配额重建
+	interceptor.UpdateQuotas(map[string]TenantQuota{"t-1": {Rate: 100, Burst: 10}})
+
+	if allowed := rateLimitPass(interceptor, "t-1", 5); allowed != 5 {
+		t.Errorf("post-reload allowed %d of 5 requests, want all 5", allowed)
+	}
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+
+	"micro-holtye/internal/pkg/logger"
+
+	"connectrpc.com/connect"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+// ResponseSizeLimitInterceptor 响应大小限制拦截器
+// 在处理器返回后检查序列化响应的大小，超过上限时返回 CodeResourceExhausted 并记录日志
+// 列表接口的截断（返回 next token 和截断标记）由各处理器的分页实现，这里只做兜底保护
+type ResponseSizeLimitInterceptor struct {
+	maxBytes int
+}
+
+// 确保 ResponseSizeLimitInterceptor 实现 connect.Interceptor 接口
+var _ connect.Interceptor = (*ResponseSizeLimitInterceptor)(nil)
+
+// NewResponseSizeLimitInterceptor 创建响应大小限制拦截器，maxBytes 必须大于 0
+func NewResponseSizeLimitInterceptor(maxBytes int) *ResponseSizeLimitInterceptor {
+	if maxBytes <= 0 {
+		maxBytes = 4 << 20 // 默认 4MB
+	}
+	return &ResponseSizeLimitInterceptor{
+		maxBytes: maxBytes,
+	}
+}
+
+// check 检查响应消息的序列化大小是否超过上限
+func (i *ResponseSizeLimitInterceptor) check(procedure string, msg any) error {
+	pm, ok := msg.(proto.Message)
+	if !ok {
+		return nil
+	}
+
+	size := proto.Size(pm)
+	if size <= i.maxBytes {
+		return nil
+	}
+
+	logger.Warn("Response exceeded maximum allowed size",
+		zap.String("procedure", procedure),
+		zap.Int("response_bytes", size),
+		zap.Int("max_bytes", i.maxBytes),
+	)
+
+	return connect.NewError(connect.CodeResourceExhausted,
+		fmt.Errorf("response size %d bytes exceeds limit of %d bytes", size, i.maxBytes))
+}
+
+// WrapUnary 包装一元 RPC 调用
+func (i *ResponseSizeLimitInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		resp, err := next(ctx, req)
+		if err != nil {
+			return resp, err
+		}
+
+		if checkErr := i.check(req.Spec().Procedure, resp.Any()); checkErr != nil {
+			return nil, checkErr
+		}
+
+		return resp, nil
+	}
+}
+
+// WrapStreamingClient 包装流式客户端调用（客户端侧不做限制）
+func (i *ResponseSizeLimitInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+// WrapStreamingHandler 包装流式处理器，逐条检查发送的消息
+func (i *ResponseSizeLimitInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		return next(ctx, &sizeLimitedHandlerConn{
+			StreamingHandlerConn: conn,
+			interceptor:          i,
+		})
+	}
+}
+
+// sizeLimitedHandlerConn 在每次 Send 前检查消息大小
+type sizeLimitedHandlerConn struct {
+	connect.StreamingHandlerConn
+	interceptor *ResponseSizeLimitInterceptor
+}
+
+func (c *sizeLimitedHandlerConn) Send(msg any) error {
+	if err := c.interceptor.check(c.Spec().Procedure, msg); err != nil {
+		return err
+	}
+	return c.StreamingHandlerConn.Send(msg)
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	userv1 "micro-holtye/gen/user/v1"
+
+	"connectrpc.com/connect"
+)
+
+func TestResponseSizeLimitRejectsOversizedResponse(t *testing.T) {
+	interceptor := NewResponseSizeLimitInterceptor(128)
+
+	// 构造一个序列化后远超 128 字节的列表响应
+	oversized := &userv1.ListUsersResponse{}
+	for i := 0; i < 10; i++ {
+		oversized.Users = append(oversized.Users, &userv1.User{
+			Id:       strings.Repeat("a", 36),
+			Email:    "someone@example.com",
+			Username: "someone_with_a_long_name",
+		})
+	}
+
+	wrapped := interceptor.WrapUnary(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(oversized), nil
+	})
+
+	_, err := wrapped(context.Background(), connect.NewRequest(&userv1.ListUsersRequest{}))
+	if err == nil {
+		t.Fatal("oversized response passed the guard, want CodeResourceExhausted")
+	}
+	if code := connect.CodeOf(err); code != connect.CodeResourceExhausted {
+		t.Errorf("CodeOf(err) = %v, want %v", code, connect.CodeResourceExhausted)
+	}
+}
+
+func TestResponseSizeLimitPassesSmallResponse(t *testing.T) {
+	interceptor := NewResponseSizeLimitInterceptor(1024)
+
+	wrapped := interceptor.WrapUnary(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&userv1.ListUsersResponse{
+			Users: []*userv1.User{{Id: "u-1", Email: "a@b.c"}},
+		}), nil
+	})
+
+	if _, err := wrapped(context.Background(), connect.NewRequest(&userv1.ListUsersRequest{})); err != nil {
+		t.Fatalf("small response rejected: %v", err)
+	}
+}
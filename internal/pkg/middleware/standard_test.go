@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+
+	"micro-holtye/internal/pkg/config"
+
+	"go.uber.org/zap"
+)
+
+func TestStandardInterceptorsBuildsConfiguredStackInOrder(t *testing.T) {
+	cfg := &config.ServerConfig{
+		MaxConcurrentRequests: 10,
+		MaxResponseBytes:      1024,
+		Interceptors: []string{
+			InterceptorResponseSize,
+			InterceptorConcurrency,
+			InterceptorTimeout,
+		},
+	}
+	cfg.Timeout.Default = time.Second
+
+	stack, err := StandardInterceptors(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("StandardInterceptors: %v", err)
+	}
+	if len(stack.Interceptors) != 3 {
+		t.Fatalf("got %d interceptors, want 3", len(stack.Interceptors))
+	}
+
+	// 列表顺序决定拦截器顺序
+	if _, ok := stack.Interceptors[0].(*ResponseSizeLimitInterceptor); !ok {
+		t.Errorf("interceptor 0 = %T, want *ResponseSizeLimitInterceptor", stack.Interceptors[0])
+	}
+	if _, ok := stack.Interceptors[1].(*ConcurrencyLimitInterceptor); !ok {
+		t.Errorf("interceptor 1 = %T, want *ConcurrencyLimitInterceptor", stack.Interceptors[1])
+	}
+	if _, ok := stack.Interceptors[2].(*TimeoutInterceptor); !ok {
+		t.Errorf("interceptor 2 = %T, want *TimeoutInterceptor", stack.Interceptors[2])
+	}
+	if stack.RateLimiter != nil {
+		t.Error("RateLimiter built although rate_limit was not configured")
+	}
+}
+
+func TestStandardInterceptorsRejectsUnknownName(t *testing.T) {
+	cfg := &config.ServerConfig{
+		Interceptors: []string{"no_such_interceptor"},
+	}
+
+	if _, err := StandardInterceptors(cfg, zap.NewNop()); err == nil {
+		t.Fatal("unknown interceptor name accepted, want startup error")
+	}
+}
+
+func TestStandardInterceptorsDerivesStackFromSwitches(t *testing.T) {
+	cfg := &config.ServerConfig{MaxConcurrentRequests: 5}
+	cfg.RateLimit.Enabled = true
+	cfg.RateLimit.DefaultRate = 10
+	cfg.RateLimit.DefaultBurst = 10
+
+	stack, err := StandardInterceptors(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("StandardInterceptors: %v", err)
+	}
+	if len(stack.Interceptors) != 2 {
+		t.Fatalf("got %d interceptors, want 2", len(stack.Interceptors))
+	}
+	if stack.RateLimiter == nil {
+		t.Error("RateLimiter is nil although rate limiting is enabled")
+	}
+}
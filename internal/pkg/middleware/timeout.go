@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"micro-holtye/internal/pkg/logger"
+
+	"connectrpc.com/connect"
+	"go.uber.org/zap"
+)
+
+// TimeoutInterceptor 请求超时拦截器
+// 为每次调用的上下文设置截止时间，防止挂死的数据库或下游调用无限阻塞；
+// 调用方自带更紧的截止时间时保留调用方的设置
+type TimeoutInterceptor struct {
+	defaultTimeout time.Duration
+	// perProcedure 按 procedure 全名覆盖默认超时（如 /user.v1.UserService/ListUsers）
+	perProcedure map[string]time.Duration
+}
+
+// 确保 TimeoutInterceptor 实现 connect.Interceptor 接口
+var _ connect.Interceptor = (*TimeoutInterceptor)(nil)
+
+// NewTimeoutInterceptor 创建超时拦截器
+// defaultTimeout 为默认超时，perProcedure 中的条目按 procedure 覆盖；
+// 超时解析为 0 的调用不做限制
+func NewTimeoutInterceptor(defaultTimeout time.Duration, perProcedure map[string]time.Duration) *TimeoutInterceptor {
+	return &TimeoutInterceptor{
+		defaultTimeout: defaultTimeout,
+		perProcedure:   perProcedure,
+	}
+}
+
+// timeoutFor 解析某个 procedure 生效的超时时长
+func (i *TimeoutInterceptor) timeoutFor(procedure string) time.Duration {
+	if timeout, ok := i.perProcedure[procedure]; ok {
+		return timeout
+	}
+	return i.defaultTimeout
+}
+
+// boundContext 为上下文设置截止时间
+// 调用方已带有更紧的截止时间时不再收紧，返回的 cancel 恒可调用
+func (i *TimeoutInterceptor) boundContext(ctx context.Context, procedure string) (context.Context, context.CancelFunc) {
+	timeout := i.timeoutFor(procedure)
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= timeout {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// WrapUnary 为一元调用设置截止时间
+func (i *TimeoutInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		procedure := req.Spec().Procedure
+		ctx, cancel := i.boundContext(ctx, procedure)
+		defer cancel()
+
+		start := time.Now()
+		resp, err := next(ctx, req)
+		return resp, i.mapDeadlineError(ctx, procedure, start, err)
+	}
+}
+
+// WrapStreamingClient 客户端流的生命周期超出本函数作用域，不做限制
+func (i *TimeoutInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+// WrapStreamingHandler 为流式处理设置截止时间，覆盖整个流的处理过程
+func (i *TimeoutInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		procedure := conn.Spec().Procedure
+		ctx, cancel := i.boundContext(ctx, procedure)
+		defer cancel()
+
+		start := time.Now()
+		err := next(ctx, conn)
+		return i.mapDeadlineError(ctx, procedure, start, err)
+	}
+}
+
+// mapDeadlineError 把截止时间到期统一映射为 CodeDeadlineExceeded 并记录耗时
+func (i *TimeoutInterceptor) mapDeadlineError(ctx context.Context, procedure string, start time.Time, err error) error {
+	if err == nil || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+
+	elapsed := time.Since(start)
+	logger.Warn("Request exceeded deadline",
+		zap.String("procedure", procedure),
+		zap.Duration("elapsed", elapsed),
+		zap.Duration("timeout", i.timeoutFor(procedure)),
+	)
+
+	if connect.CodeOf(err) == connect.CodeDeadlineExceeded {
+		return err
+	}
+	return connect.NewError(connect.CodeDeadlineExceeded,
+		fmt.Errorf("request timed out after %s: %w", elapsed.Round(time.Millisecond), err))
+}
@@ -1,50 +0,0 @@
-package observability
-
-import (
-	"fmt"
-	"os"
-
-	"go.uber.org/zap"
-	"go.uber.org/zap/zapcore"
-)
-
-func NewLogger(level, format string) (*zap.Logger, error) {
-	var config zap.Config
-
-	if format == "json" {
-		config = zap.NewProductionConfig()
-	} else {
-		config = zap.NewDevelopmentConfig()
-		config.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
-	}
-
-	zapLevel, err := zapcore.ParseLevel(level)
-	if err != nil {
-		return nil, fmt.Errorf("invalid log level: %w", err)
-	}
-	config.Level = zap.NewAtomicLevelAt(zapLevel)
-
-	config.OutputPaths = []string{"stdout"}
-	config.ErrorOutputPaths = []string{"stderr"}
-
-	logger, err := config.Build()
-	if err != nil {
-		return nil, fmt.Errorf("failed to build logger: %w", err)
-	}
-
-	return logger, nil
-}
-
-func NewLoggerFromEnv() (*zap.Logger, error) {
-	level := os.Getenv("LOG_LEVEL")
-	if level == "" {
-		level = "info"
-	}
-
-	format := os.Getenv("LOG_FORMAT")
-	if format == "" {
-		format = "console"
-	}
-
-	return NewLogger(level, format)
-}
\ No newline at end of file
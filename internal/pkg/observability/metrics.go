@@ -0,0 +1,128 @@
+package observability
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MetricsConfig 指标拦截器配置
+type MetricsConfig struct {
+	// Namespace 指标命名空间，默认 pigeon
+	Namespace string
+	// Subsystem 指标子系统，通常为服务名（如 user_service）
+	Subsystem string
+}
+
+// ConnectMetricsInterceptor 按 RPC 过程记录请求数、错误数和延迟分布
+// 通过 Handler() 暴露 Prometheus 抓取端点
+type ConnectMetricsInterceptor struct {
+	namespace string
+	subsystem string
+	registry  *prometheus.Registry
+	requests  *prometheus.CounterVec
+	errors    *prometheus.CounterVec
+	latency   *prometheus.HistogramVec
+}
+
+// 确保 ConnectMetricsInterceptor 实现 connect.Interceptor 接口
+var _ connect.Interceptor = (*ConnectMetricsInterceptor)(nil)
+
+// NewConnectMetricsInterceptor 创建指标拦截器
+func NewConnectMetricsInterceptor(config MetricsConfig) *ConnectMetricsInterceptor {
+	if config.Namespace == "" {
+		config.Namespace = "pigeon"
+	}
+
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: config.Namespace,
+		Subsystem: config.Subsystem,
+		Name:      "rpc_requests_total",
+		Help:      "Total number of RPC requests by procedure and status.",
+	}, []string{"procedure", "status"})
+
+	errors := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: config.Namespace,
+		Subsystem: config.Subsystem,
+		Name:      "rpc_errors_total",
+		Help:      "Total number of failed RPC requests by procedure and connect code.",
+	}, []string{"procedure", "status"})
+
+	latency := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: config.Namespace,
+		Subsystem: config.Subsystem,
+		Name:      "rpc_duration_seconds",
+		Help:      "RPC latency distribution by procedure and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"procedure", "status"})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(requests, errors, latency)
+
+	return &ConnectMetricsInterceptor{
+		namespace: config.Namespace,
+		subsystem: config.Subsystem,
+		registry:  registry,
+		requests:  requests,
+		errors:    errors,
+		latency:   latency,
+	}
+}
+
+// RegisterGaugeFunc 注册一个抓取时采样的外部状态指标（如熔断器状态）
+// 同名指标可用不同的 labels 多次注册
+func (i *ConnectMetricsInterceptor) RegisterGaugeFunc(name, help string, labels map[string]string, fn func() float64) {
+	i.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   i.namespace,
+		Subsystem:   i.subsystem,
+		Name:        name,
+		Help:        help,
+		ConstLabels: labels,
+	}, fn))
+}
+
+// Handler 返回 /metrics 抓取端点的 http.Handler
+func (i *ConnectMetricsInterceptor) Handler() http.Handler {
+	return promhttp.HandlerFor(i.registry, promhttp.HandlerOpts{})
+}
+
+// WrapUnary 记录一元调用的指标
+func (i *ConnectMetricsInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		start := time.Now()
+		resp, err := next(ctx, req)
+		i.observe(req.Spec().Procedure, start, err)
+		return resp, err
+	}
+}
+
+// WrapStreamingClient 客户端流不做处理
+func (i *ConnectMetricsInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+// WrapStreamingHandler 记录流式调用的指标
+func (i *ConnectMetricsInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		start := time.Now()
+		err := next(ctx, conn)
+		i.observe(conn.Spec().Procedure, start, err)
+		return err
+	}
+}
+
+// observe 记录一次调用的计数和延迟，status 为 ok 或 connect 错误码
+func (i *ConnectMetricsInterceptor) observe(procedure string, start time.Time, err error) {
+	status := "ok"
+	if err != nil {
+		status = connect.CodeOf(err).String()
+		i.errors.WithLabelValues(procedure, status).Inc()
+	}
+
+	i.requests.WithLabelValues(procedure, status).Inc()
+	i.latency.WithLabelValues(procedure, status).Observe(time.Since(start).Seconds())
+}
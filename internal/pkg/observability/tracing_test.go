@@ -0,0 +1,168 @@
+package observability
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	userv1 "micro-holtye/gen/user/v1"
+	"micro-holtye/gen/user/v1/userv1connect"
+
+	"connectrpc.com/connect"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// recordingTracerProvider 记录所有创建的 span 的内存版 TracerProvider，
+// 通过 TracingInterceptorConfig.TracerProvider 注入
+type recordingTracerProvider struct {
+	noop.TracerProvider
+
+	mu    sync.Mutex
+	spans []*recordingSpan
+}
+
+func (p *recordingTracerProvider) Tracer(string, ...trace.TracerOption) trace.Tracer {
+	return &recordingTracer{provider: p}
+}
+
+func (p *recordingTracerProvider) recorded() []*recordingSpan {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*recordingSpan(nil), p.spans...)
+}
+
+type recordingTracer struct {
+	noop.Tracer
+	provider *recordingTracerProvider
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string, _ ...trace.SpanStartOption) (context.Context, trace.Span) {
+	span := &recordingSpan{name: name}
+	t.provider.mu.Lock()
+	t.provider.spans = append(t.provider.spans, span)
+	t.provider.mu.Unlock()
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+type recordingSpan struct {
+	noop.Span
+
+	mu         sync.Mutex
+	name       string
+	ended      bool
+	statusCode codes.Code
+	attrs      map[attribute.Key]attribute.Value
+}
+
+func (s *recordingSpan) End(...trace.SpanEndOption) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ended = true
+}
+
+func (s *recordingSpan) SetStatus(code codes.Code, _ string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statusCode = code
+}
+
+func (s *recordingSpan) SetAttributes(kvs ...attribute.KeyValue) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attrs == nil {
+		s.attrs = make(map[attribute.Key]attribute.Value)
+	}
+	for _, kv := range kvs {
+		s.attrs[kv.Key] = kv.Value
+	}
+}
+
+// tracedUserService 可控结果的用户服务桩
+type tracedUserService struct {
+	userv1connect.UnimplementedUserServiceHandler
+
+	getUserErr error
+}
+
+func (s *tracedUserService) GetUser(
+	context.Context,
+	*connect.Request[userv1.GetUserRequest],
+) (*connect.Response[userv1.GetUserResponse], error) {
+	if s.getUserErr != nil {
+		return nil, s.getUserErr
+	}
+	return connect.NewResponse(&userv1.GetUserResponse{User: &userv1.User{Id: "user-1"}}), nil
+}
+
+func newTracedUserClient(t *testing.T, provider trace.TracerProvider, svc *tracedUserService) userv1connect.UserServiceClient {
+	t.Helper()
+
+	interceptor := NewConnectTracingInterceptor(TracingInterceptorConfig{
+		ServiceName:    "user-service",
+		TracerProvider: provider,
+	})
+	mux := http.NewServeMux()
+	mux.Handle(userv1connect.NewUserServiceHandler(svc, connect.WithInterceptors(interceptor)))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return userv1connect.NewUserServiceClient(server.Client(), server.URL)
+}
+
+func TestTracingInterceptorCreatesSpanPerRPC(t *testing.T) {
+	provider := &recordingTracerProvider{}
+	client := newTracedUserClient(t, provider, &tracedUserService{})
+
+	_, err := client.GetUser(context.Background(), connect.NewRequest(&userv1.GetUserRequest{Id: "user-1"}))
+	if err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+
+	spans := provider.recorded()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	span := spans[0]
+	if span.name != userv1connect.UserServiceGetUserProcedure {
+		t.Errorf("span name = %q, want %q", span.name, userv1connect.UserServiceGetUserProcedure)
+	}
+	if !span.ended {
+		t.Error("span was never ended")
+	}
+	if span.statusCode != codes.Ok {
+		t.Errorf("span status = %v, want Ok", span.statusCode)
+	}
+}
+
+func TestTracingInterceptorRecordsConnectCodeOnError(t *testing.T) {
+	provider := &recordingTracerProvider{}
+	client := newTracedUserClient(t, provider, &tracedUserService{
+		getUserErr: connect.NewError(connect.CodeNotFound, errors.New("no such user")),
+	})
+
+	_, err := client.GetUser(context.Background(), connect.NewRequest(&userv1.GetUserRequest{Id: "missing"}))
+	if connect.CodeOf(err) != connect.CodeNotFound {
+		t.Fatalf("CodeOf(err) = %v, want NotFound", connect.CodeOf(err))
+	}
+
+	spans := provider.recorded()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	span := spans[0]
+	if span.statusCode != codes.Error {
+		t.Errorf("span status = %v, want Error", span.statusCode)
+	}
+	if got := span.attrs["rpc.connect_rpc.error_code"].AsString(); got != "not_found" {
+		t.Errorf("error code attribute = %q, want not_found", got)
+	}
+	if !span.ended {
+		t.Error("span was never ended despite the error")
+	}
+}
@@ -0,0 +1,234 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"micro-holtye/internal/pkg/logger"
+
+	"connectrpc.com/connect"
+	"go.uber.org/zap"
+)
+
+// BreakerConfig 下游熔断器配置
+type BreakerConfig struct {
+	// FailureThreshold 统计窗口内触发熔断的失败率阈值，取值 (0, 1]
+	FailureThreshold float64
+	// MinRequests 窗口内至少累计多少次请求才评估失败率，避免低流量误判
+	MinRequests int
+	// Window 失败率的统计窗口时长，窗口结束后计数清零
+	Window time.Duration
+	// CoolDown 熔断打开后的冷却时长，冷却结束进入半开态放行探测请求
+	CoolDown time.Duration
+	// HalfOpenMaxProbes 半开态允许的并发探测请求数
+	HalfOpenMaxProbes int
+}
+
+// DefaultBreakerConfig 默认熔断配置：10 秒窗口内至少 10 次请求且失败率过半时熔断，冷却 30 秒
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		FailureThreshold:  0.5,
+		MinRequests:       10,
+		Window:            10 * time.Second,
+		CoolDown:          30 * time.Second,
+		HalfOpenMaxProbes: 1,
+	}
+}
+
+// 熔断器状态，数值用于指标导出
+const (
+	breakerClosed   = 0
+	breakerOpen     = 1
+	breakerHalfOpen = 2
+)
+
+// breakerStateName 状态的可读名称，用于日志
+func breakerStateName(state int) string {
+	switch state {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// circuitBreaker 单个下游服务的熔断器
+// 闭合态统计窗口内的失败率，超阈值后打开并快速失败；
+// 冷却结束进入半开态放行少量探测，成功则闭合，失败则重新打开
+type circuitBreaker struct {
+	name   string
+	config BreakerConfig
+
+	mu             sync.Mutex
+	state          int
+	windowStart    time.Time
+	requests       int
+	failures       int
+	openedAt       time.Time
+	halfOpenProbes int
+}
+
+func newCircuitBreaker(name string, config BreakerConfig) *circuitBreaker {
+	if config.FailureThreshold <= 0 || config.FailureThreshold > 1 {
+		config.FailureThreshold = 0.5
+	}
+	if config.MinRequests <= 0 {
+		config.MinRequests = 10
+	}
+	if config.Window <= 0 {
+		config.Window = 10 * time.Second
+	}
+	if config.CoolDown <= 0 {
+		config.CoolDown = 30 * time.Second
+	}
+	if config.HalfOpenMaxProbes <= 0 {
+		config.HalfOpenMaxProbes = 1
+	}
+	return &circuitBreaker{
+		name:        name,
+		config:      config,
+		windowStart: time.Now(),
+	}
+}
+
+// allow 报告当前是否放行请求，并在冷却结束时切换到半开态
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.config.CoolDown {
+			return false
+		}
+		// 冷却结束，进入半开态放行探测请求
+		b.state = breakerHalfOpen
+		b.halfOpenProbes = 0
+		logger.Info("Circuit breaker entering half-open state",
+			zap.String("downstream", b.name),
+		)
+		b.halfOpenProbes++
+		return true
+	case breakerHalfOpen:
+		if b.halfOpenProbes >= b.config.HalfOpenMaxProbes {
+			return false
+		}
+		b.halfOpenProbes++
+		return true
+	default:
+		return true
+	}
+}
+
+// record 记录一次调用结果并按状态机推进
+func (b *circuitBreaker) record(failure bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	switch b.state {
+	case breakerHalfOpen:
+		if failure {
+			b.trip(now)
+			return
+		}
+		// 探测成功，恢复闭合并重置统计窗口
+		b.state = breakerClosed
+		b.resetWindow(now)
+		logger.Info("Circuit breaker closed after successful probe",
+			zap.String("downstream", b.name),
+		)
+	case breakerClosed:
+		if now.Sub(b.windowStart) >= b.config.Window {
+			b.resetWindow(now)
+		}
+		b.requests++
+		if failure {
+			b.failures++
+		}
+		if b.requests >= b.config.MinRequests &&
+			float64(b.failures)/float64(b.requests) >= b.config.FailureThreshold {
+			b.trip(now)
+		}
+	default:
+		// 打开态下在途请求的结果不影响状态
+	}
+}
+
+// trip 打开熔断器，调用方需持有锁
+func (b *circuitBreaker) trip(now time.Time) {
+	b.state = breakerOpen
+	b.openedAt = now
+	logger.Warn("Circuit breaker opened",
+		zap.String("downstream", b.name),
+		zap.Int("window_requests", b.requests),
+		zap.Int("window_failures", b.failures),
+	)
+	b.resetWindow(now)
+}
+
+// resetWindow 重置统计窗口，调用方需持有锁
+func (b *circuitBreaker) resetWindow(now time.Time) {
+	b.windowStart = now
+	b.requests = 0
+	b.failures = 0
+}
+
+// stateValue 返回状态数值（closed=0 / open=1 / half-open=2），供指标导出
+func (b *circuitBreaker) stateValue() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return float64(b.state)
+}
+
+// breakerInterceptor 熔断客户端拦截器，包裹单个下游服务的全部调用
+type breakerInterceptor struct {
+	breaker *circuitBreaker
+}
+
+// 确保 breakerInterceptor 实现 connect.Interceptor 接口
+var _ connect.Interceptor = (*breakerInterceptor)(nil)
+
+func newBreakerInterceptor(breaker *circuitBreaker) *breakerInterceptor {
+	return &breakerInterceptor{breaker: breaker}
+}
+
+func (i *breakerInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if !i.breaker.allow() {
+			// 快速失败，不再给故障中的下游增加负载
+			return nil, connect.NewError(connect.CodeUnavailable,
+				fmt.Errorf("%s service circuit breaker is open", i.breaker.name))
+		}
+
+		resp, err := next(ctx, req)
+		i.breaker.record(isBreakerFailure(err))
+		return resp, err
+	}
+}
+
+func (i *breakerInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (i *breakerInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return next
+}
+
+// isBreakerFailure 只有基础设施类错误计入失败率
+// NotFound 等业务错误说明下游健康，不应触发熔断
+func isBreakerFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch connect.CodeOf(err) {
+	case connect.CodeUnavailable, connect.CodeDeadlineExceeded, connect.CodeInternal, connect.CodeUnknown:
+		return true
+	default:
+		return false
+	}
+}
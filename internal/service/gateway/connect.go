@@ -3,6 +3,7 @@ package gateway
 import (
 	"context"
 	"fmt"
+	"time"
 
 	gatewayv1 "micro-holtye/gen/gateway/v1"
 	"micro-holtye/gen/gateway/v1/gatewayv1connect"
@@ -12,15 +13,20 @@ import (
 	"go.uber.org/zap"
 )
 
+// userETagTTL 用户 ETag 的缓存时长，过期后条件请求重新走下游
+const userETagTTL = 30 * time.Second
+
 type ConnectHandler struct {
 	service *Service
 	logger  logger.Logger
+	etags   *userETagCache
 }
 
 func NewConnectHandler(service *Service, logger logger.Logger) gatewayv1connect.GatewayServiceHandler {
 	return &ConnectHandler{
 		service: service,
 		logger:  logger,
+		etags:   newUserETagCache(userETagTTL),
 	}
 }
 
@@ -28,11 +34,7 @@ func (h *ConnectHandler) GetUserWithOrders(
 	ctx context.Context,
 	req *connect.Request[gatewayv1.GetUserWithOrdersRequest],
 ) (*connect.Response[gatewayv1.GetUserWithOrdersResponse], error) {
-	// 添加请求 ID 到上下文（用于追踪）
-	requestID := req.Header().Get("X-Request-ID")
-	if requestID != "" {
-		ctx = context.WithValue(ctx, "request_id", requestID)
-	}
+	// 请求 ID 由日志拦截器注入上下文，这里无需再处理
 
 	// 参数验证
 	if req.Msg.UserId == "" {
@@ -41,21 +43,28 @@ func (h *ConnectHandler) GetUserWithOrders(
 			logger.Operation("GetUserWithOrders"),
 			logger.ErrorCode("INVALID_ARGUMENT"),
 		)
-		return nil, connect.NewError(connect.CodeInvalidArgument, 
+		return nil, connect.NewError(connect.CodeInvalidArgument,
 			fmt.Errorf("user_id is required"))
 	}
 
+	// 条件请求：ETag 在缓存有效期内未变化时直接返回未修改，不走下游
+	ifNoneMatch := req.Header().Get("If-None-Match")
+	if h.etags.matches(req.Msg.UserId, ifNoneMatch) {
+		h.logger.InfoContext(ctx, "GetUserWithOrders not modified, served from ETag cache",
+			logger.UserID(req.Msg.UserId),
+		)
+		return nil, notModifiedError(ifNoneMatch)
+	}
+
 	h.logger.InfoContext(ctx, "Processing GetUserWithOrders request",
 		logger.UserID(req.Msg.UserId),
-		logger.RequestID(requestID),
 		logger.Component("connect-handler"),
 	)
 
-	resp, err := h.service.GetUserWithOrders(ctx, req.Msg.UserId)
+	resp, err := h.service.GetUserWithOrders(ctx, req.Msg.UserId, req.Msg.IncludeItems)
 	if err != nil {
 		h.logger.ErrorContext(ctx, "GetUserWithOrders request failed in handler",
 			logger.UserID(req.Msg.UserId),
-			logger.RequestID(requestID),
 			zap.Error(err),
 		)
 		return nil, err
@@ -63,9 +72,134 @@ func (h *ConnectHandler) GetUserWithOrders(
 
 	h.logger.InfoContext(ctx, "GetUserWithOrders request completed in handler",
 		logger.UserID(req.Msg.UserId),
-		logger.RequestID(requestID),
 		zap.Int("response_orders_count", int(resp.TotalOrders)),
 	)
 
+	// 生成 ETag 并记录，供后续条件请求比对；下游数据已变化时自然生成新值
+	etag := computeUserETag(resp.User)
+	h.etags.store(req.Msg.UserId, etag)
+
+	connectResp := connect.NewResponse(resp)
+	connectResp.Header().Set("ETag", etag)
+	if ifNoneMatch == etag {
+		return nil, notModifiedError(etag)
+	}
+
+	return connectResp, nil
+}
+
+// notModifiedError 构造"未修改"错误，相当于 HTTP 304
+// Connect 协议没有原生 304，使用 CodeAborted 并附带 ETag 元数据
+func notModifiedError(etag string) *connect.Error {
+	err := connect.NewError(connect.CodeAborted, fmt.Errorf("user not modified"))
+	err.Meta().Set("ETag", etag)
+	return err
+}
+
+func (h *ConnectHandler) GetUserWithOrdersByEmail(
+	ctx context.Context,
+	req *connect.Request[gatewayv1.GetUserWithOrdersByEmailRequest],
+) (*connect.Response[gatewayv1.GetUserWithOrdersResponse], error) {
+	// 请求 ID 由日志拦截器注入上下文，这里无需再处理
+
+	// 参数验证
+	if req.Msg.Email == "" {
+		h.logger.WarnContext(ctx, "Invalid request: missing email",
+			logger.Component("connect-handler"),
+			logger.Operation("GetUserWithOrdersByEmail"),
+			logger.ErrorCode("INVALID_ARGUMENT"),
+		)
+		return nil, connect.NewError(connect.CodeInvalidArgument,
+			fmt.Errorf("email is required"))
+	}
+
+	h.logger.InfoContext(ctx, "Processing GetUserWithOrdersByEmail request",
+		logger.Email(req.Msg.Email),
+		logger.Component("connect-handler"),
+	)
+
+	resp, err := h.service.GetUserWithOrdersByEmail(ctx, req.Msg.Email, req.Msg.IncludeItems)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "GetUserWithOrdersByEmail request failed in handler",
+			logger.Email(req.Msg.Email),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	h.logger.InfoContext(ctx, "GetUserWithOrdersByEmail request completed in handler",
+		logger.Email(req.Msg.Email),
+		zap.Int("response_orders_count", int(resp.TotalOrders)),
+	)
+
+	return connect.NewResponse(resp), nil
+}
+
+func (h *ConnectHandler) GetOrderWithUser(
+	ctx context.Context,
+	req *connect.Request[gatewayv1.GetOrderWithUserRequest],
+) (*connect.Response[gatewayv1.GetOrderWithUserResponse], error) {
+	// 参数验证
+	if req.Msg.OrderId == "" {
+		h.logger.WarnContext(ctx, "Invalid request: missing order ID",
+			logger.Component("connect-handler"),
+			logger.Operation("GetOrderWithUser"),
+			logger.ErrorCode("INVALID_ARGUMENT"),
+		)
+		return nil, connect.NewError(connect.CodeInvalidArgument,
+			fmt.Errorf("order_id is required"))
+	}
+
+	resp, err := h.service.GetOrderWithUser(ctx, req.Msg.OrderId)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "GetOrderWithUser request failed in handler",
+			zap.String("order_id", req.Msg.OrderId),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
+func (h *ConnectHandler) SystemHealth(
+	ctx context.Context,
+	req *connect.Request[gatewayv1.SystemHealthRequest],
+) (*connect.Response[gatewayv1.SystemHealthResponse], error) {
+	resp, err := h.service.SystemHealth(ctx)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "SystemHealth request failed in handler",
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
+func (h *ConnectHandler) DeleteUserCascade(
+	ctx context.Context,
+	req *connect.Request[gatewayv1.DeleteUserCascadeRequest],
+) (*connect.Response[gatewayv1.DeleteUserCascadeResponse], error) {
+	// 参数验证
+	if req.Msg.UserId == "" {
+		h.logger.WarnContext(ctx, "Invalid request: missing user ID",
+			logger.Component("connect-handler"),
+			logger.Operation("DeleteUserCascade"),
+			logger.ErrorCode("INVALID_ARGUMENT"),
+		)
+		return nil, connect.NewError(connect.CodeInvalidArgument,
+			fmt.Errorf("user_id is required"))
+	}
+
+	resp, err := h.service.DeleteUserCascade(ctx, req.Msg.UserId)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "DeleteUserCascade request failed in handler",
+			logger.UserID(req.Msg.UserId),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
 	return connect.NewResponse(resp), nil
 }
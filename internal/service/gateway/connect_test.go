@@ -0,0 +1,78 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	gatewayv1 "micro-holtye/gen/gateway/v1"
+	"micro-holtye/gen/gateway/v1/gatewayv1connect"
+	orderv1 "micro-holtye/gen/order/v1"
+	userv1 "micro-holtye/gen/user/v1"
+	"micro-holtye/internal/pkg/logger"
+
+	"connectrpc.com/connect"
+)
+
+// newGatewayTestServer 把完整的网关栈挂到测试服务器上，下游由桩服务支撑
+func newGatewayTestServer(t *testing.T, user *stubUserService, order *stubOrderService) gatewayv1connect.GatewayServiceClient {
+	t.Helper()
+
+	userURL, orderURL := newDownstreamServers(t, user, order)
+	handler := NewConnectHandler(NewService(NewStore(userURL, orderURL), logger.NewMemoryLogger()), logger.NewMemoryLogger())
+
+	mux := http.NewServeMux()
+	mux.Handle(gatewayv1connect.NewGatewayServiceHandler(handler))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return gatewayv1connect.NewGatewayServiceClient(server.Client(), server.URL)
+}
+
+func TestGetUserWithOrdersETagConditionalRequest(t *testing.T) {
+	user := &stubUserService{
+		getUser: func(req *userv1.GetUserRequest) (*userv1.GetUserResponse, error) {
+			return &userv1.GetUserResponse{User: &userv1.User{Id: req.Id, Email: "a@b.c"}}, nil
+		},
+	}
+	order := &stubOrderService{
+		listOrders: func(*orderv1.ListOrdersRequest) (*orderv1.ListOrdersResponse, error) {
+			return &orderv1.ListOrdersResponse{}, nil
+		},
+	}
+	client := newGatewayTestServer(t, user, order)
+
+	first, err := client.GetUserWithOrders(context.Background(),
+		connect.NewRequest(&gatewayv1.GetUserWithOrdersRequest{UserId: "user-1"}))
+	if err != nil {
+		t.Fatalf("first GetUserWithOrders: %v", err)
+	}
+	etag := first.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("first response carries no ETag header")
+	}
+	if user.getUserCalls != 1 {
+		t.Fatalf("downstream GetUser called %d times after first request, want 1", user.getUserCalls)
+	}
+
+	// 第二次带上 ETag：应返回"未修改"且不再调用下游
+	req := connect.NewRequest(&gatewayv1.GetUserWithOrdersRequest{UserId: "user-1"})
+	req.Header().Set("If-None-Match", etag)
+
+	_, err = client.GetUserWithOrders(context.Background(), req)
+	if err == nil {
+		t.Fatal("conditional request returned a full response, want not-modified error")
+	}
+	var connectErr *connect.Error
+	if !errors.As(err, &connectErr) || connectErr.Code() != connect.CodeAborted {
+		t.Fatalf("conditional request error = %v, want CodeAborted", err)
+	}
+	if got := connectErr.Meta().Get("ETag"); got != etag {
+		t.Errorf("not-modified ETag = %q, want %q", got, etag)
+	}
+	if user.getUserCalls != 1 {
+		t.Errorf("downstream GetUser called %d times after conditional request, want still 1", user.getUserCalls)
+	}
+}
@@ -0,0 +1,78 @@
+package gateway
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	gatewayv1 "micro-holtye/gen/gateway/v1"
+)
+
+// computeUserETag 根据用户 ID 和更新时间生成强 ETag
+// 用户数据未变化时 ETag 保持稳定，客户端可用于条件请求
+func computeUserETag(user *gatewayv1.UserInfo) string {
+	var updatedAt int64
+	if user.UpdatedAt != nil {
+		updatedAt = user.UpdatedAt.AsTime().UnixNano()
+	}
+
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s|%d", user.Id, updatedAt))
+	return fmt.Sprintf("%q", hex.EncodeToString(sum[:16]))
+}
+
+type etagEntry struct {
+	etag      string
+	expiresAt time.Time
+}
+
+// userETagCache 记录最近返回的用户 ETag
+// If-None-Match 在 TTL 内命中时直接返回未修改，省掉下游调用
+type userETagCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]etagEntry
+}
+
+func newUserETagCache(ttl time.Duration) *userETagCache {
+	return &userETagCache{
+		ttl:     ttl,
+		entries: make(map[string]etagEntry),
+	}
+}
+
+// matches 检查用户在 TTL 内的 ETag 是否与给定值一致
+func (c *userETagCache) matches(userID, etag string) bool {
+	if etag == "" {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[userID]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return false
+	}
+
+	return entry.etag == etag
+}
+
+// store 记录用户最新的 ETag，顺带清理过期条目
+func (c *userETagCache) store(userID, etag string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for id, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, id)
+		}
+	}
+
+	c.entries[userID] = etagEntry{
+		etag:      etag,
+		expiresAt: now.Add(c.ttl),
+	}
+}
@@ -0,0 +1,117 @@
+package gateway
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"micro-holtye/gen/order/v1/orderv1connect"
+	"micro-holtye/gen/user/v1/userv1connect"
+	"micro-holtye/internal/pkg/logger"
+
+	"connectrpc.com/connect"
+	"go.uber.org/zap"
+)
+
+// RetryPolicy 下游调用的重试策略
+type RetryPolicy struct {
+	// MaxAttempts 包含首次调用在内的最大尝试次数
+	MaxAttempts int
+	// BaseDelay 首次重试前的基础等待时间，之后按指数退避并加随机抖动
+	BaseDelay time.Duration
+}
+
+// DefaultRetryPolicy 默认重试策略：最多 3 次尝试，基础延迟 100ms
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   100 * time.Millisecond,
+	}
+}
+
+// retryableProcedures 允许重试的幂等只读调用
+// 写操作不在列表中，避免重复执行产生副作用
+var retryableProcedures = map[string]bool{
+	userv1connect.UserServiceGetUserProcedure:            true,
+	userv1connect.UserServiceGetUserByEmailProcedure:     true,
+	userv1connect.UserServiceListUsersProcedure:          true,
+	orderv1connect.OrderServiceGetOrderProcedure:         true,
+	orderv1connect.OrderServiceListOrdersProcedure:       true,
+	orderv1connect.OrderServiceGetOrderByNumberProcedure: true,
+}
+
+// retryInterceptor 客户端重试拦截器
+// 只对幂等只读调用在 CodeUnavailable/CodeDeadlineExceeded 时重试
+type retryInterceptor struct {
+	policy RetryPolicy
+}
+
+// 确保 retryInterceptor 实现 connect.Interceptor 接口
+var _ connect.Interceptor = (*retryInterceptor)(nil)
+
+func newRetryInterceptor(policy RetryPolicy) *retryInterceptor {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 3
+	}
+	if policy.BaseDelay <= 0 {
+		policy.BaseDelay = 100 * time.Millisecond
+	}
+	return &retryInterceptor{policy: policy}
+}
+
+func (i *retryInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		resp, err := next(ctx, req)
+		if err == nil || !retryableProcedures[req.Spec().Procedure] {
+			return resp, err
+		}
+
+		for attempt := 2; attempt <= i.policy.MaxAttempts; attempt++ {
+			if !isRetryableError(err) {
+				break
+			}
+
+			delay := i.backoffDelay(attempt)
+			logger.Warn("Retrying downstream call",
+				zap.String("procedure", req.Spec().Procedure),
+				zap.Int("attempt", attempt),
+				zap.Duration("delay", delay),
+				zap.Error(err),
+			)
+
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return nil, err
+			}
+
+			resp, err = next(ctx, req)
+			if err == nil {
+				return resp, nil
+			}
+		}
+
+		return resp, err
+	}
+}
+
+func (i *retryInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (i *retryInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return next
+}
+
+// isRetryableError 只有瞬时错误值得重试，业务错误直接返回
+func isRetryableError(err error) bool {
+	code := connect.CodeOf(err)
+	return code == connect.CodeUnavailable || code == connect.CodeDeadlineExceeded
+}
+
+// backoffDelay 计算第 attempt 次尝试前的等待时间：指数退避加最多 50% 的随机抖动
+func (i *retryInterceptor) backoffDelay(attempt int) time.Duration {
+	delay := i.policy.BaseDelay << (attempt - 2)
+	jitter := time.Duration(rand.Int64N(int64(delay)/2 + 1))
+	return delay + jitter
+}
@@ -3,15 +3,17 @@ package gateway
 import (
 	"context"
 	"fmt"
+	"sync"
+	"time"
 
 	gatewayv1 "micro-holtye/gen/gateway/v1"
-	"micro-holtye/internal/pkg/logger"
 	orderv1 "micro-holtye/gen/order/v1"
 	userv1 "micro-holtye/gen/user/v1"
+	"micro-holtye/internal/pkg/apierror"
+	"micro-holtye/internal/pkg/logger"
 
 	"connectrpc.com/connect"
 	"go.uber.org/zap"
-	"golang.org/x/sync/errgroup"
 )
 
 type Service struct {
@@ -26,7 +28,7 @@ func NewService(store *Store, logger logger.Logger) *Service {
 	}
 }
 
-func (s *Service) GetUserWithOrders(ctx context.Context, userID string) (*gatewayv1.GetUserWithOrdersResponse, error) {
+func (s *Service) GetUserWithOrders(ctx context.Context, userID string, includeItems bool) (*gatewayv1.GetUserWithOrdersResponse, error) {
 	// 使用带追踪的日志记录请求开始
 	s.logger.InfoContext(ctx, "GetUserWithOrders request started",
 		logger.UserID(userID),
@@ -34,76 +36,99 @@ func (s *Service) GetUserWithOrders(ctx context.Context, userID string) (*gatewa
 		logger.Component("gateway-service"),
 	)
 
-	// 声明需要从并发任务中获取的变量
-	var user *userv1.User
-	var orders []*orderv1.Order
+	user, err := s.store.GetUser(ctx, userID)
+	if err != nil {
+		return nil, s.userFetchError(ctx, err, logger.UserID(userID))
+	}
 
-	// 创建一个 errgroup，它会绑定到传入的 context
-	g, gCtx := errgroup.WithContext(ctx)
+	return s.aggregateUserWithOrders(ctx, user, includeItems, "GetUserWithOrders")
+}
 
-	// 并发获取用户信息
-	g.Go(func() error {
-		s.logger.DebugContext(gCtx, "Fetching user information",
-			logger.UserID(userID),
-			logger.Component("user-service-client"),
-		)
-		
-		var err error
-		user, err = s.store.GetUser(gCtx, userID)
-		if err != nil {
-			s.logger.ErrorContext(gCtx, "Failed to fetch user information",
-				logger.UserID(userID),
-				logger.ErrorCode("USER_NOT_FOUND"),
-				zap.Error(err),
-			)
-			// 返回错误，errgroup 会处理取消其他 goroutine
-			return connect.NewError(connect.CodeNotFound, fmt.Errorf("user not found: %w", err))
-		}
-		
-		s.logger.DebugContext(gCtx, "Successfully fetched user information",
-			logger.UserID(userID),
-			zap.String("username", user.Username),
-		)
-		return nil
-	})
+// GetUserWithOrdersByEmail 与 GetUserWithOrders 相同，但通过邮箱解析用户
+// 供只持有邮箱而没有用户 UUID 的调用方使用
+func (s *Service) GetUserWithOrdersByEmail(ctx context.Context, email string, includeItems bool) (*gatewayv1.GetUserWithOrdersResponse, error) {
+	s.logger.InfoContext(ctx, "GetUserWithOrdersByEmail request started",
+		logger.Email(email),
+		logger.Operation("GetUserWithOrdersByEmail"),
+		logger.Component("gateway-service"),
+	)
 
-	// 并发获取用户订单
-	g.Go(func() error {
-		s.logger.DebugContext(gCtx, "Fetching user orders",
-			logger.UserID(userID),
-			logger.Component("order-service-client"),
+	user, err := s.store.GetUserByEmail(ctx, email)
+	if err != nil {
+		return nil, s.userFetchError(ctx, err, logger.Email(email))
+	}
+
+	return s.aggregateUserWithOrders(ctx, user, includeItems, "GetUserWithOrdersByEmail")
+}
+
+// userFetchError 区分用户查询失败的原因并转换为网关错误：
+// 下游明确返回 NotFound 时视为用户不存在，其余情况（服务不可达、超时等）
+// 视为用户服务不可用并返回可重试的 Unavailable
+func (s *Service) userFetchError(ctx context.Context, err error, identityField zap.Field) error {
+	if connect.CodeOf(err) == connect.CodeNotFound {
+		s.logger.ErrorContext(ctx, "Failed to fetch user information",
+			identityField,
+			logger.ErrorCode(apierror.CodeUserNotFound),
+			zap.Error(err),
 		)
-		
-		var err error
-		orders, err = s.store.ListUserOrders(gCtx, userID, 10)
-		if err != nil {
-			// 获取订单失败不是致命错误，记录日志但不返回错误
-			// 这样即使订单服务不可用，用户仍能获取基本信息
-			s.logger.WarnContext(gCtx, "Failed to fetch user orders, using graceful degradation",
-				logger.UserID(userID),
-				logger.ErrorCode("ORDERS_UNAVAILABLE"),
-				zap.Error(err),
-			)
-			// 返回 nil 表示这个任务"成功"（优雅降级）
-			return nil
-		}
-		
-		s.logger.DebugContext(gCtx, "Successfully fetched user orders",
-			logger.UserID(userID),
-			zap.Int("order_count", len(orders)),
+		return apierror.New(connect.CodeNotFound, fmt.Errorf("user not found: %w", err), apierror.CodeUserNotFound)
+	}
+
+	s.logger.ErrorContext(ctx, "User service unavailable",
+		identityField,
+		logger.ErrorCode(apierror.CodeUserUnavailable),
+		zap.Error(err),
+	)
+	return apierror.New(connect.CodeUnavailable, fmt.Errorf("user service unavailable: %w", err), apierror.CodeUserUnavailable)
+}
+
+// orderFetchError 区分订单查询失败的原因并转换为网关错误，与 userFetchError 对称：
+// 下游明确返回 NotFound 时视为订单不存在，其余情况（服务不可达、超时等）
+// 视为订单服务不可用并返回可重试的 Unavailable
+func (s *Service) orderFetchError(ctx context.Context, err error, orderID string) error {
+	if connect.CodeOf(err) == connect.CodeNotFound {
+		s.logger.ErrorContext(ctx, "Failed to fetch order information",
+			zap.String("order_id", orderID),
+			logger.ErrorCode(apierror.CodeOrderNotFound),
+			zap.Error(err),
 		)
-		return nil
-	})
+		return apierror.New(connect.CodeNotFound, fmt.Errorf("order not found: %w", err), apierror.CodeOrderNotFound)
+	}
 
-	// 等待所有 goroutine 完成
-	if err := g.Wait(); err != nil {
-		// 如果获取用户信息失败，这是致命错误
-		s.logger.ErrorContext(ctx, "GetUserWithOrders request failed",
-			logger.UserID(userID),
-			logger.ErrorCode("REQUEST_FAILED"),
+	s.logger.ErrorContext(ctx, "Order service unavailable",
+		zap.String("order_id", orderID),
+		logger.ErrorCode(apierror.CodeOrderUnavailable),
+		zap.Error(err),
+	)
+	return apierror.New(connect.CodeUnavailable, fmt.Errorf("order service unavailable: %w", err), apierror.CodeOrderUnavailable)
+}
+
+// aggregateUserWithOrders 为已解析的用户聚合订单并构建响应
+// 订单获取失败不是致命错误：orders 为空并在 orders_status 中标明不可用，
+// 这样即使订单服务不可用，用户仍能获取基本信息
+func (s *Service) aggregateUserWithOrders(ctx context.Context, user *userv1.User, includeItems bool, operation string) (*gatewayv1.GetUserWithOrdersResponse, error) {
+	ordersStatus := gatewayv1.OrdersStatus_ORDERS_STATUS_OK
+
+	s.logger.DebugContext(ctx, "Fetching user orders",
+		logger.UserID(user.Id),
+		logger.Component("order-service-client"),
+	)
+
+	orders, err := s.store.ListUserOrders(ctx, user.Id, 10)
+	if err != nil {
+		// 优雅降级：记录日志并在响应中标明订单不可用
+		s.logger.WarnContext(ctx, "Failed to fetch user orders, using graceful degradation",
+			logger.UserID(user.Id),
+			logger.ErrorCode("ORDERS_UNAVAILABLE"),
 			zap.Error(err),
 		)
-		return nil, err
+		ordersStatus = gatewayv1.OrdersStatus_ORDERS_STATUS_UNAVAILABLE
+		orders = nil
+	} else {
+		s.logger.DebugContext(ctx, "Successfully fetched user orders",
+			logger.UserID(user.Id),
+			zap.Int("order_count", len(orders)),
+		)
 	}
 
 	// 构建响应 - 将内部服务数据转换为 Gateway API 格式
@@ -116,41 +141,47 @@ func (s *Service) GetUserWithOrders(ctx context.Context, userID string) (*gatewa
 		UpdatedAt: user.UpdatedAt,
 	}
 
-	// 转换订单信息
+	// 转换订单信息；默认只返回订单头，仅在调用方显式要求时才拷贝明细
 	var orderInfos []*gatewayv1.OrderInfo
 	for _, order := range orders {
 		var items []*gatewayv1.OrderItem
-		for _, item := range order.Items {
-			items = append(items, &gatewayv1.OrderItem{
-				ProductId:   item.ProductId,
-				ProductName: item.ProductName,
-				Quantity:    item.Quantity,
-				Price:       item.Price,
-			})
+		if includeItems {
+			for _, item := range order.Items {
+				items = append(items, &gatewayv1.OrderItem{
+					ProductId:   item.ProductId,
+					ProductName: item.ProductName,
+					Quantity:    item.Quantity,
+					Price:       item.Price,
+					PriceStr:    item.PriceStr,
+				})
+			}
 		}
 
 		orderInfo := &gatewayv1.OrderInfo{
-			Id:          order.Id,
-			UserId:      order.UserId,
-			Items:       items,
-			TotalAmount: order.TotalAmount,
-			Status:      order.Status.String(),
-			CreatedAt:   order.CreatedAt,
-			UpdatedAt:   order.UpdatedAt,
+			Id:             order.Id,
+			UserId:         order.UserId,
+			Items:          items,
+			TotalAmount:    order.TotalAmount,
+			TotalAmountStr: order.TotalAmountStr,
+			Status:         order.Status.String(),
+			CreatedAt:      order.CreatedAt,
+			UpdatedAt:      order.UpdatedAt,
 		}
 		orderInfos = append(orderInfos, orderInfo)
 	}
 
 	response := &gatewayv1.GetUserWithOrdersResponse{
-		User:        userInfo,
-		Orders:      orderInfos,
-		TotalOrders: int32(len(orderInfos)),
+		User:         userInfo,
+		Orders:       orderInfos,
+		TotalOrders:  int32(len(orderInfos)),
+		OrdersStatus: ordersStatus,
+		Degraded:     ordersStatus != gatewayv1.OrdersStatus_ORDERS_STATUS_OK,
 	}
 
 	// 记录请求成功完成
-	s.logger.InfoContext(ctx, "GetUserWithOrders request completed successfully",
-		logger.UserID(userID),
-		logger.Operation("GetUserWithOrders"),
+	s.logger.InfoContext(ctx, operation+" request completed successfully",
+		logger.UserID(user.Id),
+		logger.Operation(operation),
 		zap.String("username", user.Username),
 		zap.Int("total_orders", len(orderInfos)),
 		logger.StatusCode(200),
@@ -158,3 +189,229 @@ func (s *Service) GetUserWithOrders(ctx context.Context, userID string) (*gatewa
 
 	return response, nil
 }
+
+// GetOrderWithUser 按订单 ID 聚合订单与下单用户，是 GetUserWithOrders 的反向查询
+// 订单是主数据，获取失败直接返回错误；用户获取失败则优雅降级，
+// user 为空并在 user_status 中标明不可用
+func (s *Service) GetOrderWithUser(ctx context.Context, orderID string) (*gatewayv1.GetOrderWithUserResponse, error) {
+	s.logger.InfoContext(ctx, "GetOrderWithUser request started",
+		zap.String("order_id", orderID),
+		logger.Operation("GetOrderWithUser"),
+		logger.Component("gateway-service"),
+	)
+
+	order, err := s.store.GetOrder(ctx, orderID)
+	if err != nil {
+		return nil, s.orderFetchError(ctx, err, orderID)
+	}
+
+	// 用户查询依赖订单中的 user_id，只能在订单返回后发起
+	userStatus := gatewayv1.UserStatus_USER_STATUS_OK
+	var userInfo *gatewayv1.UserInfo
+	user, err := s.store.GetUser(ctx, order.UserId)
+	if err != nil {
+		// 优雅降级：用户不可用不影响订单本身的返回
+		s.logger.WarnContext(ctx, "Failed to fetch order owner, using graceful degradation",
+			zap.String("order_id", orderID),
+			logger.UserID(order.UserId),
+			logger.ErrorCode("USER_NOT_FOUND"),
+			zap.Error(err),
+		)
+		userStatus = gatewayv1.UserStatus_USER_STATUS_UNAVAILABLE
+	} else {
+		userInfo = &gatewayv1.UserInfo{
+			Id:        user.Id,
+			Email:     user.Email,
+			Username:  user.Username,
+			FullName:  user.FullName,
+			CreatedAt: user.CreatedAt,
+			UpdatedAt: user.UpdatedAt,
+		}
+	}
+
+	var items []*gatewayv1.OrderItem
+	for _, item := range order.Items {
+		items = append(items, &gatewayv1.OrderItem{
+			ProductId:   item.ProductId,
+			ProductName: item.ProductName,
+			Quantity:    item.Quantity,
+			Price:       item.Price,
+			PriceStr:    item.PriceStr,
+		})
+	}
+
+	orderInfo := &gatewayv1.OrderInfo{
+		Id:             order.Id,
+		UserId:         order.UserId,
+		Items:          items,
+		TotalAmount:    order.TotalAmount,
+		TotalAmountStr: order.TotalAmountStr,
+		Status:         order.Status.String(),
+		CreatedAt:      order.CreatedAt,
+		UpdatedAt:      order.UpdatedAt,
+	}
+
+	s.logger.InfoContext(ctx, "GetOrderWithUser request completed successfully",
+		zap.String("order_id", orderID),
+		logger.UserID(order.UserId),
+		logger.Operation("GetOrderWithUser"),
+		logger.StatusCode(200),
+	)
+
+	return &gatewayv1.GetOrderWithUserResponse{
+		Order:      orderInfo,
+		User:       userInfo,
+		UserStatus: userStatus,
+	}, nil
+}
+
+// healthCheckTimeout 单个下游健康检查的超时，避免慢下游拖住整体探测
+const healthCheckTimeout = 2 * time.Second
+
+// SystemHealth 聚合各下游服务的健康状况：并发探测每个下游的 /health 端点，
+// 返回逐服务状态与整体结论；启用熔断时附带熔断器状态，帮助区分瞬时抖动和持续故障
+func (s *Service) SystemHealth(ctx context.Context) (*gatewayv1.SystemHealthResponse, error) {
+	breakerStates := s.store.BreakerStateNames()
+	services := make(map[string]*gatewayv1.ServiceHealth)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, name := range s.store.DownstreamServices() {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := s.store.CheckServiceHealth(checkCtx, name)
+
+			health := &gatewayv1.ServiceHealth{
+				Status:       gatewayv1.HealthStatus_HEALTH_STATUS_HEALTHY,
+				LatencyMs:    time.Since(start).Milliseconds(),
+				BreakerState: breakerStates[name],
+			}
+			if err != nil {
+				health.Status = gatewayv1.HealthStatus_HEALTH_STATUS_UNHEALTHY
+				health.Error = err.Error()
+				s.logger.WarnContext(ctx, "Downstream health check failed",
+					zap.String("downstream", name),
+					logger.ErrorCode("DOWNSTREAM_UNHEALTHY"),
+					zap.Error(err),
+				)
+			}
+
+			mu.Lock()
+			services[name] = health
+			mu.Unlock()
+		}(name)
+	}
+	wg.Wait()
+
+	healthy := true
+	for _, health := range services {
+		if health.Status != gatewayv1.HealthStatus_HEALTH_STATUS_HEALTHY {
+			healthy = false
+			break
+		}
+	}
+
+	s.logger.InfoContext(ctx, "System health check completed",
+		logger.Operation("SystemHealth"),
+		zap.Bool("healthy", healthy),
+		zap.Int("services_checked", len(services)),
+	)
+
+	return &gatewayv1.SystemHealthResponse{
+		Healthy:  healthy,
+		Services: services,
+	}, nil
+}
+
+// DeleteUserCascade 级联删除用户：先逐个取消其订单，再删除用户本身
+// 取消订单失败时中止，用户保持不变；用户删除失败时订单取消无法自动回滚，
+// 记录补偿日志供人工核对
+func (s *Service) DeleteUserCascade(ctx context.Context, userID string) (*gatewayv1.DeleteUserCascadeResponse, error) {
+	s.logger.InfoContext(ctx, "DeleteUserCascade request started",
+		logger.UserID(userID),
+		logger.Operation("DeleteUserCascade"),
+		logger.Component("gateway-service"),
+	)
+
+	// 第一步：取消用户的全部订单（可安全中止的步骤放在前面）
+	// 分页拉取到末尾后再取消，保证超过单页容量的订单不会漏掉；
+	// 先收集完整列表，避免边取消边翻页导致令牌指向已变化的数据
+	var orders []*orderv1.Order
+	pageToken := ""
+	for {
+		page, nextToken, err := s.store.ListUserOrdersPage(ctx, userID, 100, pageToken)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to list user orders for cascade deletion",
+				logger.UserID(userID),
+				logger.ErrorCode("ORDERS_UNAVAILABLE"),
+				zap.Error(err),
+			)
+			return nil, apierror.New(connect.CodeUnavailable,
+				fmt.Errorf("failed to list user orders: %w", err), apierror.CodeOrdersUnavailable)
+		}
+
+		orders = append(orders, page...)
+		if nextToken == "" || len(page) == 0 {
+			break
+		}
+		pageToken = nextToken
+	}
+
+	cancelled := int32(0)
+	for _, order := range orders {
+		if order.Status == orderv1.OrderStatus_ORDER_STATUS_CANCELLED {
+			continue
+		}
+
+		s.logger.InfoContext(ctx, "Cancelling order in cascade deletion",
+			logger.UserID(userID),
+			zap.String("order_id", order.Id),
+			logger.Operation("DeleteUserCascade"),
+		)
+
+		if err := s.store.CancelOrder(ctx, order.Id); err != nil {
+			// 此时用户尚未删除，中止即可，无需补偿
+			s.logger.ErrorContext(ctx, "Failed to cancel order, aborting cascade deletion",
+				logger.UserID(userID),
+				zap.String("order_id", order.Id),
+				zap.Int32("orders_cancelled_so_far", cancelled),
+				logger.ErrorCode("CASCADE_CANCEL_FAILED"),
+				zap.Error(err),
+			)
+			return nil, apierror.New(connect.CodeInternal,
+				fmt.Errorf("failed to cancel order %s: %w", order.Id, err), apierror.CodeInternal)
+		}
+		cancelled++
+	}
+
+	// 第二步：删除用户
+	if err := s.store.DeleteUser(ctx, userID); err != nil {
+		// 补偿路径：订单已取消但用户删除失败，取消无法自动恢复，记录待人工处理
+		s.logger.ErrorContext(ctx, "User deletion failed after orders were cancelled, manual compensation required",
+			logger.UserID(userID),
+			zap.Int32("cancelled_orders", cancelled),
+			logger.ErrorCode("COMPENSATION_REQUIRED"),
+			zap.Error(err),
+		)
+		return nil, apierror.New(connect.CodeInternal,
+			fmt.Errorf("failed to delete user after cancelling %d orders: %w", cancelled, err), apierror.CodeInternal)
+	}
+
+	s.logger.InfoContext(ctx, "DeleteUserCascade request completed successfully",
+		logger.UserID(userID),
+		logger.Operation("DeleteUserCascade"),
+		zap.Int32("cancelled_orders", cancelled),
+		logger.StatusCode(200),
+	)
+
+	return &gatewayv1.DeleteUserCascadeResponse{
+		Success:         true,
+		CancelledOrders: cancelled,
+	}, nil
+}
@@ -0,0 +1,383 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	gatewayv1 "micro-holtye/gen/gateway/v1"
+	orderv1 "micro-holtye/gen/order/v1"
+	userv1 "micro-holtye/gen/user/v1"
+	"micro-holtye/internal/pkg/logger"
+
+	"connectrpc.com/connect"
+)
+
+func TestGetUserWithOrdersItemInclusion(t *testing.T) {
+	user := &stubUserService{
+		getUser: func(req *userv1.GetUserRequest) (*userv1.GetUserResponse, error) {
+			return &userv1.GetUserResponse{User: &userv1.User{Id: req.Id, Email: "a@b.c", Username: "alice"}}, nil
+		},
+	}
+	order := &stubOrderService{
+		listOrders: func(*orderv1.ListOrdersRequest) (*orderv1.ListOrdersResponse, error) {
+			return &orderv1.ListOrdersResponse{
+				Orders: []*orderv1.Order{{
+					Id:     "order-1",
+					UserId: "user-1",
+					Items: []*orderv1.OrderItem{
+						{ProductId: "p-1", ProductName: "widget", Quantity: 2, Price: 9.5},
+					},
+					Status: orderv1.OrderStatus_ORDER_STATUS_PENDING,
+				}},
+			}, nil
+		},
+	}
+	userURL, orderURL := newDownstreamServers(t, user, order)
+
+	service := NewService(NewStore(userURL, orderURL), logger.NewMemoryLogger())
+
+	// 默认（include_items=false）只返回订单头
+	resp, err := service.GetUserWithOrders(context.Background(), "user-1", false)
+	if err != nil {
+		t.Fatalf("GetUserWithOrders: %v", err)
+	}
+	if len(resp.Orders) != 1 {
+		t.Fatalf("got %d orders, want 1", len(resp.Orders))
+	}
+	if len(resp.Orders[0].Items) != 0 {
+		t.Errorf("items returned although include_items is false: %v", resp.Orders[0].Items)
+	}
+
+	// 显式要求时才拷贝明细
+	resp, err = service.GetUserWithOrders(context.Background(), "user-1", true)
+	if err != nil {
+		t.Fatalf("GetUserWithOrders(includeItems): %v", err)
+	}
+	if len(resp.Orders[0].Items) != 1 {
+		t.Fatalf("got %d items with include_items, want 1", len(resp.Orders[0].Items))
+	}
+	if resp.Orders[0].Items[0].ProductId != "p-1" {
+		t.Errorf("item product id = %q, want p-1", resp.Orders[0].Items[0].ProductId)
+	}
+}
+
+func TestGetUserWithOrdersOrdersStatus(t *testing.T) {
+	cases := []struct {
+		name       string
+		listOrders func(*orderv1.ListOrdersRequest) (*orderv1.ListOrdersResponse, error)
+		wantStatus gatewayv1.OrdersStatus
+		wantOrders int
+	}{
+		{
+			name: "orders available",
+			listOrders: func(*orderv1.ListOrdersRequest) (*orderv1.ListOrdersResponse, error) {
+				return &orderv1.ListOrdersResponse{
+					Orders: []*orderv1.Order{{Id: "order-1", UserId: "user-1"}},
+				}, nil
+			},
+			wantStatus: gatewayv1.OrdersStatus_ORDERS_STATUS_OK,
+			wantOrders: 1,
+		},
+		{
+			name: "user without orders",
+			listOrders: func(*orderv1.ListOrdersRequest) (*orderv1.ListOrdersResponse, error) {
+				return &orderv1.ListOrdersResponse{}, nil
+			},
+			wantStatus: gatewayv1.OrdersStatus_ORDERS_STATUS_OK,
+			wantOrders: 0,
+		},
+		{
+			name: "order service down",
+			listOrders: func(*orderv1.ListOrdersRequest) (*orderv1.ListOrdersResponse, error) {
+				return nil, connect.NewError(connect.CodeUnavailable, errors.New("order backend down"))
+			},
+			wantStatus: gatewayv1.OrdersStatus_ORDERS_STATUS_UNAVAILABLE,
+			wantOrders: 0,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			user := &stubUserService{
+				getUser: func(req *userv1.GetUserRequest) (*userv1.GetUserResponse, error) {
+					return &userv1.GetUserResponse{User: &userv1.User{Id: req.Id, Email: "a@b.c"}}, nil
+				},
+			}
+			order := &stubOrderService{listOrders: tc.listOrders}
+			userURL, orderURL := newDownstreamServers(t, user, order)
+
+			service := NewService(NewStore(userURL, orderURL), logger.NewMemoryLogger())
+
+			resp, err := service.GetUserWithOrders(context.Background(), "user-1", false)
+			if err != nil {
+				t.Fatalf("GetUserWithOrders: %v", err)
+			}
+			if resp.OrdersStatus != tc.wantStatus {
+				t.Errorf("OrdersStatus = %v, want %v", resp.OrdersStatus, tc.wantStatus)
+			}
+			if len(resp.Orders) != tc.wantOrders {
+				t.Errorf("got %d orders, want %d", len(resp.Orders), tc.wantOrders)
+			}
+		})
+	}
+}
+
+func TestDeleteUserCascadeAbortsWhenOrderCancelFails(t *testing.T) {
+	user := &stubUserService{
+		deleteUser: func(*userv1.DeleteUserRequest) (*userv1.DeleteUserResponse, error) {
+			return &userv1.DeleteUserResponse{Success: true}, nil
+		},
+	}
+	order := &stubOrderService{
+		listOrders: func(*orderv1.ListOrdersRequest) (*orderv1.ListOrdersResponse, error) {
+			return &orderv1.ListOrdersResponse{
+				Orders: []*orderv1.Order{{Id: "order-1", UserId: "user-1",
+					Status: orderv1.OrderStatus_ORDER_STATUS_PENDING}},
+			}, nil
+		},
+		cancelOrder: func(*orderv1.CancelOrderRequest) (*orderv1.CancelOrderResponse, error) {
+			return nil, connect.NewError(connect.CodeInternal, errors.New("cancel backend down"))
+		},
+	}
+	userURL, orderURL := newDownstreamServers(t, user, order)
+
+	service := NewService(NewStore(userURL, orderURL), logger.NewMemoryLogger())
+
+	_, err := service.DeleteUserCascade(context.Background(), "user-1")
+	if err == nil {
+		t.Fatal("DeleteUserCascade succeeded although order cancellation failed")
+	}
+	if code := connect.CodeOf(err); code != connect.CodeInternal {
+		t.Errorf("CodeOf(err) = %v, want Internal", code)
+	}
+
+	// 订单取消在用户删除之前执行，失败即中止，用户必须保持未删除
+	if user.deleteCalls != 0 {
+		t.Errorf("DeleteUser called %d times after order step failure, want 0", user.deleteCalls)
+	}
+}
+
+func TestDeleteUserCascadeLogsCompensationWhenUserDeleteFails(t *testing.T) {
+	user := &stubUserService{
+		deleteUser: func(*userv1.DeleteUserRequest) (*userv1.DeleteUserResponse, error) {
+			return nil, connect.NewError(connect.CodeInternal, errors.New("user backend down"))
+		},
+	}
+	order := &stubOrderService{
+		listOrders: func(*orderv1.ListOrdersRequest) (*orderv1.ListOrdersResponse, error) {
+			return &orderv1.ListOrdersResponse{
+				Orders: []*orderv1.Order{{Id: "order-1", UserId: "user-1",
+					Status: orderv1.OrderStatus_ORDER_STATUS_PENDING}},
+			}, nil
+		},
+		cancelOrder: func(*orderv1.CancelOrderRequest) (*orderv1.CancelOrderResponse, error) {
+			return &orderv1.CancelOrderResponse{}, nil
+		},
+	}
+	userURL, orderURL := newDownstreamServers(t, user, order)
+
+	mem := logger.NewMemoryLogger()
+	service := NewService(NewStore(userURL, orderURL), mem)
+
+	if _, err := service.DeleteUserCascade(context.Background(), "user-1"); err == nil {
+		t.Fatal("DeleteUserCascade succeeded although user deletion failed")
+	}
+
+	// 订单已取消但用户删除失败，必须留下补偿日志供人工核对
+	compensationLogged := false
+	for _, entry := range mem.EntriesAtLevel(logger.ErrorLevel) {
+		if entry.FieldEquals("error_code", "COMPENSATION_REQUIRED") {
+			compensationLogged = true
+		}
+	}
+	if !compensationLogged {
+		t.Error("no COMPENSATION_REQUIRED log entry after user deletion failure")
+	}
+}
+
+func TestDeleteUserCascadeCancelsAllOrders(t *testing.T) {
+	user := &stubUserService{
+		deleteUser: func(*userv1.DeleteUserRequest) (*userv1.DeleteUserResponse, error) {
+			return &userv1.DeleteUserResponse{Success: true}, nil
+		},
+	}
+	order := &stubOrderService{
+		listOrders: func(req *orderv1.ListOrdersRequest) (*orderv1.ListOrdersResponse, error) {
+			// 第一页之后返回空令牌，模拟两页订单
+			if req.PageToken == "" {
+				return &orderv1.ListOrdersResponse{
+					Orders: []*orderv1.Order{{Id: "order-1", UserId: "user-1",
+						Status: orderv1.OrderStatus_ORDER_STATUS_PENDING}},
+					NextPageToken: "page-2",
+				}, nil
+			}
+			return &orderv1.ListOrdersResponse{
+				Orders: []*orderv1.Order{{Id: "order-2", UserId: "user-1",
+					Status: orderv1.OrderStatus_ORDER_STATUS_PROCESSING}},
+			}, nil
+		},
+		cancelOrder: func(*orderv1.CancelOrderRequest) (*orderv1.CancelOrderResponse, error) {
+			return &orderv1.CancelOrderResponse{}, nil
+		},
+	}
+	userURL, orderURL := newDownstreamServers(t, user, order)
+
+	service := NewService(NewStore(userURL, orderURL), logger.NewMemoryLogger())
+
+	resp, err := service.DeleteUserCascade(context.Background(), "user-1")
+	if err != nil {
+		t.Fatalf("DeleteUserCascade: %v", err)
+	}
+	if resp.CancelledOrders != 2 {
+		t.Errorf("CancelledOrders = %d, want 2", resp.CancelledOrders)
+	}
+	if order.cancelCalls != 2 {
+		t.Errorf("downstream CancelOrder called %d times, want 2", order.cancelCalls)
+	}
+	if user.deleteCalls != 1 {
+		t.Errorf("downstream DeleteUser called %d times, want 1", user.deleteCalls)
+	}
+}
+
+func TestGetUserWithOrdersLogsGracefulDegradation(t *testing.T) {
+	user := &stubUserService{
+		getUser: func(req *userv1.GetUserRequest) (*userv1.GetUserResponse, error) {
+			return &userv1.GetUserResponse{User: &userv1.User{Id: req.Id, Email: "a@b.c"}}, nil
+		},
+	}
+	order := &stubOrderService{
+		listOrders: func(*orderv1.ListOrdersRequest) (*orderv1.ListOrdersResponse, error) {
+			return nil, connect.NewError(connect.CodeUnavailable, errors.New("order backend down"))
+		},
+	}
+	userURL, orderURL := newDownstreamServers(t, user, order)
+
+	mem := logger.NewMemoryLogger()
+	service := NewService(NewStore(userURL, orderURL), mem)
+
+	if _, err := service.GetUserWithOrders(context.Background(), "user-1", false); err != nil {
+		t.Fatalf("GetUserWithOrders: %v", err)
+	}
+
+	// 降级路径必须留下一条 warn 日志，带用户 ID 和降级原因
+	warns := mem.EntriesAtLevel(logger.WarnLevel)
+	if len(warns) != 1 {
+		t.Fatalf("got %d warn entries, want exactly 1 degradation log: %+v", len(warns), warns)
+	}
+	entry := warns[0]
+	if !entry.FieldEquals("error_code", "ORDERS_UNAVAILABLE") {
+		t.Errorf("warn entry fields = %v, want error_code=ORDERS_UNAVAILABLE", entry.FieldMap())
+	}
+	if !entry.FieldEquals("user_id", "user-1") {
+		t.Errorf("warn entry fields = %v, want user_id=user-1", entry.FieldMap())
+	}
+	if !entry.HasField("error") {
+		t.Errorf("warn entry fields = %v, want the downstream error attached", entry.FieldMap())
+	}
+}
+
+func TestGetUserWithOrdersDistinguishesUserFetchFailures(t *testing.T) {
+	cases := []struct {
+		name           string
+		downstreamCode connect.Code
+		wantCode       connect.Code
+	}{
+		{"user genuinely missing", connect.CodeNotFound, connect.CodeNotFound},
+		{"user service unreachable", connect.CodeUnavailable, connect.CodeUnavailable},
+		{"user service deadline exceeded", connect.CodeDeadlineExceeded, connect.CodeUnavailable},
+		{"user service internal error", connect.CodeInternal, connect.CodeUnavailable},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			user := &stubUserService{
+				getUser: func(*userv1.GetUserRequest) (*userv1.GetUserResponse, error) {
+					return nil, connect.NewError(tc.downstreamCode, errors.New("downstream says no"))
+				},
+			}
+			userURL, orderURL := newDownstreamServers(t, user, &stubOrderService{})
+
+			service := NewService(NewStore(userURL, orderURL), logger.NewMemoryLogger())
+
+			_, err := service.GetUserWithOrders(context.Background(), "user-1", false)
+			if err == nil {
+				t.Fatal("GetUserWithOrders succeeded, want error")
+			}
+			if code := connect.CodeOf(err); code != tc.wantCode {
+				t.Errorf("CodeOf(err) = %v, want %v for downstream %v", code, tc.wantCode, tc.downstreamCode)
+			}
+		})
+	}
+}
+
+func TestSystemHealthReportsOneDownstreamDown(t *testing.T) {
+	// 用户服务健康，订单服务的 /health 返回 503
+	userMux := http.NewServeMux()
+	userMux.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	userServer := httptest.NewServer(userMux)
+	t.Cleanup(userServer.Close)
+
+	orderMux := http.NewServeMux()
+	orderMux.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	orderServer := httptest.NewServer(orderMux)
+	t.Cleanup(orderServer.Close)
+
+	mem := logger.NewMemoryLogger()
+	service := NewService(NewStore(userServer.URL, orderServer.URL), mem)
+
+	resp, err := service.SystemHealth(context.Background())
+	if err != nil {
+		t.Fatalf("SystemHealth: %v", err)
+	}
+
+	if resp.Healthy {
+		t.Error("overall verdict healthy although a downstream is down")
+	}
+	if got := resp.Services["user"].Status; got != gatewayv1.HealthStatus_HEALTH_STATUS_HEALTHY {
+		t.Errorf("user status = %v, want healthy", got)
+	}
+	order := resp.Services["order"]
+	if order.Status != gatewayv1.HealthStatus_HEALTH_STATUS_UNHEALTHY {
+		t.Errorf("order status = %v, want unhealthy", order.Status)
+	}
+	if order.Error == "" {
+		t.Error("unhealthy downstream carries no error detail")
+	}
+
+	// 探测失败留下一条 warn 日志标明故障的下游
+	warns := mem.EntriesAtLevel(logger.WarnLevel)
+	if len(warns) != 1 || !warns[0].FieldEquals("downstream", "order") {
+		t.Errorf("warn entries = %+v, want one for the order downstream", warns)
+	}
+}
+
+func TestSystemHealthAllDownstreamsUp(t *testing.T) {
+	healthMux := http.NewServeMux()
+	healthMux.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	userServer := httptest.NewServer(healthMux)
+	t.Cleanup(userServer.Close)
+	orderServer := httptest.NewServer(healthMux)
+	t.Cleanup(orderServer.Close)
+
+	service := NewService(NewStore(userServer.URL, orderServer.URL), logger.NewMemoryLogger())
+
+	resp, err := service.SystemHealth(context.Background())
+	if err != nil {
+		t.Fatalf("SystemHealth: %v", err)
+	}
+	if !resp.Healthy {
+		t.Errorf("overall verdict unhealthy with all downstreams up: %+v", resp.Services)
+	}
+	if len(resp.Services) != 2 {
+		t.Errorf("got %d services, want user and order", len(resp.Services))
+	}
+}
@@ -2,34 +2,221 @@ package gateway
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	orderv1 "micro-holtye/gen/order/v1"
 	"micro-holtye/gen/order/v1/orderv1connect"
 	userv1 "micro-holtye/gen/user/v1"
 	"micro-holtye/gen/user/v1/userv1connect"
+	"micro-holtye/internal/pkg/logger"
 
 	"connectrpc.com/connect"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
 )
 
 type Store struct {
 	userClient  userv1connect.UserServiceClient
 	orderClient orderv1connect.OrderServiceClient
+
+	// 订单缓存（可选）：聚合查询后按订单 ID 预热，短 TTL 内的 GetOrder 直接命中
+	cacheMu         sync.Mutex
+	orderCache      map[string]orderCacheEntry
+	orderCacheTTL   time.Duration
+	orderCacheLimit int
+
+	// 缓存未命中时的防击穿保护：同一订单的并发请求合并为一次下游调用
+	orderGroup        singleflight.Group
+	coalescedRequests atomic.Uint64
+
+	// 按下游服务划分的熔断器（可选），键为 "user" / "order"
+	breakers map[string]*circuitBreaker
+
+	// 下游健康检查：服务名到 /health 端点的映射，复用下游 HTTP 客户端
+	healthURLs map[string]string
+	httpClient *http.Client
 }
 
-func NewStore(userServiceURL, orderServiceURL string) *Store {
-	return &Store{
-		userClient: userv1connect.NewUserServiceClient(
-			http.DefaultClient,
-			userServiceURL,
-		),
-		orderClient: orderv1connect.NewOrderServiceClient(
-			http.DefaultClient,
-			orderServiceURL,
-		),
+type orderCacheEntry struct {
+	order     *orderv1.Order
+	expiresAt time.Time
+}
+
+// StoreOption 配置 Store 的函数式选项
+type StoreOption func(*storeOptions)
+
+type storeOptions struct {
+	retryPolicy   *RetryPolicy
+	breakerConfig *BreakerConfig
+}
+
+// WithRetryPolicy 为下游客户端启用幂等只读调用的重试
+func WithRetryPolicy(policy RetryPolicy) StoreOption {
+	return func(o *storeOptions) {
+		o.retryPolicy = &policy
+	}
+}
+
+// WithCircuitBreaker 为每个下游服务启用独立的熔断器
+// 熔断打开时调用立即以 CodeUnavailable 失败，已有的优雅降级路径随之生效
+func WithCircuitBreaker(config BreakerConfig) StoreOption {
+	return func(o *storeOptions) {
+		o.breakerConfig = &config
 	}
 }
 
+// 默认下游 HTTP 客户端参数
+const (
+	// defaultClientTimeout 整个下游请求的硬超时，防止挂死的下游拖垮聚合
+	defaultClientTimeout = 10 * time.Second
+	// defaultMaxIdleConnsPerHost 每个下游服务保留的空闲连接数，高并发下减少握手开销
+	defaultMaxIdleConnsPerHost = 32
+	// defaultIdleConnTimeout 空闲连接的回收时长
+	defaultIdleConnTimeout = 90 * time.Second
+)
+
+// defaultHTTPClient 构建带超时和连接池调优的下游 HTTP 客户端
+func defaultHTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	transport.IdleConnTimeout = defaultIdleConnTimeout
+
+	return &http.Client{
+		Timeout:   defaultClientTimeout,
+		Transport: transport,
+	}
+}
+
+// NewStore 使用默认的下游 HTTP 客户端创建 Store
+func NewStore(userServiceURL, orderServiceURL string, opts ...StoreOption) *Store {
+	return NewStoreWithClient(userServiceURL, orderServiceURL, defaultHTTPClient(), opts...)
+}
+
+// NewStoreWithClient 使用调用方提供的 HTTP 客户端创建 Store
+// 需要自定义超时、代理或 TLS 配置时使用
+func NewStoreWithClient(userServiceURL, orderServiceURL string, client *http.Client, opts ...StoreOption) *Store {
+	options := &storeOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	// 把上下文中的 request_id / 追踪上下文注入下游请求头，
+	// 下游服务的日志得以与网关日志关联
+	clientOpts := []connect.ClientOption{
+		connect.WithInterceptors(logger.NewConnectPropagationInterceptor()),
+	}
+	if options.retryPolicy != nil {
+		clientOpts = append(clientOpts, connect.WithInterceptors(newRetryInterceptor(*options.retryPolicy)))
+	}
+
+	store := &Store{
+		healthURLs: map[string]string{
+			"user":  userServiceURL + "/health",
+			"order": orderServiceURL + "/health",
+		},
+		httpClient: client,
+	}
+
+	// 每个下游独立熔断：用户服务故障不应影响订单服务的调用
+	userOpts := clientOpts
+	orderOpts := clientOpts
+	if options.breakerConfig != nil {
+		userBreaker := newCircuitBreaker("user", *options.breakerConfig)
+		orderBreaker := newCircuitBreaker("order", *options.breakerConfig)
+		store.breakers = map[string]*circuitBreaker{
+			"user":  userBreaker,
+			"order": orderBreaker,
+		}
+		userOpts = append(userOpts[:len(userOpts):len(userOpts)],
+			connect.WithInterceptors(newBreakerInterceptor(userBreaker)))
+		orderOpts = append(orderOpts[:len(orderOpts):len(orderOpts)],
+			connect.WithInterceptors(newBreakerInterceptor(orderBreaker)))
+	}
+
+	store.userClient = userv1connect.NewUserServiceClient(
+		client,
+		userServiceURL,
+		userOpts...,
+	)
+	store.orderClient = orderv1connect.NewOrderServiceClient(
+		client,
+		orderServiceURL,
+		orderOpts...,
+	)
+	return store
+}
+
+// BreakerStates 返回各下游熔断器的当前状态数值（closed=0 / open=1 / half-open=2）
+// 未启用熔断时返回空映射，供指标拦截器按需采样
+func (s *Store) BreakerStates() map[string]float64 {
+	states := make(map[string]float64, len(s.breakers))
+	for name, breaker := range s.breakers {
+		states[name] = breaker.stateValue()
+	}
+	return states
+}
+
+// BreakerStateNames 返回各下游熔断器的可读状态名（closed / open / half-open）
+// 未启用熔断时返回空映射
+func (s *Store) BreakerStateNames() map[string]string {
+	states := make(map[string]string, len(s.breakers))
+	for name, breaker := range s.breakers {
+		states[name] = breakerStateName(int(breaker.stateValue()))
+	}
+	return states
+}
+
+// DownstreamServices 返回网关聚合的下游服务名列表
+func (s *Store) DownstreamServices() []string {
+	names := make([]string, 0, len(s.healthURLs))
+	for name := range s.healthURLs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CheckServiceHealth 探测指定下游的 /health 端点，非 200 视为不健康
+// 探测绕过熔断器直达下游，熔断打开期间也能反映下游的真实恢复情况
+func (s *Store) CheckServiceHealth(ctx context.Context, name string) error {
+	url, ok := s.healthURLs[name]
+	if !ok {
+		return fmt.Errorf("unknown downstream service %q", name)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("health endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// EnableOrderCache 启用订单缓存预热，缓存条目数不超过 maxEntries
+// 默认不启用，调用方按需开启
+func (s *Store) EnableOrderCache(ttl time.Duration, maxEntries int) {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	s.orderCache = make(map[string]orderCacheEntry)
+	s.orderCacheTTL = ttl
+	s.orderCacheLimit = maxEntries
+}
+
 func (s *Store) GetUser(ctx context.Context, userID string) (*userv1.User, error) {
 	req := connect.NewRequest(&userv1.GetUserRequest{
 		Id: userID,
@@ -43,6 +230,19 @@ func (s *Store) GetUser(ctx context.Context, userID string) (*userv1.User, error
 	return resp.Msg.User, nil
 }
 
+func (s *Store) GetUserByEmail(ctx context.Context, email string) (*userv1.User, error) {
+	req := connect.NewRequest(&userv1.GetUserByEmailRequest{
+		Email: email,
+	})
+
+	resp, err := s.userClient.GetUserByEmail(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Msg.User, nil
+}
+
 func (s *Store) ListUserOrders(ctx context.Context, userID string, limit int32) ([]*orderv1.Order, error) {
 	req := connect.NewRequest(&orderv1.ListOrdersRequest{
 		UserId:   userID,
@@ -54,5 +254,131 @@ func (s *Store) ListUserOrders(ctx context.Context, userID string, limit int32)
 		return nil, err
 	}
 
+	s.warmOrderCache(resp.Msg.Orders)
+
 	return resp.Msg.Orders, nil
 }
+
+// ListUserOrdersPage 按页拉取用户订单并返回下一页令牌，令牌为空表示已到末尾
+// 供需要遍历全部订单的调用方（如级联删除）使用
+func (s *Store) ListUserOrdersPage(ctx context.Context, userID string, pageSize int32, pageToken string) ([]*orderv1.Order, string, error) {
+	req := connect.NewRequest(&orderv1.ListOrdersRequest{
+		UserId:    userID,
+		PageSize:  pageSize,
+		PageToken: pageToken,
+	})
+
+	resp, err := s.orderClient.ListOrders(ctx, req)
+	if err != nil {
+		return nil, "", err
+	}
+
+	s.warmOrderCache(resp.Msg.Orders)
+
+	return resp.Msg.Orders, resp.Msg.NextPageToken, nil
+}
+
+func (s *Store) GetOrder(ctx context.Context, orderID string) (*orderv1.Order, error) {
+	if order := s.cachedOrder(orderID); order != nil {
+		return order, nil
+	}
+
+	// singleflight 合并同一订单的并发请求，只有 leader 真正发起下游调用
+	cacheKey := "order:" + orderID
+	leader := false
+	result, err, _ := s.orderGroup.Do(cacheKey, func() (any, error) {
+		leader = true
+		logger.Debug("Order fetch triggered downstream call",
+			zap.String("cache_key", cacheKey),
+		)
+
+		req := connect.NewRequest(&orderv1.GetOrderRequest{
+			Id: orderID,
+		})
+
+		resp, err := s.orderClient.GetOrder(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return resp.Msg.Order, nil
+	})
+	if !leader {
+		s.coalescedRequests.Add(1)
+		logger.Debug("Order fetch coalesced onto in-flight request",
+			zap.String("cache_key", cacheKey),
+		)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return result.(*orderv1.Order), nil
+}
+
+// CoalescedOrderRequests 返回被合并到在途请求上的 GetOrder 调用次数
+func (s *Store) CoalescedOrderRequests() uint64 {
+	return s.coalescedRequests.Load()
+}
+
+func (s *Store) CancelOrder(ctx context.Context, orderID string) error {
+	req := connect.NewRequest(&orderv1.CancelOrderRequest{
+		Id: orderID,
+	})
+
+	_, err := s.orderClient.CancelOrder(ctx, req)
+	return err
+}
+
+func (s *Store) DeleteUser(ctx context.Context, userID string) error {
+	req := connect.NewRequest(&userv1.DeleteUserRequest{
+		Id: userID,
+	})
+
+	_, err := s.userClient.DeleteUser(ctx, req)
+	return err
+}
+
+// warmOrderCache 将订单列表写入缓存，过期条目先清理，超出容量的直接跳过
+func (s *Store) warmOrderCache(orders []*orderv1.Order) {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	if s.orderCache == nil {
+		return
+	}
+
+	now := time.Now()
+	for id, entry := range s.orderCache {
+		if now.After(entry.expiresAt) {
+			delete(s.orderCache, id)
+		}
+	}
+
+	for _, order := range orders {
+		if len(s.orderCache) >= s.orderCacheLimit {
+			break
+		}
+		s.orderCache[order.Id] = orderCacheEntry{
+			order:     order,
+			expiresAt: now.Add(s.orderCacheTTL),
+		}
+	}
+}
+
+// cachedOrder 返回缓存中未过期的订单，未命中时返回 nil
+func (s *Store) cachedOrder(orderID string) *orderv1.Order {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	if s.orderCache == nil {
+		return nil
+	}
+
+	entry, ok := s.orderCache[orderID]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil
+	}
+
+	return entry.order
+}
@@ -0,0 +1,361 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	orderv1 "micro-holtye/gen/order/v1"
+	"micro-holtye/gen/order/v1/orderv1connect"
+	userv1 "micro-holtye/gen/user/v1"
+	"micro-holtye/gen/user/v1/userv1connect"
+	"micro-holtye/internal/pkg/logger"
+
+	"connectrpc.com/connect"
+)
+
+// stubUserService 可编程的用户服务桩，未设置的方法返回 CodeUnimplemented
+type stubUserService struct {
+	userv1connect.UnimplementedUserServiceHandler
+
+	mu           sync.Mutex
+	getUserCalls int
+	deleteCalls  int
+	getUser      func(*userv1.GetUserRequest) (*userv1.GetUserResponse, error)
+	deleteUser   func(*userv1.DeleteUserRequest) (*userv1.DeleteUserResponse, error)
+}
+
+func (s *stubUserService) GetUser(
+	ctx context.Context,
+	req *connect.Request[userv1.GetUserRequest],
+) (*connect.Response[userv1.GetUserResponse], error) {
+	s.mu.Lock()
+	s.getUserCalls++
+	s.mu.Unlock()
+
+	if s.getUser == nil {
+		return nil, connect.NewError(connect.CodeUnimplemented, nil)
+	}
+	resp, err := s.getUser(req.Msg)
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(resp), nil
+}
+
+func (s *stubUserService) DeleteUser(
+	ctx context.Context,
+	req *connect.Request[userv1.DeleteUserRequest],
+) (*connect.Response[userv1.DeleteUserResponse], error) {
+	s.mu.Lock()
+	s.deleteCalls++
+	s.mu.Unlock()
+
+	if s.deleteUser == nil {
+		return nil, connect.NewError(connect.CodeUnimplemented, nil)
+	}
+	resp, err := s.deleteUser(req.Msg)
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(resp), nil
+}
+
+// stubOrderService 可编程的订单服务桩
+type stubOrderService struct {
+	orderv1connect.UnimplementedOrderServiceHandler
+
+	mu            sync.Mutex
+	getOrderCalls int
+	listCalls     int
+	cancelCalls   int
+	getOrder      func(*orderv1.GetOrderRequest) (*orderv1.GetOrderResponse, error)
+	listOrders    func(*orderv1.ListOrdersRequest) (*orderv1.ListOrdersResponse, error)
+	cancelOrder   func(*orderv1.CancelOrderRequest) (*orderv1.CancelOrderResponse, error)
+}
+
+func (s *stubOrderService) GetOrder(
+	ctx context.Context,
+	req *connect.Request[orderv1.GetOrderRequest],
+) (*connect.Response[orderv1.GetOrderResponse], error) {
+	s.mu.Lock()
+	s.getOrderCalls++
+	s.mu.Unlock()
+
+	if s.getOrder == nil {
+		return nil, connect.NewError(connect.CodeUnimplemented, nil)
+	}
+	resp, err := s.getOrder(req.Msg)
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(resp), nil
+}
+
+func (s *stubOrderService) ListOrders(
+	ctx context.Context,
+	req *connect.Request[orderv1.ListOrdersRequest],
+) (*connect.Response[orderv1.ListOrdersResponse], error) {
+	s.mu.Lock()
+	s.listCalls++
+	s.mu.Unlock()
+
+	if s.listOrders == nil {
+		return nil, connect.NewError(connect.CodeUnimplemented, nil)
+	}
+	resp, err := s.listOrders(req.Msg)
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(resp), nil
+}
+
+func (s *stubOrderService) CancelOrder(
+	ctx context.Context,
+	req *connect.Request[orderv1.CancelOrderRequest],
+) (*connect.Response[orderv1.CancelOrderResponse], error) {
+	s.mu.Lock()
+	s.cancelCalls++
+	s.mu.Unlock()
+
+	if s.cancelOrder == nil {
+		return nil, connect.NewError(connect.CodeUnimplemented, nil)
+	}
+	resp, err := s.cancelOrder(req.Msg)
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(resp), nil
+}
+
+// newDownstreamServers 启动用户和订单服务桩，返回各自的基础 URL
+func newDownstreamServers(t *testing.T, user *stubUserService, order *stubOrderService) (string, string) {
+	t.Helper()
+
+	userMux := http.NewServeMux()
+	userMux.Handle(userv1connect.NewUserServiceHandler(user))
+	userServer := httptest.NewServer(userMux)
+	t.Cleanup(userServer.Close)
+
+	orderMux := http.NewServeMux()
+	orderMux.Handle(orderv1connect.NewOrderServiceHandler(order))
+	orderServer := httptest.NewServer(orderMux)
+	t.Cleanup(orderServer.Close)
+
+	return userServer.URL, orderServer.URL
+}
+
+func TestOrderCacheWarmSkipsDownstreamGetOrder(t *testing.T) {
+	order := &stubOrderService{
+		listOrders: func(*orderv1.ListOrdersRequest) (*orderv1.ListOrdersResponse, error) {
+			return &orderv1.ListOrdersResponse{
+				Orders: []*orderv1.Order{
+					{Id: "order-1", UserId: "user-1", TotalAmount: 10},
+					{Id: "order-2", UserId: "user-1", TotalAmount: 20},
+				},
+			}, nil
+		},
+	}
+	userURL, orderURL := newDownstreamServers(t, &stubUserService{}, order)
+
+	store := NewStore(userURL, orderURL)
+	store.EnableOrderCache(time.Minute, 100)
+
+	ctx := context.Background()
+	if _, err := store.ListUserOrders(ctx, "user-1", 10); err != nil {
+		t.Fatalf("ListUserOrders: %v", err)
+	}
+
+	got, err := store.GetOrder(ctx, "order-2")
+	if err != nil {
+		t.Fatalf("GetOrder: %v", err)
+	}
+	if got.Id != "order-2" {
+		t.Errorf("GetOrder returned order %q, want order-2", got.Id)
+	}
+	if order.getOrderCalls != 0 {
+		t.Errorf("downstream GetOrder called %d times within TTL, want 0", order.getOrderCalls)
+	}
+}
+
+func TestConcurrentGetOrderCoalescesOntoLeader(t *testing.T) {
+	downstreamStarted := make(chan struct{})
+	releaseDownstream := make(chan struct{})
+	order := &stubOrderService{
+		getOrder: func(req *orderv1.GetOrderRequest) (*orderv1.GetOrderResponse, error) {
+			close(downstreamStarted)
+			<-releaseDownstream
+			return &orderv1.GetOrderResponse{Order: &orderv1.Order{Id: req.Id}}, nil
+		},
+	}
+	userURL, orderURL := newDownstreamServers(t, &stubUserService{}, order)
+	store := NewStore(userURL, orderURL)
+
+	const followers = 4
+	var wg sync.WaitGroup
+	errs := make(chan error, followers+1)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, err := store.GetOrder(context.Background(), "order-1")
+		errs <- err
+	}()
+	<-downstreamStarted
+
+	// 下游调用挂起期间发起的相同请求应合并到在途请求上
+	for i := 0; i < followers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := store.GetOrder(context.Background(), "order-1")
+			errs <- err
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(releaseDownstream)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("GetOrder: %v", err)
+		}
+	}
+	if order.getOrderCalls != 1 {
+		t.Errorf("downstream GetOrder called %d times, want 1", order.getOrderCalls)
+	}
+	if got := store.CoalescedOrderRequests(); got != followers {
+		t.Errorf("CoalescedOrderRequests = %d, want %d", got, followers)
+	}
+}
+
+func TestOrderCacheDisabledHitsDownstream(t *testing.T) {
+	order := &stubOrderService{
+		getOrder: func(req *orderv1.GetOrderRequest) (*orderv1.GetOrderResponse, error) {
+			return &orderv1.GetOrderResponse{Order: &orderv1.Order{Id: req.Id}}, nil
+		},
+	}
+	userURL, orderURL := newDownstreamServers(t, &stubUserService{}, order)
+
+	store := NewStore(userURL, orderURL)
+
+	if _, err := store.GetOrder(context.Background(), "order-1"); err != nil {
+		t.Fatalf("GetOrder: %v", err)
+	}
+	if order.getOrderCalls != 1 {
+		t.Errorf("downstream GetOrder called %d times, want 1", order.getOrderCalls)
+	}
+}
+
+func TestStoreClientTimeoutFiresOnSlowDownstream(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	// 先放行阻塞中的处理器，再关闭服务器，否则 Close 会等待活跃连接
+	defer func() {
+		close(release)
+		slow.Close()
+	}()
+
+	client := &http.Client{Timeout: 50 * time.Millisecond}
+	store := NewStoreWithClient(slow.URL, slow.URL, client)
+
+	start := time.Now()
+	_, err := store.GetUser(context.Background(), "user-1")
+	if err == nil {
+		t.Fatal("GetUser succeeded against a hung downstream, want timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("timeout took %v, want client timeout to fire well before that", elapsed)
+	}
+}
+
+// headerRecorder 在转发请求前记录关联头部，按到达顺序保存
+type headerRecorder struct {
+	mu         sync.Mutex
+	requestIDs []string
+	traces     []string
+}
+
+func (r *headerRecorder) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.mu.Lock()
+		r.requestIDs = append(r.requestIDs, req.Header.Get("X-Request-ID"))
+		r.traces = append(r.traces, req.Header.Get("traceparent"))
+		r.mu.Unlock()
+		next.ServeHTTP(w, req)
+	})
+}
+
+func TestStorePropagatesRequestIDToBothDownstreams(t *testing.T) {
+	user := &stubUserService{
+		getUser: func(req *userv1.GetUserRequest) (*userv1.GetUserResponse, error) {
+			return &userv1.GetUserResponse{User: &userv1.User{Id: req.Id, Email: "a@b.c"}}, nil
+		},
+	}
+	order := &stubOrderService{
+		listOrders: func(*orderv1.ListOrdersRequest) (*orderv1.ListOrdersResponse, error) {
+			return &orderv1.ListOrdersResponse{}, nil
+		},
+	}
+
+	recorder := &headerRecorder{}
+	userMux := http.NewServeMux()
+	userMux.Handle(userv1connect.NewUserServiceHandler(user))
+	userServer := httptest.NewServer(recorder.wrap(userMux))
+	t.Cleanup(userServer.Close)
+
+	orderMux := http.NewServeMux()
+	orderMux.Handle(orderv1connect.NewOrderServiceHandler(order))
+	orderServer := httptest.NewServer(recorder.wrap(orderMux))
+	t.Cleanup(orderServer.Close)
+
+	store := NewStore(userServer.URL, orderServer.URL)
+
+	ctx := logger.ContextWithRequestID(context.Background(), "req-e2e-42")
+	ctx = logger.ContextWithTraceID(ctx, "0123456789abcdef0123456789abcdef")
+
+	if _, err := store.GetUser(ctx, "user-1"); err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+	if _, err := store.ListUserOrders(ctx, "user-1", 10); err != nil {
+		t.Fatalf("ListUserOrders: %v", err)
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if len(recorder.requestIDs) != 2 {
+		t.Fatalf("downstreams saw %d requests, want 2", len(recorder.requestIDs))
+	}
+	for i, id := range recorder.requestIDs {
+		if id != "req-e2e-42" {
+			t.Errorf("downstream %d received X-Request-ID %q, want req-e2e-42", i, id)
+		}
+	}
+	for i, tp := range recorder.traces {
+		if !strings.Contains(tp, "0123456789abcdef0123456789abcdef") {
+			t.Errorf("downstream %d traceparent = %q, want the context trace ID embedded", i, tp)
+		}
+	}
+}
+
+func TestExtractTraceContextRestoresPropagatedID(t *testing.T) {
+	// 服务端侧：下游拦截器把传播来的头部读回上下文
+	header := http.Header{}
+	header.Set("X-Request-ID", "req-e2e-42")
+	header.Set("traceparent", "00-0123456789abcdef0123456789abcdef-0123456789abcdef-01")
+
+	ctx := logger.ExtractTraceContext(context.Background(), header)
+	if got := logger.RequestIDFromContext(ctx); got != "req-e2e-42" {
+		t.Errorf("RequestIDFromContext = %q, want req-e2e-42", got)
+	}
+}
@@ -3,11 +3,17 @@ package order
 import (
 	"context"
 	"errors"
+	"fmt"
+
 	orderv1 "micro-holtye/gen/order/v1"
 	"micro-holtye/gen/order/v1/orderv1connect"
+	"micro-holtye/internal/pkg/apierror"
+	"micro-holtye/internal/pkg/logger"
+	orderdb "micro-holtye/internal/service/order/db"
 
 	"connectrpc.com/connect"
 	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -35,39 +41,35 @@ func (h *ConnectHandler) CreateOrder(
 			ProductName: item.ProductName,
 			Quantity:    item.Quantity,
 			Price:       item.Price,
+			PriceStr:    item.PriceStr,
 		})
 	}
 
-	order, orderItems, err := h.service.CreateOrder(ctx, msg.UserId, items)
+	idempotencyKey := req.Header().Get("Idempotency-Key")
+
+	order, orderItems, err := h.service.CreateOrder(ctx, msg.UserId, items, idempotencyKey)
 	if err != nil {
-		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		// 校验违规聚合为结构化详情，客户端一次拿到全部字段的问题
+		var verr *ValidationError
+		if errors.As(err, &verr) {
+			return nil, apierror.NewWithViolations(connect.CodeInvalidArgument, err,
+				apierror.CodeInvalidArgument, verr.Violations)
+		}
+		// 同一幂等键的并发请求：让客户端稍后重试，届时返回已创建的订单
+		if errors.Is(err, ErrIdempotencyKeyInProgress) {
+			return nil, connect.NewError(connect.CodeAborted, err)
+		}
+		// 事务失败、幂等存储故障等其余错误属于服务端问题
+		return nil, connect.NewError(connect.CodeInternal, err)
 	}
 
-	var pbItems []*orderv1.OrderItem
-	for _, item := range orderItems {
-		price, _ := decimal.NewFromString(item.Price)
-		priceFloat, _ := price.Float64()
-		pbItems = append(pbItems, &orderv1.OrderItem{
-			ProductId:   item.ProductID,
-			ProductName: item.ProductName,
-			Quantity:    item.Quantity,
-			Price:       priceFloat,
-		})
+	pbOrder, err := buildOrderProto(order, orderItems)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
 	}
 
-	totalAmount, _ := decimal.NewFromString(order.TotalAmount)
-	totalFloat, _ := totalAmount.Float64()
-
 	return connect.NewResponse(&orderv1.CreateOrderResponse{
-		Order: &orderv1.Order{
-			Id:          order.ID.String(),
-			UserId:      order.UserID.String(),
-			Items:       pbItems,
-			TotalAmount: totalFloat,
-			Status:      mapStatusToProto(order.Status),
-			CreatedAt:   timestamppb.New(order.CreatedAt),
-			UpdatedAt:   timestamppb.New(order.UpdatedAt),
-		},
+		Order: pbOrder,
 	}), nil
 }
 
@@ -77,37 +79,47 @@ func (h *ConnectHandler) GetOrder(
 ) (*connect.Response[orderv1.GetOrderResponse], error) {
 	order, items, err := h.service.GetOrderWithItems(ctx, req.Msg.Id)
 	if err != nil {
-		if err.Error() == "order not found" {
-			return nil, connect.NewError(connect.CodeNotFound, errors.New("order not found"))
+		if errors.Is(err, ErrNotFound) {
+			return nil, apierror.New(connect.CodeNotFound, ErrNotFound, apierror.CodeOrderNotFound)
+		}
+		if errors.Is(err, ErrInvalidID) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
 		}
 		return nil, connect.NewError(connect.CodeInternal, err)
 	}
 
-	var pbItems []*orderv1.OrderItem
-	for _, item := range items {
-		price, _ := decimal.NewFromString(item.Price)
-		priceFloat, _ := price.Float64()
-		pbItems = append(pbItems, &orderv1.OrderItem{
-			ProductId:   item.ProductID,
-			ProductName: item.ProductName,
-			Quantity:    item.Quantity,
-			Price:       priceFloat,
-		})
+	pbOrder, err := buildOrderProto(order, items)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
 	}
 
-	totalAmount, _ := decimal.NewFromString(order.TotalAmount)
-	totalFloat, _ := totalAmount.Float64()
-
 	return connect.NewResponse(&orderv1.GetOrderResponse{
-		Order: &orderv1.Order{
-			Id:          order.ID.String(),
-			UserId:      order.UserID.String(),
-			Items:       pbItems,
-			TotalAmount: totalFloat,
-			Status:      mapStatusToProto(order.Status),
-			CreatedAt:   timestamppb.New(order.CreatedAt),
-			UpdatedAt:   timestamppb.New(order.UpdatedAt),
-		},
+		Order: pbOrder,
+	}), nil
+}
+
+func (h *ConnectHandler) GetOrderByNumber(
+	ctx context.Context,
+	req *connect.Request[orderv1.GetOrderByNumberRequest],
+) (*connect.Response[orderv1.GetOrderByNumberResponse], error) {
+	order, items, err := h.service.GetOrderByNumber(ctx, req.Msg.OrderNumber)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, apierror.New(connect.CodeNotFound, ErrNotFound, apierror.CodeOrderNotFound)
+		}
+		if errors.Is(err, ErrInvalidID) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	pbOrder, err := buildOrderProto(order, items)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	return connect.NewResponse(&orderv1.GetOrderByNumberResponse{
+		Order: pbOrder,
 	}), nil
 }
 
@@ -115,24 +127,33 @@ func (h *ConnectHandler) UpdateOrderStatus(
 	ctx context.Context,
 	req *connect.Request[orderv1.UpdateOrderStatusRequest],
 ) (*connect.Response[orderv1.UpdateOrderStatusResponse], error) {
-	status := mapStatusFromProto(req.Msg.Status)
+	status, err := statusFromProto(req.Msg.Status)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
 	order, err := h.service.UpdateOrderStatus(ctx, req.Msg.Id, status)
 	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, apierror.New(connect.CodeNotFound, ErrNotFound, apierror.CodeOrderNotFound)
+		}
+		if errors.Is(err, ErrInvalidID) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		// 非法状态迁移，错误消息中带出当前状态和目标状态
+		var terr *InvalidTransitionError
+		if errors.As(err, &terr) {
+			return nil, connect.NewError(connect.CodeFailedPrecondition, terr)
+		}
 		return nil, connect.NewError(connect.CodeInternal, err)
 	}
 
-	totalAmount, _ := decimal.NewFromString(order.TotalAmount)
-	totalFloat, _ := totalAmount.Float64()
+	pbOrder, err := buildOrderProto(order, nil)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
 
 	return connect.NewResponse(&orderv1.UpdateOrderStatusResponse{
-		Order: &orderv1.Order{
-			Id:          order.ID.String(),
-			UserId:      order.UserID.String(),
-			TotalAmount: totalFloat,
-			Status:      mapStatusToProto(order.Status),
-			CreatedAt:   timestamppb.New(order.CreatedAt),
-			UpdatedAt:   timestamppb.New(order.UpdatedAt),
-		},
+		Order: pbOrder,
 	}), nil
 }
 
@@ -144,37 +165,75 @@ func (h *ConnectHandler) ListOrders(
 	if pageSize == 0 {
 		pageSize = 10
 	}
+	offset := decodePageToken(req.Msg.PageToken)
 
-	orders, err := h.service.ListOrdersByUser(ctx, req.Msg.UserId, pageSize, 0)
+	// 多取一条用于判断是否还有下一页
+	orders, err := h.service.ListOrdersByUser(ctx, req.Msg.UserId, pageSize+1, offset)
 	if err != nil {
 		return nil, connect.NewError(connect.CodeInternal, err)
 	}
 
+	nextPageToken := ""
+	if int32(len(orders)) > pageSize {
+		orders = orders[:pageSize]
+		nextPageToken = encodePageToken(offset + pageSize)
+	}
+
 	var pbOrders []*orderv1.Order
 	for _, order := range orders {
-		totalAmount, _ := decimal.NewFromString(order.TotalAmount)
-		totalFloat, _ := totalAmount.Float64()
-
-		pbOrders = append(pbOrders, &orderv1.Order{
-			Id:          order.ID.String(),
-			UserId:      order.UserID.String(),
-			TotalAmount: totalFloat,
-			Status:      mapStatusToProto(order.Status),
-			CreatedAt:   timestamppb.New(order.CreatedAt),
-			UpdatedAt:   timestamppb.New(order.UpdatedAt),
-		})
+		pbOrder, err := buildOrderProto(order, nil)
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInternal, err)
+		}
+		pbOrders = append(pbOrders, pbOrder)
 	}
 
 	return connect.NewResponse(&orderv1.ListOrdersResponse{
-		Orders: pbOrders,
+		Orders:        pbOrders,
+		NextPageToken: nextPageToken,
 	}), nil
 }
 
+// StreamOrders 逐条推送用户订单，存储层按批分页加载以控制内存占用
+func (h *ConnectHandler) StreamOrders(
+	ctx context.Context,
+	req *connect.Request[orderv1.StreamOrdersRequest],
+	stream *connect.ServerStream[orderv1.StreamOrdersResponse],
+) error {
+	err := h.service.StreamOrders(ctx, req.Msg.UserId, func(order *orderdb.Order) error {
+		pbOrder, err := buildOrderProto(order, nil)
+		if err != nil {
+			return err
+		}
+		return stream.Send(&orderv1.StreamOrdersResponse{Order: pbOrder})
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return connect.NewError(connect.CodeCanceled, ctx.Err())
+		}
+		if errors.Is(err, ErrInvalidID) {
+			return connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		return connect.NewError(connect.CodeInternal, err)
+	}
+	return nil
+}
+
 func (h *ConnectHandler) CancelOrder(
 	ctx context.Context,
 	req *connect.Request[orderv1.CancelOrderRequest],
 ) (*connect.Response[orderv1.CancelOrderResponse], error) {
 	if err := h.service.CancelOrder(ctx, req.Msg.Id); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, apierror.New(connect.CodeNotFound, ErrNotFound, apierror.CodeOrderNotFound)
+		}
+		if errors.Is(err, ErrInvalidID) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		// 已发货/已送达的订单不可取消
+		if errors.Is(err, ErrOrderNotCancellable) {
+			return nil, connect.NewError(connect.CodeFailedPrecondition, err)
+		}
 		return nil, connect.NewError(connect.CodeInternal, err)
 	}
 
@@ -183,6 +242,156 @@ func (h *ConnectHandler) CancelOrder(
 	}), nil
 }
 
+func (h *ConnectHandler) AddOrderItem(
+	ctx context.Context,
+	req *connect.Request[orderv1.AddOrderItemRequest],
+) (*connect.Response[orderv1.AddOrderItemResponse], error) {
+	if req.Msg.Item == nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("item is required"))
+	}
+
+	item := OrderItemInput{
+		ProductID:   req.Msg.Item.ProductId,
+		ProductName: req.Msg.Item.ProductName,
+		Quantity:    req.Msg.Item.Quantity,
+		Price:       req.Msg.Item.Price,
+		PriceStr:    req.Msg.Item.PriceStr,
+	}
+
+	order, items, err := h.service.AddOrderItem(ctx, req.Msg.OrderId, item)
+	if err != nil {
+		return nil, mapItemMutationError(err)
+	}
+
+	pbOrder, err := buildOrderProto(order, items)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	return connect.NewResponse(&orderv1.AddOrderItemResponse{
+		Order: pbOrder,
+	}), nil
+}
+
+func (h *ConnectHandler) RemoveOrderItem(
+	ctx context.Context,
+	req *connect.Request[orderv1.RemoveOrderItemRequest],
+) (*connect.Response[orderv1.RemoveOrderItemResponse], error) {
+	order, items, err := h.service.RemoveOrderItem(ctx, req.Msg.OrderId, req.Msg.ItemId)
+	if err != nil {
+		return nil, mapItemMutationError(err)
+	}
+
+	pbOrder, err := buildOrderProto(order, items)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	return connect.NewResponse(&orderv1.RemoveOrderItemResponse{
+		Order: pbOrder,
+	}), nil
+}
+
+// mapItemMutationError 将条目修改的服务层错误映射为 Connect 错误码
+func mapItemMutationError(err error) error {
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		return apierror.NewWithViolations(connect.CodeInvalidArgument, err,
+			apierror.CodeInvalidArgument, verr.Violations)
+	}
+	if errors.Is(err, ErrNotFound) {
+		return apierror.New(connect.CodeNotFound, ErrNotFound, apierror.CodeOrderNotFound)
+	}
+	if errors.Is(err, ErrItemNotFound) {
+		return connect.NewError(connect.CodeNotFound, err)
+	}
+	if errors.Is(err, ErrInvalidID) {
+		return connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	// 非 pending 状态的订单不可修改条目
+	if errors.Is(err, ErrOrderNotPending) {
+		return connect.NewError(connect.CodeFailedPrecondition, err)
+	}
+	return connect.NewError(connect.CodeInternal, err)
+}
+
+func (h *ConnectHandler) VerifyOrder(
+	ctx context.Context,
+	req *connect.Request[orderv1.VerifyOrderRequest],
+) (*connect.Response[orderv1.VerifyOrderResponse], error) {
+	result, err := h.service.RecalculateTotal(ctx, req.Msg.Id, req.Msg.Correct)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, apierror.New(connect.CodeNotFound, ErrNotFound, apierror.CodeOrderNotFound)
+		}
+		if errors.Is(err, ErrInvalidID) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	return connect.NewResponse(&orderv1.VerifyOrderResponse{
+		OrderId:       result.OrderID,
+		StoredTotal:   result.StoredTotal,
+		ComputedTotal: result.ComputedTotal,
+		Discrepancy:   result.Discrepancy,
+		Corrected:     result.Corrected,
+	}), nil
+}
+
+// parseStoredAmount 解析数据库存储的 decimal 字符串
+// 解析失败时记录日志并返回错误，避免把损坏的值静默变成 0 返回给客户端
+func parseStoredAmount(value, field, orderID string) (float64, error) {
+	amount, err := decimal.NewFromString(value)
+	if err != nil {
+		logger.Error("Failed to parse stored decimal value",
+			zap.String("order_id", orderID),
+			zap.String("field", field),
+			zap.String("value", value),
+			zap.Error(err),
+		)
+		return 0, fmt.Errorf("invalid %s for order %s: %w", field, orderID, err)
+	}
+
+	amountFloat, _ := amount.Float64()
+	return amountFloat, nil
+}
+
+// buildOrderProto 将数据库订单（和可选的订单项）转换为 API 响应格式
+func buildOrderProto(order *orderdb.Order, items []*orderdb.OrderItem) (*orderv1.Order, error) {
+	totalFloat, err := parseStoredAmount(order.TotalAmount, "total_amount", order.ID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	var pbItems []*orderv1.OrderItem
+	for _, item := range items {
+		priceFloat, err := parseStoredAmount(item.Price, "price", order.ID.String())
+		if err != nil {
+			return nil, err
+		}
+		pbItems = append(pbItems, &orderv1.OrderItem{
+			ProductId:   item.ProductID,
+			ProductName: item.ProductName,
+			Quantity:    item.Quantity,
+			Price:       priceFloat,
+			PriceStr:    item.Price,
+		})
+	}
+
+	return &orderv1.Order{
+		Id:             order.ID.String(),
+		UserId:         order.UserID.String(),
+		Items:          pbItems,
+		TotalAmount:    totalFloat,
+		TotalAmountStr: order.TotalAmount,
+		Status:         mapStatusToProto(order.Status),
+		CreatedAt:      timestamppb.New(order.CreatedAt),
+		UpdatedAt:      timestamppb.New(order.UpdatedAt),
+		OrderNumber:    order.OrderNumber,
+	}, nil
+}
+
 func mapStatusToProto(status string) orderv1.OrderStatus {
 	switch status {
 	case "pending":
@@ -200,6 +409,18 @@ func mapStatusToProto(status string) orderv1.OrderStatus {
 	}
 }
 
+// statusFromProto 在 mapStatusFromProto 之上把未知/未指定状态转换为错误，
+// 供处理器在调用服务层前以 CodeInvalidArgument 拒绝
+func statusFromProto(status orderv1.OrderStatus) (string, error) {
+	mapped := mapStatusFromProto(status)
+	if mapped == "" {
+		return "", fmt.Errorf("unknown or unspecified order status %v", status)
+	}
+	return mapped, nil
+}
+
+// mapStatusFromProto 将 proto 枚举映射为存储层状态字符串，
+// 未知或未指定的枚举值返回空串，由调用方决定如何拒绝
 func mapStatusFromProto(status orderv1.OrderStatus) string {
 	switch status {
 	case orderv1.OrderStatus_ORDER_STATUS_PENDING:
@@ -213,6 +434,6 @@ func mapStatusFromProto(status orderv1.OrderStatus) string {
 	case orderv1.OrderStatus_ORDER_STATUS_CANCELLED:
 		return "cancelled"
 	default:
-		return "pending"
+		return ""
 	}
 }
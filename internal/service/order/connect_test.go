@@ -0,0 +1,191 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	commonv1 "micro-holtye/gen/common/v1"
+	orderv1 "micro-holtye/gen/order/v1"
+	orderdb "micro-holtye/internal/service/order/db"
+
+	"connectrpc.com/connect"
+	"github.com/google/uuid"
+)
+
+func TestParseStoredAmountMalformed(t *testing.T) {
+	amount, err := parseStoredAmount("12.3.4", "total_amount", "order-1")
+	if err == nil {
+		t.Fatal("parseStoredAmount accepted a malformed value, want error")
+	}
+	if amount != 0 {
+		t.Errorf("amount = %v, want 0", amount)
+	}
+	if !strings.Contains(err.Error(), "total_amount") {
+		t.Errorf("error %q does not name the field", err)
+	}
+}
+
+func TestParseStoredAmountValid(t *testing.T) {
+	amount, err := parseStoredAmount("19.99", "price", "order-1")
+	if err != nil {
+		t.Fatalf("parseStoredAmount: %v", err)
+	}
+	if amount != 19.99 {
+		t.Errorf("amount = %v, want 19.99", amount)
+	}
+}
+
+func TestBuildOrderProtoKeepsExactPriceString(t *testing.T) {
+	now := time.Now()
+	orderID := uuid.New()
+	order := &orderdb.Order{
+		ID:          orderID,
+		UserID:      uuid.New(),
+		TotalAmount: "123456789.123456789",
+		Status:      "pending",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		OrderNumber: "ORD-0000000001",
+	}
+	items := []*orderdb.OrderItem{{
+		ID:          uuid.New(),
+		OrderID:     orderID,
+		ProductID:   "p-1",
+		ProductName: "widget",
+		Quantity:    1,
+		Price:       "0.123456789012345678",
+		CreatedAt:   now,
+	}}
+
+	pb, err := buildOrderProto(order, items)
+	if err != nil {
+		t.Fatalf("buildOrderProto: %v", err)
+	}
+
+	// 字符串字段保留精确的十进制值，double 字段允许四舍五入
+	if pb.TotalAmountStr != "123456789.123456789" {
+		t.Errorf("TotalAmountStr = %q, want exact stored value", pb.TotalAmountStr)
+	}
+	if pb.Items[0].PriceStr != "0.123456789012345678" {
+		t.Errorf("PriceStr = %q, want exact stored value", pb.Items[0].PriceStr)
+	}
+	if pb.TotalAmount < 123456789.12 || pb.TotalAmount > 123456789.13 {
+		t.Errorf("TotalAmount double = %v, want approx 123456789.1234…", pb.TotalAmount)
+	}
+}
+
+func TestBuildOrderProtoRejectsCorruptTotal(t *testing.T) {
+	now := time.Now()
+	order := &orderdb.Order{
+		ID:          uuid.New(),
+		UserID:      uuid.New(),
+		TotalAmount: "not-a-number",
+		Status:      "pending",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		OrderNumber: "ORD-20260101-000001",
+	}
+
+	pb, err := buildOrderProto(order, nil)
+	if err == nil {
+		t.Fatalf("buildOrderProto returned %+v for corrupt total, want error", pb)
+	}
+}
+
+func TestCreateOrderReportsAllViolationsTogether(t *testing.T) {
+	service, _ := newOrderTestService(t)
+	handler := NewConnectHandler(service)
+
+	// 同时提交非法用户 ID 和两条有问题的条目，应一次性收到全部违规
+	_, err := handler.CreateOrder(context.Background(), connect.NewRequest(&orderv1.CreateOrderRequest{
+		UserId: "not-a-uuid",
+		Items: []*orderv1.OrderItem{
+			{ProductId: "", Quantity: 2, Price: 9.99},
+			{ProductId: "prod-2", Quantity: 0, PriceStr: "abc"},
+		},
+	}))
+	if err == nil {
+		t.Fatal("CreateOrder accepted invalid request, want aggregated validation error")
+	}
+
+	var connectErr *connect.Error
+	if !errors.As(err, &connectErr) || connectErr.Code() != connect.CodeInvalidArgument {
+		t.Fatalf("CreateOrder error = %v, want CodeInvalidArgument", err)
+	}
+
+	fields := make(map[string]bool)
+	for _, detail := range connectErr.Details() {
+		msg, detailErr := detail.Value()
+		if detailErr != nil {
+			t.Fatalf("detail.Value: %v", detailErr)
+		}
+		if ed, ok := msg.(*commonv1.ErrorDetail); ok {
+			fields[ed.Field] = true
+		}
+	}
+
+	for _, want := range []string{
+		"user_id",
+		"items[0].product_id",
+		"items[1].quantity",
+		"items[1].price_str",
+	} {
+		if !fields[want] {
+			t.Errorf("violation for %q missing from error details (got %v)", want, fields)
+		}
+	}
+}
+
+func TestStatusFromProtoCoversEveryEnumValue(t *testing.T) {
+	cases := []struct {
+		status  orderv1.OrderStatus
+		want    string
+		wantErr bool
+	}{
+		{orderv1.OrderStatus_ORDER_STATUS_UNSPECIFIED, "", true},
+		{orderv1.OrderStatus_ORDER_STATUS_PENDING, "pending", false},
+		{orderv1.OrderStatus_ORDER_STATUS_PROCESSING, "processing", false},
+		{orderv1.OrderStatus_ORDER_STATUS_SHIPPED, "shipped", false},
+		{orderv1.OrderStatus_ORDER_STATUS_DELIVERED, "delivered", false},
+		{orderv1.OrderStatus_ORDER_STATUS_CANCELLED, "cancelled", false},
+		// proto 枚举之外的数值同样不能静默落到 pending
+		{orderv1.OrderStatus(99), "", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.status.String(), func(t *testing.T) {
+			got, err := statusFromProto(tc.status)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("statusFromProto(%v) = %q, want error", tc.status, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("statusFromProto(%v): %v", tc.status, err)
+			}
+			if got != tc.want {
+				t.Errorf("statusFromProto(%v) = %q, want %q", tc.status, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestUpdateOrderStatusRejectsUnspecifiedStatus(t *testing.T) {
+	service, _ := newOrderTestService(t)
+	handler := NewConnectHandler(service)
+
+	_, err := handler.UpdateOrderStatus(context.Background(), connect.NewRequest(&orderv1.UpdateOrderStatusRequest{
+		Id:     uuid.NewString(),
+		Status: orderv1.OrderStatus_ORDER_STATUS_UNSPECIFIED,
+	}))
+	if err == nil {
+		t.Fatal("unspecified status accepted, want CodeInvalidArgument")
+	}
+	if code := connect.CodeOf(err); code != connect.CodeInvalidArgument {
+		t.Errorf("CodeOf(err) = %v, want %v", code, connect.CodeInvalidArgument)
+	}
+}
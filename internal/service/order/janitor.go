@@ -0,0 +1,71 @@
+package order
+
+import (
+	"context"
+	"time"
+
+	"micro-holtye/internal/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultIdempotencyKeyTTL 幂等键默认保留时长
+	defaultIdempotencyKeyTTL = 24 * time.Hour
+
+	// defaultIdempotencyCleanupInterval 后台清理任务的默认执行间隔
+	defaultIdempotencyCleanupInterval = 1 * time.Hour
+
+	// idempotencyCleanupTimeout 单次清理的超时时间
+	idempotencyCleanupTimeout = 30 * time.Second
+)
+
+// timeNow 可在测试中替换，用于用假时钟验证过期判定
+var timeNow = time.Now
+
+// StartIdempotencyJanitor 启动后台清理任务，定期删除过期的幂等键
+// interval 和 ttl 非正数时使用默认值；返回停止函数，关闭服务时调用以结束后台 goroutine
+func (s *Service) StartIdempotencyJanitor(interval, ttl time.Duration) func() {
+	if interval <= 0 {
+		interval = defaultIdempotencyCleanupInterval
+	}
+	if ttl <= 0 {
+		ttl = defaultIdempotencyKeyTTL
+	}
+
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.cleanupExpiredIdempotencyKeys(ttl)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// cleanupExpiredIdempotencyKeys 删除创建时间超过 ttl 的幂等键
+func (s *Service) cleanupExpiredIdempotencyKeys(ttl time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), idempotencyCleanupTimeout)
+	defer cancel()
+
+	deleted, err := s.store.DeleteExpiredIdempotencyKeys(ctx, timeNow().Add(-ttl))
+	if err != nil {
+		logger.Warn("Failed to clean up expired idempotency keys", zap.Error(err))
+		return
+	}
+
+	if deleted > 0 {
+		logger.Info("Expired idempotency keys removed",
+			zap.Int64("deleted", deleted),
+			zap.Duration("ttl", ttl),
+		)
+	}
+}
@@ -0,0 +1,61 @@
+package order
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCleanupExpiredIdempotencyKeysUsesFakeClockCutoff(t *testing.T) {
+	service, mock := newOrderTestService(t)
+
+	// 假时钟拨到固定时间点，TTL 为 1 小时：
+	// 2 小时前创建的键应被删除，10 分钟前创建的键应保留
+	now := time.Date(2026, 8, 1, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	ttl := time.Hour
+	expiredAt := now.Add(-2 * time.Hour)
+	freshAt := now.Add(-10 * time.Minute)
+
+	mock.ExpectExec("-- name: DeleteExpiredIdempotencyKeys").
+		WithArgs(now.Add(-ttl)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	service.cleanupExpiredIdempotencyKeys(ttl)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("cutoff mismatch: %v", err)
+	}
+
+	// 游标恰好落在两个创建时间之间，决定了谁删谁留
+	cutoff := now.Add(-ttl)
+	if !expiredAt.Before(cutoff) {
+		t.Errorf("expired key created %v would not be removed by cutoff %v", expiredAt, cutoff)
+	}
+	if freshAt.Before(cutoff) {
+		t.Errorf("fresh key created %v would be removed by cutoff %v", freshAt, cutoff)
+	}
+}
+
+func TestIdempotencyJanitorStopsOnShutdown(t *testing.T) {
+	service, mock := newOrderTestService(t)
+
+	mock.ExpectExec("-- name: DeleteExpiredIdempotencyKeys").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	stop := service.StartIdempotencyJanitor(5*time.Millisecond, time.Hour)
+
+	// 等待至少一次清理触发，再停止后台任务
+	deadline := time.Now().Add(2 * time.Second)
+	for mock.ExpectationsWereMet() != nil && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	stop()
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("janitor never ran a cleanup: %v", err)
+	}
+}
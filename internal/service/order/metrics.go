@@ -0,0 +1,132 @@
+package order
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"micro-holtye/internal/pkg/metrics"
+	orderdb "micro-holtye/internal/service/order/db"
+
+	"github.com/google/uuid"
+)
+
+// instrumentedQuerier 包装 orderdb.Queries，按查询名和结果计数
+type instrumentedQuerier struct {
+	q *orderdb.Queries
+}
+
+// 确保 instrumentedQuerier 实现 orderdb.Querier 接口
+var _ orderdb.Querier = (*instrumentedQuerier)(nil)
+
+func newInstrumentedQuerier(q *orderdb.Queries) *instrumentedQuerier {
+	return &instrumentedQuerier{q: q}
+}
+
+// WithTx 返回绑定到事务的插桩查询器
+func (i *instrumentedQuerier) WithTx(tx *sql.Tx) *instrumentedQuerier {
+	return newInstrumentedQuerier(i.q.WithTx(tx))
+}
+
+// countQuery 记录一次数据库查询的结果计数
+// sql.ErrNoRows 单独归为 not_found，避免污染错误率
+func countQuery(query string, err error) {
+	outcome := "ok"
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		outcome = "not_found"
+	case err != nil:
+		outcome = "error"
+	}
+
+	metrics.Default().Counter("db_queries_total", map[string]string{
+		"service": "order",
+		"query":   query,
+		"outcome": outcome,
+	}).Inc()
+}
+
+func (i *instrumentedQuerier) CancelOrder(ctx context.Context, id uuid.UUID) error {
+	err := i.q.CancelOrder(ctx, id)
+	countQuery("CancelOrder", err)
+	return err
+}
+
+func (i *instrumentedQuerier) CreateIdempotencyKey(ctx context.Context, arg orderdb.CreateIdempotencyKeyParams) error {
+	err := i.q.CreateIdempotencyKey(ctx, arg)
+	countQuery("CreateIdempotencyKey", err)
+	return err
+}
+
+func (i *instrumentedQuerier) CreateOrder(ctx context.Context, arg orderdb.CreateOrderParams) (*orderdb.Order, error) {
+	order, err := i.q.CreateOrder(ctx, arg)
+	countQuery("CreateOrder", err)
+	return order, err
+}
+
+func (i *instrumentedQuerier) CreateOrderItem(ctx context.Context, arg orderdb.CreateOrderItemParams) (*orderdb.OrderItem, error) {
+	item, err := i.q.CreateOrderItem(ctx, arg)
+	countQuery("CreateOrderItem", err)
+	return item, err
+}
+
+func (i *instrumentedQuerier) DeleteExpiredIdempotencyKeys(ctx context.Context, createdAt time.Time) (int64, error) {
+	deleted, err := i.q.DeleteExpiredIdempotencyKeys(ctx, createdAt)
+	countQuery("DeleteExpiredIdempotencyKeys", err)
+	return deleted, err
+}
+
+func (i *instrumentedQuerier) GetIdempotencyKey(ctx context.Context, key string) (*orderdb.IdempotencyKey, error) {
+	record, err := i.q.GetIdempotencyKey(ctx, key)
+	countQuery("GetIdempotencyKey", err)
+	return record, err
+}
+
+func (i *instrumentedQuerier) DeleteOrderItem(ctx context.Context, arg orderdb.DeleteOrderItemParams) (int64, error) {
+	affected, err := i.q.DeleteOrderItem(ctx, arg)
+	countQuery("DeleteOrderItem", err)
+	return affected, err
+}
+
+func (i *instrumentedQuerier) GetOrder(ctx context.Context, id uuid.UUID) (*orderdb.Order, error) {
+	order, err := i.q.GetOrder(ctx, id)
+	countQuery("GetOrder", err)
+	return order, err
+}
+
+func (i *instrumentedQuerier) GetOrderByNumber(ctx context.Context, orderNumber string) (*orderdb.Order, error) {
+	order, err := i.q.GetOrderByNumber(ctx, orderNumber)
+	countQuery("GetOrderByNumber", err)
+	return order, err
+}
+
+func (i *instrumentedQuerier) GetOrderForUpdate(ctx context.Context, id uuid.UUID) (*orderdb.Order, error) {
+	order, err := i.q.GetOrderForUpdate(ctx, id)
+	countQuery("GetOrderForUpdate", err)
+	return order, err
+}
+
+func (i *instrumentedQuerier) GetOrderItems(ctx context.Context, orderID uuid.UUID) ([]*orderdb.OrderItem, error) {
+	items, err := i.q.GetOrderItems(ctx, orderID)
+	countQuery("GetOrderItems", err)
+	return items, err
+}
+
+func (i *instrumentedQuerier) ListOrdersByUser(ctx context.Context, arg orderdb.ListOrdersByUserParams) ([]*orderdb.Order, error) {
+	orders, err := i.q.ListOrdersByUser(ctx, arg)
+	countQuery("ListOrdersByUser", err)
+	return orders, err
+}
+
+func (i *instrumentedQuerier) UpdateOrderStatus(ctx context.Context, arg orderdb.UpdateOrderStatusParams) (*orderdb.Order, error) {
+	order, err := i.q.UpdateOrderStatus(ctx, arg)
+	countQuery("UpdateOrderStatus", err)
+	return order, err
+}
+
+func (i *instrumentedQuerier) UpdateOrderTotal(ctx context.Context, arg orderdb.UpdateOrderTotalParams) (*orderdb.Order, error) {
+	order, err := i.q.UpdateOrderTotal(ctx, arg)
+	countQuery("UpdateOrderTotal", err)
+	return order, err
+}
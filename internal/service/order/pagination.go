@@ -0,0 +1,41 @@
+package order
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 分页令牌对调用方不透明，内部编码下一页的偏移量
+const pageTokenPrefix = "offset:"
+
+// encodePageToken 将偏移量编码为不透明的分页令牌
+func encodePageToken(offset int32) string {
+	return base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s%d", pageTokenPrefix, offset)))
+}
+
+// decodePageToken 解析分页令牌为偏移量
+// 空令牌或无法解析的令牌从头开始（偏移量 0）
+func decodePageToken(token string) int32 {
+	if token == "" {
+		return 0
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return 0
+	}
+
+	value, ok := strings.CutPrefix(string(raw), pageTokenPrefix)
+	if !ok {
+		return 0
+	}
+
+	offset, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || offset < 0 {
+		return 0
+	}
+
+	return int32(offset)
+}
@@ -0,0 +1,14 @@
+-- name: CreateIdempotencyKey :exec
+INSERT INTO idempotency_keys (
+    key, order_id
+) VALUES (
+    $1, $2
+);
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE key = $1 LIMIT 1;
+
+-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_keys
+WHERE created_at < $1;
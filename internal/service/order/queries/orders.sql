@@ -9,6 +9,10 @@ INSERT INTO orders (
 SELECT * FROM orders
 WHERE id = $1 LIMIT 1;
 
+-- name: GetOrderByNumber :one
+SELECT * FROM orders
+WHERE order_number = $1 LIMIT 1;
+
 -- name: UpdateOrderStatus :one
 UPDATE orders
 SET
@@ -37,7 +41,23 @@ INSERT INTO order_items (
     $1, $2, $3, $4, $5
 ) RETURNING *;
 
+-- name: GetOrderForUpdate :one
+SELECT * FROM orders
+WHERE id = $1 LIMIT 1
+FOR UPDATE;
+
+-- name: DeleteOrderItem :execrows
+DELETE FROM order_items
+WHERE id = $1 AND order_id = $2;
+
 -- name: GetOrderItems :many
 SELECT * FROM order_items
 WHERE order_id = $1
-ORDER BY created_at;
\ No newline at end of file
+ORDER BY created_at;
+-- name: UpdateOrderTotal :one
+UPDATE orders
+SET
+    total_amount = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
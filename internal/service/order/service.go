@@ -5,19 +5,83 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+
+	"micro-holtye/internal/pkg/apierror"
+	"micro-holtye/internal/pkg/audit"
+	"micro-holtye/internal/pkg/logger"
 	orderdb "micro-holtye/internal/service/order/db"
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
 )
 
+// ErrIdempotencyKeyInProgress 表示同一幂等键的另一请求正在创建订单，调用方应稍后重试
+var ErrIdempotencyKeyInProgress = errors.New("another request with this idempotency key is in progress")
+
+// ErrOrderNotCancellable 表示订单已进入不可取消的状态（已发货或已送达）
+var ErrOrderNotCancellable = errors.New("order can no longer be cancelled")
+
+// ErrOrderNotPending 表示订单已不在 pending 状态，条目不可再修改
+var ErrOrderNotPending = errors.New("order is no longer pending")
+
+// ErrItemNotFound 表示订单条目不存在或不属于该订单
+var ErrItemNotFound = errors.New("order item not found")
+
+// ErrNotFound 表示订单不存在，处理器用 errors.Is 映射为 CodeNotFound
+var ErrNotFound = errors.New("order not found")
+
+// ErrInvalidID 表示传入的标识不是合法的 UUID
+var ErrInvalidID = errors.New("invalid ID")
+
+// orderStatusTransitions 定义订单状态机的合法迁移：
+// pending → processing → shipped → delivered，取消仅允许在发货前。
+// delivered 和 cancelled 为终态。
+var orderStatusTransitions = map[string][]string{
+	"pending":    {"processing", "cancelled"},
+	"processing": {"shipped", "cancelled"},
+	"shipped":    {"delivered"},
+	"delivered":  {},
+	"cancelled":  {},
+}
+
+// IsKnownStatus 报告 status 是否为状态机中已知的订单状态
+func IsKnownStatus(status string) bool {
+	_, ok := orderStatusTransitions[status]
+	return ok
+}
+
+// InvalidTransitionError 表示一次非法的订单状态迁移
+type InvalidTransitionError struct {
+	From string
+	To   string
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("cannot transition order from %q to %q", e.From, e.To)
+}
+
+// canTransition 判断从 from 到 to 的迁移是否合法
+func canTransition(from, to string) bool {
+	for _, next := range orderStatusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
 type Service struct {
-	store *Store
+	store  *Store
+	logger logger.Logger
+	// streamBatchSize 为 StreamOrders 分页批大小，0 使用存储层默认值
+	streamBatchSize int32
 }
 
-func NewService(store *Store) *Service {
+func NewService(store *Store, logger logger.Logger) *Service {
 	return &Service{
-		store: store,
+		store:  store,
+		logger: logger,
 	}
 }
 
@@ -26,29 +90,143 @@ type OrderItemInput struct {
 	ProductName string
 	Quantity    int32
 	Price       float64
+	// PriceStr 为精确的十进制价格字符串，非空时优先于 Price 使用，避免浮点精度损失
+	PriceStr string
 }
 
-func (s *Service) CreateOrder(ctx context.Context, userID string, items []OrderItemInput) (*orderdb.Order, []*orderdb.OrderItem, error) {
-	if len(items) == 0 {
-		return nil, nil, errors.New("order must have at least one item")
+// priceDecimal 解析条目价格：优先使用精确的 PriceStr，回退到 double Price
+func (i OrderItemInput) priceDecimal() (decimal.Decimal, error) {
+	if i.PriceStr != "" {
+		return decimal.NewFromString(i.PriceStr)
 	}
+	return decimal.NewFromFloat(i.Price), nil
+}
+
+// ValidationError 聚合 CreateOrder 的全部校验违规，一次性返回给调用方
+type ValidationError struct {
+	Violations []apierror.Violation
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("order validation failed with %d violation(s)", len(e.Violations))
+}
+
+// validateCreateOrder 收集订单级和条目级的所有校验违规，不在首个问题处提前返回
+func validateCreateOrder(userID string, items []OrderItemInput) (uuid.UUID, *ValidationError) {
+	var violations []apierror.Violation
 
 	userUUID, err := uuid.Parse(userID)
 	if err != nil {
-		return nil, nil, fmt.Errorf("invalid user ID: %w", err)
+		violations = append(violations, apierror.Violation{
+			Field:       "user_id",
+			Description: "invalid user ID",
+		})
 	}
 
+	if len(items) == 0 {
+		violations = append(violations, apierror.Violation{
+			Field:       "items",
+			Description: "order must have at least one item",
+		})
+	}
+	for i, item := range items {
+		if item.ProductID == "" {
+			violations = append(violations, apierror.Violation{
+				Field:       fmt.Sprintf("items[%d].product_id", i),
+				Description: "product ID is required",
+			})
+		}
+		if item.Quantity <= 0 {
+			violations = append(violations, apierror.Violation{
+				Field:       fmt.Sprintf("items[%d].quantity", i),
+				Description: "quantity must be positive",
+			})
+		}
+		price, err := item.priceDecimal()
+		if err != nil {
+			violations = append(violations, apierror.Violation{
+				Field:       fmt.Sprintf("items[%d].price_str", i),
+				Description: "invalid decimal price",
+			})
+		} else if price.IsNegative() {
+			violations = append(violations, apierror.Violation{
+				Field:       fmt.Sprintf("items[%d].price", i),
+				Description: "price must not be negative",
+			})
+		}
+	}
+
+	if len(violations) > 0 {
+		return uuid.UUID{}, &ValidationError{Violations: violations}
+	}
+	return userUUID, nil
+}
+
+// CreateOrder 创建订单。idempotencyKey 非空时启用幂等去重：
+// 重复请求返回首次创建的订单，键到订单的映射与订单创建在同一事务内写入，
+// 保留时长由后台清理任务（server.idempotency.key_ttl）控制。
+func (s *Service) CreateOrder(ctx context.Context, userID string, items []OrderItemInput, idempotencyKey string) (*orderdb.Order, []*orderdb.OrderItem, error) {
+	userUUID, verr := validateCreateOrder(userID, items)
+	if verr != nil {
+		s.logger.WarnContext(ctx, "CreateOrder rejected by input validation",
+			zap.String("user_id", userID),
+			logger.Operation("CreateOrder"),
+			logger.Component("order-service"),
+			logger.ErrorCode("INVALID_ARGUMENT"),
+		)
+		return nil, nil, verr
+	}
+
+	s.logger.InfoContext(ctx, "CreateOrder request started",
+		logger.UserID(userID),
+		zap.Int("item_count", len(items)),
+		logger.Operation("CreateOrder"),
+		logger.Component("order-service"),
+	)
+
+	if idempotencyKey != "" {
+		// 重复请求直接返回原订单
+		order, orderItems, found, err := s.findOrderByIdempotencyKey(ctx, idempotencyKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		if found {
+			return order, orderItems, nil
+		}
+
+		// Redis 锁串行化共享同一键的并发请求；锁被占用说明另一请求正在创建
+		release, acquired, err := s.store.AcquireIdempotencyLock(ctx, idempotencyKey)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to acquire idempotency lock: %w", err)
+		}
+		if !acquired {
+			return nil, nil, ErrIdempotencyKeyInProgress
+		}
+		defer release()
+
+		// 拿到锁后复查映射，覆盖并发请求刚提交完成的窗口
+		order, orderItems, found, err = s.findOrderByIdempotencyKey(ctx, idempotencyKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		if found {
+			return order, orderItems, nil
+		}
+	}
+
+	// 校验已保证 priceDecimal 不会失败，金额全程以 decimal 计算，不经过 float 换算
 	totalAmount := decimal.Zero
-	for _, item := range items {
-		price := decimal.NewFromFloat(item.Price)
-		quantity := decimal.NewFromInt32(item.Quantity)
-		totalAmount = totalAmount.Add(price.Mul(quantity))
+	prices := make([]decimal.Decimal, len(items))
+	for i, item := range items {
+		prices[i], _ = item.priceDecimal()
+		totalAmount = totalAmount.Add(prices[i].Mul(decimal.NewFromInt32(item.Quantity)))
 	}
 
 	var order *orderdb.Order
 	var orderItems []*orderdb.OrderItem
 
-	err = s.store.WithTx(ctx, func(txStore *Store) error {
+	err := s.store.WithTx(ctx, func(txStore *Store) error {
+		var err error
 		order, err = txStore.CreateOrder(ctx, orderdb.CreateOrderParams{
 			UserID:      userUUID,
 			TotalAmount: totalAmount.String(),
@@ -58,13 +236,13 @@ func (s *Service) CreateOrder(ctx context.Context, userID string, items []OrderI
 			return fmt.Errorf("failed to create order: %w", err)
 		}
 
-		for _, item := range items {
+		for i, item := range items {
 			orderItem, err := txStore.CreateOrderItem(ctx, orderdb.CreateOrderItemParams{
 				OrderID:     order.ID,
 				ProductID:   item.ProductID,
 				ProductName: item.ProductName,
 				Quantity:    item.Quantity,
-				Price:       decimal.NewFromFloat(item.Price).String(),
+				Price:       prices[i].String(),
 			})
 			if err != nil {
 				return fmt.Errorf("failed to create order item: %w", err)
@@ -72,26 +250,67 @@ func (s *Service) CreateOrder(ctx context.Context, userID string, items []OrderI
 			orderItems = append(orderItems, orderItem)
 		}
 
+		if idempotencyKey != "" {
+			if err := txStore.CreateIdempotencyKey(ctx, idempotencyKey, order.ID); err != nil {
+				return fmt.Errorf("failed to record idempotency key: %w", err)
+			}
+		}
+
 		return nil
 	})
 
 	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to create order",
+			logger.UserID(userID),
+			logger.Operation("CreateOrder"),
+			logger.Component("order-service"),
+			zap.Error(err),
+		)
 		return nil, nil, err
 	}
 
+	s.logger.InfoContext(ctx, "Order created",
+		logger.UserID(userID),
+		zap.String("order_id", order.ID.String()),
+		zap.String("order_number", order.OrderNumber),
+		zap.String("total_amount", order.TotalAmount),
+		logger.Operation("CreateOrder"),
+		logger.Component("order-service"),
+	)
 	return order, orderItems, nil
 }
 
+// findOrderByIdempotencyKey 查询幂等键已关联的订单，未找到时 found 为 false
+func (s *Service) findOrderByIdempotencyKey(ctx context.Context, key string) (*orderdb.Order, []*orderdb.OrderItem, bool, error) {
+	record, err := s.store.GetIdempotencyKey(ctx, key)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, false, nil
+		}
+		return nil, nil, false, fmt.Errorf("failed to look up idempotency key: %w", err)
+	}
+
+	order, err := s.store.GetOrder(ctx, record.OrderID)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("failed to get order for idempotency key: %w", err)
+	}
+	items, err := s.store.GetOrderItems(ctx, record.OrderID)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("failed to get order items: %w", err)
+	}
+	return order, items, true, nil
+}
+
 func (s *Service) GetOrder(ctx context.Context, id string) (*orderdb.Order, error) {
 	orderUUID, err := uuid.Parse(id)
 	if err != nil {
-		return nil, fmt.Errorf("invalid order ID: %w", err)
+		return nil, fmt.Errorf("invalid order ID: %w", ErrInvalidID)
 	}
 
 	order, err := s.store.GetOrder(ctx, orderUUID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("order not found")
+			return nil, ErrNotFound
 		}
 		return nil, fmt.Errorf("failed to get order: %w", err)
 	}
@@ -101,13 +320,13 @@ func (s *Service) GetOrder(ctx context.Context, id string) (*orderdb.Order, erro
 func (s *Service) GetOrderWithItems(ctx context.Context, id string) (*orderdb.Order, []*orderdb.OrderItem, error) {
 	orderUUID, err := uuid.Parse(id)
 	if err != nil {
-		return nil, nil, fmt.Errorf("invalid order ID: %w", err)
+		return nil, nil, fmt.Errorf("invalid order ID: %w", ErrInvalidID)
 	}
 
 	order, err := s.store.GetOrder(ctx, orderUUID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, nil, errors.New("order not found")
+			return nil, nil, ErrNotFound
 		}
 		return nil, nil, fmt.Errorf("failed to get order: %w", err)
 	}
@@ -120,18 +339,324 @@ func (s *Service) GetOrderWithItems(ctx context.Context, id string) (*orderdb.Or
 	return order, items, nil
 }
 
+func (s *Service) GetOrderByNumber(ctx context.Context, orderNumber string) (*orderdb.Order, []*orderdb.OrderItem, error) {
+	if orderNumber == "" {
+		return nil, nil, errors.New("order number is required")
+	}
+
+	order, err := s.store.GetOrderByNumber(ctx, orderNumber)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	items, err := s.store.GetOrderItems(ctx, order.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get order items: %w", err)
+	}
+
+	return order, items, nil
+}
+
+// validateOrderItem 校验单个订单条目，违规时返回 ValidationError
+func validateOrderItem(item OrderItemInput) *ValidationError {
+	var violations []apierror.Violation
+
+	if item.ProductID == "" {
+		violations = append(violations, apierror.Violation{
+			Field:       "item.product_id",
+			Description: "product ID is required",
+		})
+	}
+	if item.Quantity <= 0 {
+		violations = append(violations, apierror.Violation{
+			Field:       "item.quantity",
+			Description: "quantity must be positive",
+		})
+	}
+	price, err := item.priceDecimal()
+	if err != nil {
+		violations = append(violations, apierror.Violation{
+			Field:       "item.price_str",
+			Description: "invalid decimal price",
+		})
+	} else if price.IsNegative() {
+		violations = append(violations, apierror.Violation{
+			Field:       "item.price",
+			Description: "price must not be negative",
+		})
+	}
+
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+	return nil
+}
+
+// lockPendingOrder 在事务内取订单行锁并校验仍处于 pending 状态
+// 行锁串行化并发的条目修改，保证总额与条目始终一致
+func lockPendingOrder(ctx context.Context, txStore *Store, orderID uuid.UUID) error {
+	order, err := txStore.GetOrderForUpdate(ctx, orderID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to lock order: %w", err)
+	}
+	if order.Status != "pending" {
+		return ErrOrderNotPending
+	}
+	return nil
+}
+
+// recalcOrderTotal 在事务内按当前条目重算并写回订单总额
+func recalcOrderTotal(ctx context.Context, txStore *Store, orderID uuid.UUID) (*orderdb.Order, []*orderdb.OrderItem, error) {
+	items, err := txStore.GetOrderItems(ctx, orderID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get order items: %w", err)
+	}
+
+	total := decimal.Zero
+	for _, item := range items {
+		price, err := decimal.NewFromString(item.Price)
+		if err != nil {
+			return nil, nil, fmt.Errorf("order item %s has invalid price %q: %w", item.ID, item.Price, err)
+		}
+		total = total.Add(price.Mul(decimal.NewFromInt32(item.Quantity)))
+	}
+
+	order, err := txStore.UpdateOrderTotal(ctx, orderdb.UpdateOrderTotalParams{
+		ID:          orderID,
+		TotalAmount: total.String(),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to update order total: %w", err)
+	}
+	return order, items, nil
+}
+
+// AddOrderItem 向 pending 状态的订单追加条目并在同一事务内重算总额。
+// 非 pending 订单返回 ErrOrderNotPending，处理器映射为 CodeFailedPrecondition。
+func (s *Service) AddOrderItem(ctx context.Context, orderID string, item OrderItemInput) (*orderdb.Order, []*orderdb.OrderItem, error) {
+	orderUUID, err := uuid.Parse(orderID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid order ID: %w", ErrInvalidID)
+	}
+	if verr := validateOrderItem(item); verr != nil {
+		return nil, nil, verr
+	}
+
+	// 校验已保证 priceDecimal 不会失败
+	price, _ := item.priceDecimal()
+
+	var order *orderdb.Order
+	var items []*orderdb.OrderItem
+	err = s.store.WithTx(ctx, func(txStore *Store) error {
+		if err := lockPendingOrder(ctx, txStore, orderUUID); err != nil {
+			return err
+		}
+
+		if _, err := txStore.CreateOrderItem(ctx, orderdb.CreateOrderItemParams{
+			OrderID:     orderUUID,
+			ProductID:   item.ProductID,
+			ProductName: item.ProductName,
+			Quantity:    item.Quantity,
+			Price:       price.String(),
+		}); err != nil {
+			return fmt.Errorf("failed to create order item: %w", err)
+		}
+
+		var err error
+		order, items, err = recalcOrderTotal(ctx, txStore, orderUUID)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.logger.InfoContext(ctx, "Order item added",
+		zap.String("order_id", orderID),
+		zap.String("product_id", item.ProductID),
+		zap.String("total_amount", order.TotalAmount),
+		logger.Operation("AddOrderItem"),
+		logger.Component("order-service"),
+	)
+	return order, items, nil
+}
+
+// RemoveOrderItem 从 pending 状态的订单移除条目并在同一事务内重算总额。
+// 条目不存在或不属于该订单时返回 ErrItemNotFound。
+func (s *Service) RemoveOrderItem(ctx context.Context, orderID, itemID string) (*orderdb.Order, []*orderdb.OrderItem, error) {
+	orderUUID, err := uuid.Parse(orderID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid order ID: %w", ErrInvalidID)
+	}
+	itemUUID, err := uuid.Parse(itemID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid item ID: %w", ErrInvalidID)
+	}
+
+	var order *orderdb.Order
+	var items []*orderdb.OrderItem
+	err = s.store.WithTx(ctx, func(txStore *Store) error {
+		if err := lockPendingOrder(ctx, txStore, orderUUID); err != nil {
+			return err
+		}
+
+		affected, err := txStore.DeleteOrderItem(ctx, orderdb.DeleteOrderItemParams{
+			ID:      itemUUID,
+			OrderID: orderUUID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete order item: %w", err)
+		}
+		if affected == 0 {
+			return ErrItemNotFound
+		}
+
+		order, items, err = recalcOrderTotal(ctx, txStore, orderUUID)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.logger.InfoContext(ctx, "Order item removed",
+		zap.String("order_id", orderID),
+		zap.String("item_id", itemID),
+		zap.String("total_amount", order.TotalAmount),
+		logger.Operation("RemoveOrderItem"),
+		logger.Component("order-service"),
+	)
+	return order, items, nil
+}
+
+// RecalculateResult RecalculateTotal 的校验结果
+type RecalculateResult struct {
+	OrderID       string
+	StoredTotal   string
+	ComputedTotal string
+	// Discrepancy 为 true 表示存储总额与按明细重算的结果不一致
+	Discrepancy bool
+	// Corrected 为 true 表示已用重算结果修正了存储总额
+	Corrected bool
+}
+
+// RecalculateTotal 按明细重算订单总额（quantity * price 的 decimal 精确求和）
+// 并与存储值比对，correct 为 true 且发现漂移时修正存储总额。
+// 读取与修正在同一事务内完成，避免与并发的明细变更交错。
+func (s *Service) RecalculateTotal(ctx context.Context, id string, correct bool) (*RecalculateResult, error) {
+	orderUUID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid order ID: %w", ErrInvalidID)
+	}
+
+	var result *RecalculateResult
+	err = s.store.WithTx(ctx, func(txStore *Store) error {
+		order, err := txStore.GetOrder(ctx, orderUUID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNotFound
+			}
+			return fmt.Errorf("failed to get order: %w", err)
+		}
+
+		items, err := txStore.GetOrderItems(ctx, orderUUID)
+		if err != nil {
+			return fmt.Errorf("failed to get order items: %w", err)
+		}
+
+		computed := decimal.Zero
+		for _, item := range items {
+			price, err := decimal.NewFromString(item.Price)
+			if err != nil {
+				return fmt.Errorf("order item %s has invalid price %q: %w", item.ID, item.Price, err)
+			}
+			computed = computed.Add(price.Mul(decimal.NewFromInt32(item.Quantity)))
+		}
+
+		stored, err := decimal.NewFromString(order.TotalAmount)
+		if err != nil {
+			return fmt.Errorf("order has invalid total amount %q: %w", order.TotalAmount, err)
+		}
+
+		result = &RecalculateResult{
+			OrderID:       order.ID.String(),
+			StoredTotal:   order.TotalAmount,
+			ComputedTotal: computed.String(),
+			Discrepancy:   !stored.Equal(computed),
+		}
+
+		if result.Discrepancy && correct {
+			if _, err := txStore.UpdateOrderTotal(ctx, orderdb.UpdateOrderTotalParams{
+				ID:          orderUUID,
+				TotalAmount: computed.String(),
+			}); err != nil {
+				return fmt.Errorf("failed to correct order total: %w", err)
+			}
+			result.Corrected = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Discrepancy {
+		s.logger.WarnContext(ctx, "Order total discrepancy detected",
+			zap.String("order_id", result.OrderID),
+			zap.String("stored_total", result.StoredTotal),
+			zap.String("computed_total", result.ComputedTotal),
+			zap.Bool("corrected", result.Corrected),
+			logger.Operation("RecalculateTotal"),
+			logger.Component("order-service"),
+		)
+	}
+	return result, nil
+}
+
+// UpdateOrderStatus 更新订单状态，迁移需符合 orderStatusTransitions 定义的状态机；
+// 状态读取与更新在同一事务内完成。重复提交当前状态视为幂等成功。
 func (s *Service) UpdateOrderStatus(ctx context.Context, id string, status string) (*orderdb.Order, error) {
 	orderUUID, err := uuid.Parse(id)
 	if err != nil {
-		return nil, fmt.Errorf("invalid order ID: %w", err)
+		return nil, fmt.Errorf("invalid order ID: %w", ErrInvalidID)
+	}
+	if !IsKnownStatus(status) {
+		return nil, fmt.Errorf("unknown order status %q", status)
 	}
 
-	order, err := s.store.UpdateOrderStatus(ctx, orderdb.UpdateOrderStatusParams{
-		ID:     orderUUID,
-		Status: status,
+	var order *orderdb.Order
+	err = s.store.WithTx(ctx, func(txStore *Store) error {
+		current, err := txStore.GetOrder(ctx, orderUUID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNotFound
+			}
+			return fmt.Errorf("failed to get order: %w", err)
+		}
+
+		if current.Status == status {
+			order = current
+			return nil
+		}
+		if !canTransition(current.Status, status) {
+			return &InvalidTransitionError{From: current.Status, To: status}
+		}
+
+		order, err = txStore.UpdateOrderStatus(ctx, orderdb.UpdateOrderStatusParams{
+			ID:     orderUUID,
+			Status: status,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update order status: %w", err)
+		}
+		return nil
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to update order status: %w", err)
+		return nil, err
 	}
 
 	return order, nil
@@ -140,7 +665,7 @@ func (s *Service) UpdateOrderStatus(ctx context.Context, id string, status strin
 func (s *Service) ListOrdersByUser(ctx context.Context, userID string, pageSize int32, offset int32) ([]*orderdb.Order, error) {
 	userUUID, err := uuid.Parse(userID)
 	if err != nil {
-		return nil, fmt.Errorf("invalid user ID: %w", err)
+		return nil, fmt.Errorf("invalid user ID: %w", ErrInvalidID)
 	}
 
 	orders, err := s.store.ListOrdersByUser(ctx, userUUID, pageSize, offset)
@@ -150,14 +675,78 @@ func (s *Service) ListOrdersByUser(ctx context.Context, userID string, pageSize
 	return orders, nil
 }
 
+// SetStreamBatchSize 配置 StreamOrders 的分页批大小（server.stream_batch_size），
+// n <= 0 时保持存储层默认值
+func (s *Service) SetStreamBatchSize(n int32) {
+	s.streamBatchSize = n
+}
+
+// StreamOrders 分批遍历用户的全部订单并逐条回调 fn
+func (s *Service) StreamOrders(ctx context.Context, userID string, fn func(*orderdb.Order) error) error {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return fmt.Errorf("invalid user ID: %w", ErrInvalidID)
+	}
+
+	if err := s.store.ForEachOrderByUser(ctx, userUUID, s.streamBatchSize, fn); err != nil {
+		if err == ctx.Err() {
+			return err
+		}
+		return fmt.Errorf("failed to stream orders: %w", err)
+	}
+	return nil
+}
+
+// CancelOrder 取消订单。仅允许从 pending / processing 状态取消；
+// 读取状态和写入取消在同一事务内完成，避免与并发的状态更新产生竞态。
 func (s *Service) CancelOrder(ctx context.Context, id string) error {
 	orderUUID, err := uuid.Parse(id)
 	if err != nil {
-		return fmt.Errorf("invalid order ID: %w", err)
+		return fmt.Errorf("invalid order ID: %w", ErrInvalidID)
 	}
 
-	if err := s.store.CancelOrder(ctx, orderUUID); err != nil {
-		return fmt.Errorf("failed to cancel order: %w", err)
+	err = s.store.WithTx(ctx, func(txStore *Store) error {
+		order, err := txStore.GetOrder(ctx, orderUUID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNotFound
+			}
+			return fmt.Errorf("failed to get order: %w", err)
+		}
+
+		switch order.Status {
+		case "pending", "processing":
+			// 允许取消
+		case "cancelled":
+			// 重复取消视为成功，保持幂等
+			return nil
+		default:
+			s.logger.WarnContext(ctx, "Order cancellation blocked by status",
+				zap.String("order_id", id),
+				zap.String("status", order.Status),
+				logger.Operation("CancelOrder"),
+				logger.Component("order-service"),
+			)
+			return ErrOrderNotCancellable
+		}
+
+		if err := txStore.CancelOrder(ctx, orderUUID); err != nil {
+			return fmt.Errorf("failed to cancel order: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
+
+	s.logger.InfoContext(ctx, "Order cancelled",
+		zap.String("order_id", id),
+		logger.Operation("CancelOrder"),
+		logger.Component("order-service"),
+	)
+	audit.Record(ctx, audit.Event{
+		Action: audit.ActionOrderCancelled,
+		Target: id,
+	})
 	return nil
 }
@@ -0,0 +1,400 @@
+package order
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"micro-holtye/internal/pkg/logger"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+// orderColumns 与 orders 表查询返回的列保持一致
+var orderColumns = []string{
+	"id", "user_id", "total_amount", "status", "created_at", "updated_at", "order_number",
+}
+
+// orderRow 构造一行 orders 查询结果，供 sqlmock 返回
+func orderRow(id uuid.UUID, orderNumber string) *sqlmock.Rows {
+	now := time.Now()
+	return sqlmock.NewRows(orderColumns).AddRow(
+		id, uuid.New(), "42.50", "pending", now, now, orderNumber,
+	)
+}
+
+// newOrderTestService 用 sqlmock 支撑的订单服务，返回服务和 sqlmock 句柄
+func newOrderTestService(t *testing.T) (*Service, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return NewService(NewStore(db), logger.NewMemoryLogger()), mock
+}
+
+func TestGetOrderByNumberReturnsMatchingOrder(t *testing.T) {
+	service, mock := newOrderTestService(t)
+
+	orderID := uuid.New()
+	mock.ExpectQuery("-- name: GetOrderByNumber").WithArgs("ORD-0000000042").
+		WillReturnRows(orderRow(orderID, "ORD-0000000042"))
+	mock.ExpectQuery("-- name: GetOrderItems").WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "product_id", "product_name", "quantity", "price", "created_at"}))
+
+	order, items, err := service.GetOrderByNumber(context.Background(), "ORD-0000000042")
+	if err != nil {
+		t.Fatalf("GetOrderByNumber: %v", err)
+	}
+	if order.OrderNumber != "ORD-0000000042" {
+		t.Errorf("OrderNumber = %q, want ORD-0000000042", order.OrderNumber)
+	}
+	if order.ID != orderID {
+		t.Errorf("ID = %v, want %v", order.ID, orderID)
+	}
+	if len(items) != 0 {
+		t.Errorf("got %d items, want 0", len(items))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet sqlmock expectations: %v", err)
+	}
+}
+
+func TestGetOrderByNumberUnknownNumberMapsToNotFound(t *testing.T) {
+	service, mock := newOrderTestService(t)
+
+	mock.ExpectQuery("-- name: GetOrderByNumber").WithArgs("ORD-9999999999").
+		WillReturnError(sql.ErrNoRows)
+
+	_, _, err := service.GetOrderByNumber(context.Background(), "ORD-9999999999")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestGetOrderByNumberRejectsEmptyNumber(t *testing.T) {
+	service, _ := newOrderTestService(t)
+
+	if _, _, err := service.GetOrderByNumber(context.Background(), ""); err == nil {
+		t.Error("empty order number accepted, want error")
+	}
+}
+
+func TestCreateOrderTotalStaysExactForDriftProneAmounts(t *testing.T) {
+	service, mock := newOrderTestService(t)
+
+	userID := uuid.New()
+	orderID := uuid.New()
+	now := time.Now()
+
+	// 19.99 × 3 经 float64 计算会得到 59.970000000000006，decimal 下必须是 59.97
+	mock.ExpectBegin()
+	mock.ExpectQuery("-- name: CreateOrder :one").
+		WithArgs(userID, "59.97", "pending").
+		WillReturnRows(sqlmock.NewRows(orderColumns).AddRow(
+			orderID, userID, "59.97", "pending", now, now, "ORD-20260801-000001",
+		))
+	mock.ExpectQuery("-- name: CreateOrderItem :one").
+		WithArgs(orderID, "prod-1", "Widget", int32(3), "19.99").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "order_id", "product_id", "product_name", "quantity", "price", "created_at",
+		}).AddRow(uuid.New(), orderID, "prod-1", "Widget", int32(3), "19.99", now))
+	mock.ExpectCommit()
+
+	order, _, err := service.CreateOrder(context.Background(), userID.String(), []OrderItemInput{
+		{ProductID: "prod-1", ProductName: "Widget", Quantity: 3, PriceStr: "19.99"},
+	}, "")
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+
+	if order.TotalAmount != "59.97" {
+		t.Errorf("TotalAmount = %q, want exact 59.97", order.TotalAmount)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("total sent to the database drifted: %v", err)
+	}
+}
+
+func TestCancelOrderTransitionsPerStartingStatus(t *testing.T) {
+	cases := []struct {
+		status  string
+		allowed bool
+		// cancels 为 true 时期望实际执行取消语句（幂等的 cancelled 状态直接成功）
+		cancels bool
+	}{
+		{"pending", true, true},
+		{"processing", true, true},
+		{"cancelled", true, false},
+		{"shipped", false, false},
+		{"delivered", false, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.status, func(t *testing.T) {
+			service, mock := newOrderTestService(t)
+			orderID := uuid.New()
+			now := time.Now()
+
+			mock.ExpectBegin()
+			mock.ExpectQuery("-- name: GetOrder :one").
+				WithArgs(orderID).
+				WillReturnRows(sqlmock.NewRows(orderColumns).AddRow(
+					orderID, uuid.New(), "42.50", tc.status, now, now, "ORD-1",
+				))
+			switch {
+			case tc.cancels:
+				mock.ExpectExec("-- name: CancelOrder :exec").
+					WithArgs(orderID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			case tc.allowed:
+				mock.ExpectCommit()
+			default:
+				mock.ExpectRollback()
+			}
+
+			err := service.CancelOrder(context.Background(), orderID.String())
+
+			if tc.allowed && err != nil {
+				t.Fatalf("CancelOrder from %s: %v, want success", tc.status, err)
+			}
+			if !tc.allowed && !errors.Is(err, ErrOrderNotCancellable) {
+				t.Fatalf("CancelOrder from %s: %v, want ErrOrderNotCancellable", tc.status, err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unexpected statement flow from %s: %v", tc.status, err)
+			}
+		})
+	}
+}
+
+func TestOrderServiceLogsWithOperationAndComponent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	mem := logger.NewMemoryLogger()
+	service := NewService(NewStore(db), mem)
+	orderID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("-- name: GetOrder :one").
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows(orderColumns).AddRow(
+			orderID, uuid.New(), "42.50", "pending", now, now, "ORD-1",
+		))
+	mock.ExpectExec("-- name: CancelOrder :exec").
+		WithArgs(orderID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := service.CancelOrder(context.Background(), orderID.String()); err != nil {
+		t.Fatalf("CancelOrder: %v", err)
+	}
+
+	// 与 user/gateway 服务一致：结构化日志带 operation / component 字段
+	entry, ok := mem.LastEntry()
+	if !ok {
+		t.Fatal("order service emitted no log entries")
+	}
+	if !entry.FieldEquals("operation", "CancelOrder") {
+		t.Errorf("operation field = %v, want CancelOrder", entry.FieldMap()["operation"])
+	}
+	if !entry.FieldEquals("component", "order-service") {
+		t.Errorf("component field = %v, want order-service", entry.FieldMap()["component"])
+	}
+}
+
+// itemColumns 与 order_items 表查询返回的列保持一致
+var itemColumns = []string{"id", "order_id", "product_id", "product_name", "quantity", "price", "created_at"}
+
+// expectRecalculateRead 设置事务内读取订单（总额 59.99）和两条明细（实际合计 59.97）的期望
+func expectRecalculateRead(mock sqlmock.Sqlmock, orderID uuid.UUID) {
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery("-- name: GetOrder :one").WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows(orderColumns).AddRow(
+			orderID, uuid.New(), "59.99", "pending", now, now, "ORD-0000000001",
+		))
+	mock.ExpectQuery("-- name: GetOrderItems").WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows(itemColumns).
+			AddRow(uuid.New(), orderID, "p-1", "widget", int32(2), "19.99", now).
+			AddRow(uuid.New(), orderID, "p-2", "gadget", int32(1), "19.99", now))
+}
+
+func TestRecalculateTotalDetectsDriftWithoutCorrecting(t *testing.T) {
+	service, mock := newOrderTestService(t)
+	orderID := uuid.New()
+
+	expectRecalculateRead(mock, orderID)
+	mock.ExpectCommit()
+
+	result, err := service.RecalculateTotal(context.Background(), orderID.String(), false)
+	if err != nil {
+		t.Fatalf("RecalculateTotal: %v", err)
+	}
+	if !result.Discrepancy {
+		t.Error("drifted total not flagged as discrepancy")
+	}
+	if result.Corrected {
+		t.Error("total corrected although correct=false")
+	}
+	if result.StoredTotal != "59.99" || result.ComputedTotal != "59.97" {
+		t.Errorf("stored/computed = %s/%s, want 59.99/59.97", result.StoredTotal, result.ComputedTotal)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unexpected database writes: %v", err)
+	}
+}
+
+func TestRecalculateTotalCorrectsDriftInTransaction(t *testing.T) {
+	service, mock := newOrderTestService(t)
+	orderID := uuid.New()
+
+	expectRecalculateRead(mock, orderID)
+	mock.ExpectQuery("-- name: UpdateOrderTotal").WithArgs(orderID, "59.97").
+		WillReturnRows(orderRow(orderID, "ORD-0000000001"))
+	mock.ExpectCommit()
+
+	result, err := service.RecalculateTotal(context.Background(), orderID.String(), true)
+	if err != nil {
+		t.Fatalf("RecalculateTotal(correct): %v", err)
+	}
+	if !result.Discrepancy || !result.Corrected {
+		t.Errorf("result = %+v, want discrepancy detected and corrected", result)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("correction did not run inside the transaction: %v", err)
+	}
+}
+
+func TestRecalculateTotalLeavesConsistentOrderAlone(t *testing.T) {
+	service, mock := newOrderTestService(t)
+	orderID := uuid.New()
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery("-- name: GetOrder :one").WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows(orderColumns).AddRow(
+			orderID, uuid.New(), "39.98", "pending", now, now, "ORD-0000000002",
+		))
+	mock.ExpectQuery("-- name: GetOrderItems").WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows(itemColumns).
+			AddRow(uuid.New(), orderID, "p-1", "widget", int32(2), "19.99", now))
+	mock.ExpectCommit()
+
+	result, err := service.RecalculateTotal(context.Background(), orderID.String(), true)
+	if err != nil {
+		t.Fatalf("RecalculateTotal: %v", err)
+	}
+	if result.Discrepancy || result.Corrected {
+		t.Errorf("result = %+v, want consistent order untouched", result)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unexpected writes for a consistent order: %v", err)
+	}
+}
+
+func TestAddOrderItemRecomputesTotalInTransaction(t *testing.T) {
+	service, mock := newOrderTestService(t)
+	orderID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("-- name: GetOrderForUpdate").WithArgs(orderID).
+		WillReturnRows(orderRow(orderID, "ORD-0000000001"))
+	mock.ExpectQuery("-- name: CreateOrderItem").
+		WithArgs(orderID, "p-2", "gadget", int32(1), "10.01").
+		WillReturnRows(sqlmock.NewRows(itemColumns).
+			AddRow(uuid.New(), orderID, "p-2", "gadget", int32(1), "10.01", now))
+	mock.ExpectQuery("-- name: GetOrderItems").WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows(itemColumns).
+			AddRow(uuid.New(), orderID, "p-1", "widget", int32(2), "19.99", now).
+			AddRow(uuid.New(), orderID, "p-2", "gadget", int32(1), "10.01", now))
+	mock.ExpectQuery("-- name: UpdateOrderTotal").WithArgs(orderID, "49.99").
+		WillReturnRows(orderRow(orderID, "ORD-0000000001"))
+	mock.ExpectCommit()
+
+	_, items, err := service.AddOrderItem(context.Background(), orderID.String(), OrderItemInput{
+		ProductID:   "p-2",
+		ProductName: "gadget",
+		Quantity:    1,
+		PriceStr:    "10.01",
+	})
+	if err != nil {
+		t.Fatalf("AddOrderItem: %v", err)
+	}
+	if len(items) != 2 {
+		t.Errorf("got %d items after add, want 2", len(items))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("total not recomputed inside the transaction: %v", err)
+	}
+}
+
+func TestRemoveOrderItemRecomputesTotal(t *testing.T) {
+	service, mock := newOrderTestService(t)
+	orderID := uuid.New()
+	itemID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("-- name: GetOrderForUpdate").WithArgs(orderID).
+		WillReturnRows(orderRow(orderID, "ORD-0000000001"))
+	mock.ExpectExec("-- name: DeleteOrderItem").WithArgs(itemID, orderID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("-- name: GetOrderItems").WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows(itemColumns).
+			AddRow(uuid.New(), orderID, "p-1", "widget", int32(2), "19.99", now))
+	mock.ExpectQuery("-- name: UpdateOrderTotal").WithArgs(orderID, "39.98").
+		WillReturnRows(orderRow(orderID, "ORD-0000000001"))
+	mock.ExpectCommit()
+
+	_, items, err := service.RemoveOrderItem(context.Background(), orderID.String(), itemID.String())
+	if err != nil {
+		t.Fatalf("RemoveOrderItem: %v", err)
+	}
+	if len(items) != 1 {
+		t.Errorf("got %d items after remove, want 1", len(items))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("total not recomputed after removal: %v", err)
+	}
+}
+
+func TestMutateOrderItemsRejectsNonPendingOrder(t *testing.T) {
+	service, mock := newOrderTestService(t)
+	orderID := uuid.New()
+	now := time.Now()
+
+	// 已发货的订单锁定后直接回滚，不触达明细表
+	mock.ExpectBegin()
+	mock.ExpectQuery("-- name: GetOrderForUpdate").WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows(orderColumns).AddRow(
+			orderID, uuid.New(), "42.50", "shipped", now, now, "ORD-0000000001",
+		))
+	mock.ExpectRollback()
+
+	_, _, err := service.AddOrderItem(context.Background(), orderID.String(), OrderItemInput{
+		ProductID:   "p-2",
+		ProductName: "gadget",
+		Quantity:    1,
+		PriceStr:    "10.01",
+	})
+	if !errors.Is(err, ErrOrderNotPending) {
+		t.Errorf("AddOrderItem on shipped order: %v, want ErrOrderNotPending", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("non-pending order was still mutated: %v", err)
+	}
+}
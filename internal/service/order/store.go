@@ -3,21 +3,40 @@ package order
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	orderdb "micro-holtye/internal/service/order/db"
 
 	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// defaultOrderBatchSize 是流式遍历订单时每批从数据库加载的行数
+const defaultOrderBatchSize = 100
+
+const (
+	// idempotencyLockPrefix 是幂等键 Redis 锁的键前缀
+	idempotencyLockPrefix = "order:idempotency_lock:"
+	// idempotencyLockTTL 限定锁的最长持有时间，防止进程崩溃后锁永久残留
+	idempotencyLockTTL = 10 * time.Second
 )
 
 type Store struct {
-	queries *orderdb.Queries
+	queries *instrumentedQuerier
 	db      *sql.DB
+	redis   *redis.Client
 }
 
 func NewStore(database *sql.DB) *Store {
+	return NewStoreWithRedis(database, nil)
+}
+
+// NewStoreWithRedis 创建带 Redis 客户端的 Store，Redis 用于幂等键的分布式锁
+func NewStoreWithRedis(database *sql.DB, redisClient *redis.Client) *Store {
 	return &Store{
-		queries: orderdb.New(database),
+		queries: newInstrumentedQuerier(orderdb.New(database)),
 		db:      database,
+		redis:   redisClient,
 	}
 }
 
@@ -33,10 +52,23 @@ func (s *Store) GetOrder(ctx context.Context, id any) (*orderdb.Order, error) {
 	return s.queries.GetOrder(ctx, orderID)
 }
 
+// GetOrderForUpdate 取订单并持有行锁（FOR UPDATE），用于事务内串行化订单修改
+func (s *Store) GetOrderForUpdate(ctx context.Context, id uuid.UUID) (*orderdb.Order, error) {
+	return s.queries.GetOrderForUpdate(ctx, id)
+}
+
+func (s *Store) GetOrderByNumber(ctx context.Context, orderNumber string) (*orderdb.Order, error) {
+	return s.queries.GetOrderByNumber(ctx, orderNumber)
+}
+
 func (s *Store) UpdateOrderStatus(ctx context.Context, params orderdb.UpdateOrderStatusParams) (*orderdb.Order, error) {
 	return s.queries.UpdateOrderStatus(ctx, params)
 }
 
+func (s *Store) UpdateOrderTotal(ctx context.Context, params orderdb.UpdateOrderTotalParams) (*orderdb.Order, error) {
+	return s.queries.UpdateOrderTotal(ctx, params)
+}
+
 func (s *Store) ListOrdersByUser(ctx context.Context, userID any, limit, offset int32) ([]*orderdb.Order, error) {
 	userUUID, ok := userID.(uuid.UUID)
 	if !ok {
@@ -49,6 +81,42 @@ func (s *Store) ListOrdersByUser(ctx context.Context, userID any, limit, offset
 	})
 }
 
+// ForEachOrderByUser 按批次分页遍历用户的全部订单并逐条调用 fn，
+// 避免一次性把所有订单加载进内存。fn 返回错误或上下文结束时提前停止；
+// batchSize <= 0 时使用 defaultOrderBatchSize。
+func (s *Store) ForEachOrderByUser(ctx context.Context, userID uuid.UUID, batchSize int32, fn func(*orderdb.Order) error) error {
+	if batchSize <= 0 {
+		batchSize = defaultOrderBatchSize
+	}
+
+	var offset int32
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		orders, err := s.queries.ListOrdersByUser(ctx, orderdb.ListOrdersByUserParams{
+			UserID: userID,
+			Limit:  batchSize,
+			Offset: offset,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, order := range orders {
+			if err := fn(order); err != nil {
+				return err
+			}
+		}
+
+		if int32(len(orders)) < batchSize {
+			return nil
+		}
+		offset += batchSize
+	}
+}
+
 func (s *Store) CancelOrder(ctx context.Context, id any) error {
 	orderID, ok := id.(uuid.UUID)
 	if !ok {
@@ -57,6 +125,11 @@ func (s *Store) CancelOrder(ctx context.Context, id any) error {
 	return s.queries.CancelOrder(ctx, orderID)
 }
 
+// DeleteOrderItem 删除订单条目，返回删除的行数（0 表示条目不存在或不属于该订单）
+func (s *Store) DeleteOrderItem(ctx context.Context, params orderdb.DeleteOrderItemParams) (int64, error) {
+	return s.queries.DeleteOrderItem(ctx, params)
+}
+
 func (s *Store) CreateOrderItem(ctx context.Context, params orderdb.CreateOrderItemParams) (*orderdb.OrderItem, error) {
 	return s.queries.CreateOrderItem(ctx, params)
 }
@@ -69,6 +142,45 @@ func (s *Store) GetOrderItems(ctx context.Context, orderID any) ([]*orderdb.Orde
 	return s.queries.GetOrderItems(ctx, orderUUID)
 }
 
+// CreateIdempotencyKey 记录幂等键到订单的映射
+func (s *Store) CreateIdempotencyKey(ctx context.Context, key string, orderID uuid.UUID) error {
+	return s.queries.CreateIdempotencyKey(ctx, orderdb.CreateIdempotencyKeyParams{
+		Key:     key,
+		OrderID: orderID,
+	})
+}
+
+// GetIdempotencyKey 查询幂等键对应的订单映射，不存在时返回 sql.ErrNoRows
+func (s *Store) GetIdempotencyKey(ctx context.Context, key string) (*orderdb.IdempotencyKey, error) {
+	return s.queries.GetIdempotencyKey(ctx, key)
+}
+
+// AcquireIdempotencyLock 尝试获取幂等键的 Redis 锁，用于串行化共享同一键的并发请求。
+// 返回释放函数和是否获取成功；未配置 Redis 时直接视为成功（退化为仅靠数据库主键兜底）。
+func (s *Store) AcquireIdempotencyLock(ctx context.Context, key string) (func(), bool, error) {
+	if s.redis == nil {
+		return func() {}, true, nil
+	}
+
+	lockKey := idempotencyLockPrefix + key
+	acquired, err := s.redis.SetNX(ctx, lockKey, "1", idempotencyLockTTL).Result()
+	if err != nil {
+		return func() {}, false, err
+	}
+	if !acquired {
+		return func() {}, false, nil
+	}
+	return func() {
+		// 使用独立上下文释放，避免请求上下文取消导致锁残留到 TTL 过期
+		_ = s.redis.Del(context.Background(), lockKey).Err()
+	}, true, nil
+}
+
+// DeleteExpiredIdempotencyKeys 删除创建时间早于 cutoff 的幂等键记录，返回删除行数
+func (s *Store) DeleteExpiredIdempotencyKeys(ctx context.Context, cutoff time.Time) (int64, error) {
+	return s.queries.DeleteExpiredIdempotencyKeys(ctx, cutoff)
+}
+
 func (s *Store) WithTx(ctx context.Context, fn func(*Store) error) error {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -78,6 +190,7 @@ func (s *Store) WithTx(ctx context.Context, fn func(*Store) error) error {
 	txStore := &Store{
 		queries: s.queries.WithTx(tx),
 		db:      s.db,
+		redis:   s.redis,
 	}
 
 	if err := fn(txStore); err != nil {
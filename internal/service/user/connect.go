@@ -2,10 +2,14 @@ package user
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"fmt"
 
 	userv1 "micro-holtye/gen/user/v1"
 	"micro-holtye/gen/user/v1/userv1connect"
+	"micro-holtye/internal/pkg/apierror"
 
 	"connectrpc.com/connect"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -30,7 +34,20 @@ func (h *ConnectHandler) CreateUser(
 
 	user, err := h.service.CreateUser(ctx, msg.Email, msg.Username, msg.FullName, msg.Password)
 	if err != nil {
-		return nil, connect.NewError(connect.CodeAlreadyExists, err)
+		// 输入校验违规聚合为结构化详情，客户端一次拿到全部字段的问题
+		var verr *ValidationError
+		if errors.As(err, &verr) {
+			return nil, apierror.NewWithViolations(connect.CodeInvalidArgument, err,
+				apierror.CodeInvalidArgument, verr.Violations)
+		}
+		if errors.Is(err, ErrEmailExists) {
+			return nil, apierror.NewWithField(connect.CodeAlreadyExists, err, apierror.CodeEmailExists, "email")
+		}
+		if errors.Is(err, ErrUsernameExists) {
+			return nil, apierror.NewWithField(connect.CodeAlreadyExists, err, apierror.CodeUsernameExists, "username")
+		}
+		// 数据库故障、哈希失败等其余错误一律按内部错误上报
+		return nil, connect.NewError(connect.CodeInternal, err)
 	}
 
 	return connect.NewResponse(&userv1.CreateUserResponse{
@@ -45,14 +62,127 @@ func (h *ConnectHandler) CreateUser(
 	}), nil
 }
 
+// Authenticate 认证并签发最小会话令牌；认证失败统一返回 CodeUnauthenticated
+func (h *ConnectHandler) Authenticate(
+	ctx context.Context,
+	req *connect.Request[userv1.AuthenticateRequest],
+) (*connect.Response[userv1.AuthenticateResponse], error) {
+	user, err := h.service.Authenticate(ctx, req.Msg.Identifier, req.Msg.Password)
+	if err != nil {
+		if errors.Is(err, ErrInvalidCredentials) {
+			return nil, connect.NewError(connect.CodeUnauthenticated, err)
+		}
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	token, err := newSessionToken()
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	return connect.NewResponse(&userv1.AuthenticateResponse{
+		User: &userv1.User{
+			Id:        user.ID.String(),
+			Email:     user.Email,
+			Username:  user.Username,
+			FullName:  user.FullName.String,
+			CreatedAt: timestamppb.New(user.CreatedAt),
+			UpdatedAt: timestamppb.New(user.UpdatedAt),
+		},
+		SessionToken: token,
+	}), nil
+}
+
+// newSessionToken 生成 256 位随机不透明令牌
+// 暂不做服务端持久化，仅作为最小会话凭据返回
+func newSessionToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate session token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// BatchGetUsers 批量获取用户，结果与请求 ID 一一对应
+func (h *ConnectHandler) BatchGetUsers(
+	ctx context.Context,
+	req *connect.Request[userv1.BatchGetUsersRequest],
+) (*connect.Response[userv1.BatchGetUsersResponse], error) {
+	results, err := h.service.BatchGetUsers(ctx, req.Msg.Ids)
+	if err != nil {
+		var verr *ValidationError
+		if errors.As(err, &verr) {
+			return nil, apierror.NewWithViolations(connect.CodeInvalidArgument, err,
+				apierror.CodeInvalidArgument, verr.Violations)
+		}
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	pbResults := make([]*userv1.BatchGetUsersResult, 0, len(results))
+	for _, result := range results {
+		pbResult := &userv1.BatchGetUsersResult{
+			Id:    result.ID,
+			Found: result.User != nil,
+		}
+		if result.User != nil {
+			pbResult.User = &userv1.User{
+				Id:        result.User.ID.String(),
+				Email:     result.User.Email,
+				Username:  result.User.Username,
+				FullName:  result.User.FullName.String,
+				CreatedAt: timestamppb.New(result.User.CreatedAt),
+				UpdatedAt: timestamppb.New(result.User.UpdatedAt),
+			}
+		}
+		pbResults = append(pbResults, pbResult)
+	}
+
+	return connect.NewResponse(&userv1.BatchGetUsersResponse{
+		Results: pbResults,
+	}), nil
+}
+
+// ChangePassword 校验旧密码后轮换密码
+func (h *ConnectHandler) ChangePassword(
+	ctx context.Context,
+	req *connect.Request[userv1.ChangePasswordRequest],
+) (*connect.Response[userv1.ChangePasswordResponse], error) {
+	msg := req.Msg
+
+	if err := h.service.ChangePassword(ctx, msg.Id, msg.OldPassword, msg.NewPassword); err != nil {
+		var verr *ValidationError
+		if errors.As(err, &verr) {
+			return nil, apierror.NewWithViolations(connect.CodeInvalidArgument, err,
+				apierror.CodeInvalidArgument, verr.Violations)
+		}
+		if errors.Is(err, ErrInvalidCredentials) {
+			return nil, connect.NewError(connect.CodeUnauthenticated, err)
+		}
+		if errors.Is(err, ErrNotFound) {
+			return nil, apierror.New(connect.CodeNotFound, ErrNotFound, apierror.CodeUserNotFound)
+		}
+		if errors.Is(err, ErrInvalidID) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	return connect.NewResponse(&userv1.ChangePasswordResponse{
+		Success: true,
+	}), nil
+}
+
 func (h *ConnectHandler) GetUser(
 	ctx context.Context,
 	req *connect.Request[userv1.GetUserRequest],
 ) (*connect.Response[userv1.GetUserResponse], error) {
 	user, err := h.service.GetUser(ctx, req.Msg.Id)
 	if err != nil {
-		if err.Error() == "user not found" {
-			return nil, connect.NewError(connect.CodeNotFound, errors.New("user not found"))
+		if errors.Is(err, ErrNotFound) {
+			return nil, apierror.New(connect.CodeNotFound, ErrNotFound, apierror.CodeUserNotFound)
+		}
+		if errors.Is(err, ErrInvalidID) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
 		}
 		return nil, connect.NewError(connect.CodeInternal, err)
 	}
@@ -69,6 +199,33 @@ func (h *ConnectHandler) GetUser(
 	}), nil
 }
 
+func (h *ConnectHandler) GetUserByEmail(
+	ctx context.Context,
+	req *connect.Request[userv1.GetUserByEmailRequest],
+) (*connect.Response[userv1.GetUserByEmailResponse], error) {
+	user, err := h.service.GetUserByEmail(ctx, req.Msg.Email)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, apierror.New(connect.CodeNotFound, ErrNotFound, apierror.CodeUserNotFound)
+		}
+		if errors.Is(err, ErrInvalidID) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	return connect.NewResponse(&userv1.GetUserByEmailResponse{
+		User: &userv1.User{
+			Id:        user.ID.String(),
+			Email:     user.Email,
+			Username:  user.Username,
+			FullName:  user.FullName.String,
+			CreatedAt: timestamppb.New(user.CreatedAt),
+			UpdatedAt: timestamppb.New(user.UpdatedAt),
+		},
+	}), nil
+}
+
 func (h *ConnectHandler) UpdateUser(
 	ctx context.Context,
 	req *connect.Request[userv1.UpdateUserRequest],
@@ -116,6 +273,33 @@ func (h *ConnectHandler) DeleteUser(
 	}), nil
 }
 
+func (h *ConnectHandler) RestoreUser(
+	ctx context.Context,
+	req *connect.Request[userv1.RestoreUserRequest],
+) (*connect.Response[userv1.RestoreUserResponse], error) {
+	user, err := h.service.RestoreUser(ctx, req.Msg.Id)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, apierror.New(connect.CodeNotFound, ErrNotFound, apierror.CodeUserNotFound)
+		}
+		if errors.Is(err, ErrInvalidID) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	return connect.NewResponse(&userv1.RestoreUserResponse{
+		User: &userv1.User{
+			Id:        user.ID.String(),
+			Email:     user.Email,
+			Username:  user.Username,
+			FullName:  user.FullName.String,
+			CreatedAt: timestamppb.New(user.CreatedAt),
+			UpdatedAt: timestamppb.New(user.UpdatedAt),
+		},
+	}), nil
+}
+
 func (h *ConnectHandler) ListUsers(
 	ctx context.Context,
 	req *connect.Request[userv1.ListUsersRequest],
@@ -125,8 +309,28 @@ func (h *ConnectHandler) ListUsers(
 		pageSize = 10
 	}
 
-	users, err := h.service.ListUsers(ctx, pageSize, 0)
+	filter := ListUsersFilter{
+		EmailContains:  req.Msg.EmailContains,
+		UsernamePrefix: req.Msg.UsernamePrefix,
+		SortOrder:      sortOrderFromProto(req.Msg.SortOrder),
+		IncludeDeleted: req.Msg.IncludeDeleted,
+	}
+	if req.Msg.CreatedAfter != nil {
+		createdAfter := req.Msg.CreatedAfter.AsTime()
+		filter.CreatedAfter = &createdAfter
+	}
+	if req.Msg.CreatedBefore != nil {
+		createdBefore := req.Msg.CreatedBefore.AsTime()
+		filter.CreatedBefore = &createdBefore
+	}
+
+	users, total, err := h.service.ListUsers(ctx, pageSize, 0, filter)
 	if err != nil {
+		var verr *ValidationError
+		if errors.As(err, &verr) {
+			return nil, apierror.NewWithViolations(connect.CodeInvalidArgument, err,
+				apierror.CodeInvalidArgument, verr.Violations)
+		}
 		return nil, connect.NewError(connect.CodeInternal, err)
 	}
 
@@ -143,6 +347,57 @@ func (h *ConnectHandler) ListUsers(
 	}
 
 	return connect.NewResponse(&userv1.ListUsersResponse{
-		Users: pbUsers,
+		Users:      pbUsers,
+		TotalCount: total,
+	}), nil
+}
+
+// sortOrderFromProto 将 RPC 排序枚举转换为服务层排序键
+func sortOrderFromProto(order userv1.UserSortOrder) string {
+	switch order {
+	case userv1.UserSortOrder_USER_SORT_ORDER_CREATED_AT_ASC:
+		return SortCreatedAtAsc
+	case userv1.UserSortOrder_USER_SORT_ORDER_USERNAME_ASC:
+		return SortUsernameAsc
+	case userv1.UserSortOrder_USER_SORT_ORDER_USERNAME_DESC:
+		return SortUsernameDesc
+	default:
+		return SortCreatedAtDesc
+	}
+}
+
+func (h *ConnectHandler) ImportUsers(
+	ctx context.Context,
+	stream *connect.ClientStream[userv1.ImportUsersRequest],
+) (*connect.Response[userv1.ImportUsersResponse], error) {
+	var imported, failed int32
+	var failures []*userv1.ImportFailure
+	index := int32(0)
+
+	for stream.Receive() {
+		msg := stream.Msg()
+
+		_, err := h.service.CreateUser(ctx, msg.Email, msg.Username, msg.FullName, msg.Password)
+		if err != nil {
+			failed++
+			failures = append(failures, &userv1.ImportFailure{
+				Index:  index,
+				Email:  msg.Email,
+				Reason: err.Error(),
+			})
+		} else {
+			imported++
+		}
+		index++
+	}
+
+	if err := stream.Err(); err != nil {
+		return nil, connect.NewError(connect.CodeUnknown, err)
+	}
+
+	return connect.NewResponse(&userv1.ImportUsersResponse{
+		ImportedCount: imported,
+		FailedCount:   failed,
+		Failures:      failures,
 	}), nil
 }
@@ -0,0 +1,247 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	commonv1 "micro-holtye/gen/common/v1"
+	userv1 "micro-holtye/gen/user/v1"
+	"micro-holtye/gen/user/v1/userv1connect"
+	"micro-holtye/internal/pkg/apierror"
+	"micro-holtye/internal/pkg/logger"
+
+	"connectrpc.com/connect"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+// userColumns 与 users 表查询返回的列保持一致
+var userColumns = []string{
+	"id", "email", "username", "full_name", "password_hash", "created_at", "updated_at", "deleted_at",
+}
+
+// userRow 构造一行 users 查询结果，供 sqlmock 返回
+func userRow(email, username string) *sqlmock.Rows {
+	now := time.Now()
+	return sqlmock.NewRows(userColumns).AddRow(
+		uuid.New(), email, username, sql.NullString{}, "hash", now, now, sql.NullTime{},
+	)
+}
+
+// newUserTestServer 用 sqlmock 支撑的完整服务栈启动测试服务器，
+// 返回 Connect 客户端和 sqlmock 句柄用于设置期望
+func newUserTestServer(t *testing.T) (userv1connect.UserServiceClient, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	store := NewStore(db)
+	service := NewService(store, logger.NewMemoryLogger())
+	handler := NewConnectHandler(service)
+
+	mux := http.NewServeMux()
+	mux.Handle(userv1connect.NewUserServiceHandler(handler))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client := userv1connect.NewUserServiceClient(server.Client(), server.URL)
+	return client, mock
+}
+
+// expectCreateUserSuccess 设置一次成功创建用户的事务期望
+func expectCreateUserSuccess(mock sqlmock.Sqlmock, email, username string) {
+	mock.ExpectBegin()
+	mock.ExpectQuery("-- name: GetUserByEmail").WithArgs(email).WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("-- name: GetUserByUsername").WithArgs(username).WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("-- name: CreateUser").WillReturnRows(userRow(email, username))
+	mock.ExpectCommit()
+}
+
+// expectCreateUserEmailExists 设置一次因邮箱已存在而回滚的事务期望
+func expectCreateUserEmailExists(mock sqlmock.Sqlmock, email string) {
+	mock.ExpectBegin()
+	mock.ExpectQuery("-- name: GetUserByEmail").WithArgs(email).WillReturnRows(userRow(email, "taken"))
+	mock.ExpectRollback()
+}
+
+// errorDetails 解出 Connect 错误携带的全部结构化详情
+func errorDetails(t *testing.T, err error) []*commonv1.ErrorDetail {
+	t.Helper()
+
+	var connectErr *connect.Error
+	if !errors.As(err, &connectErr) {
+		t.Fatalf("error is not a *connect.Error: %v", err)
+	}
+	var details []*commonv1.ErrorDetail
+	for _, d := range connectErr.Details() {
+		msg, valueErr := d.Value()
+		if valueErr != nil {
+			continue
+		}
+		if detail, ok := msg.(*commonv1.ErrorDetail); ok {
+			details = append(details, detail)
+		}
+	}
+	return details
+}
+
+func TestCreateUserConflictCarriesEmailExistsDetail(t *testing.T) {
+	client, mock := newUserTestServer(t)
+	expectCreateUserEmailExists(mock, "alice@example.com")
+
+	_, err := client.CreateUser(context.Background(), connect.NewRequest(&userv1.CreateUserRequest{
+		Email:    "alice@example.com",
+		Username: "alice",
+		Password: "password123",
+	}))
+	if err == nil {
+		t.Fatal("CreateUser succeeded, want AlreadyExists")
+	}
+	if code := connect.CodeOf(err); code != connect.CodeAlreadyExists {
+		t.Fatalf("CodeOf(err) = %v, want %v", code, connect.CodeAlreadyExists)
+	}
+
+	details := errorDetails(t, err)
+	if len(details) != 1 {
+		t.Fatalf("len(details) = %d, want 1", len(details))
+	}
+	if details[0].Code != apierror.CodeEmailExists {
+		t.Errorf("detail code = %q, want %q", details[0].Code, apierror.CodeEmailExists)
+	}
+	if details[0].Field != "email" {
+		t.Errorf("detail field = %q, want %q", details[0].Field, "email")
+	}
+	if details[0].Retryable {
+		t.Error("detail retryable = true, want false")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet sqlmock expectations: %v", err)
+	}
+}
+
+func TestImportUsersCountsDuplicate(t *testing.T) {
+	client, mock := newUserTestServer(t)
+
+	expectCreateUserSuccess(mock, "alice@example.com", "alice")
+	expectCreateUserEmailExists(mock, "bob@example.com")
+	expectCreateUserSuccess(mock, "carol@example.com", "carol")
+
+	rows := []*userv1.ImportUsersRequest{
+		{Email: "alice@example.com", Username: "alice", Password: "password123"},
+		{Email: "bob@example.com", Username: "bob_2", Password: "password123"},
+		{Email: "carol@example.com", Username: "carol", Password: "password123"},
+	}
+
+	stream := client.ImportUsers(context.Background())
+	for _, row := range rows {
+		if err := stream.Send(row); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+	resp, err := stream.CloseAndReceive()
+	if err != nil {
+		t.Fatalf("CloseAndReceive: %v", err)
+	}
+
+	if resp.Msg.ImportedCount != 2 {
+		t.Errorf("ImportedCount = %d, want 2", resp.Msg.ImportedCount)
+	}
+	if resp.Msg.FailedCount != 1 {
+		t.Errorf("FailedCount = %d, want 1", resp.Msg.FailedCount)
+	}
+	if len(resp.Msg.Failures) != 1 {
+		t.Fatalf("len(Failures) = %d, want 1", len(resp.Msg.Failures))
+	}
+	failure := resp.Msg.Failures[0]
+	if failure.Index != 1 || failure.Email != "bob@example.com" {
+		t.Errorf("Failures[0] = {Index: %d, Email: %q}, want {Index: 1, Email: %q}",
+			failure.Index, failure.Email, "bob@example.com")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet sqlmock expectations: %v", err)
+	}
+}
+
+func TestWrappedSentinelErrorsStillMatch(t *testing.T) {
+	// 经过 fmt.Errorf 包装的哨兵错误仍可被 errors.Is 识别
+	wrapped := fmt.Errorf("loading profile: %w", ErrNotFound)
+	if !errors.Is(wrapped, ErrNotFound) {
+		t.Error("wrapped ErrNotFound no longer matches")
+	}
+	wrapped = fmt.Errorf("signup: %w", ErrEmailExists)
+	if !errors.Is(wrapped, ErrEmailExists) {
+		t.Error("wrapped ErrEmailExists no longer matches")
+	}
+}
+
+func TestGetUserMapsMissingRowToCodeNotFound(t *testing.T) {
+	client, mock := newUserTestServer(t)
+
+	mock.ExpectQuery("-- name: GetUser :one").WillReturnError(sql.ErrNoRows)
+
+	_, err := client.GetUser(context.Background(),
+		connect.NewRequest(&userv1.GetUserRequest{Id: uuid.NewString()}))
+	if err == nil {
+		t.Fatal("missing user returned success, want CodeNotFound")
+	}
+	if code := connect.CodeOf(err); code != connect.CodeNotFound {
+		t.Errorf("CodeOf(err) = %v, want %v", code, connect.CodeNotFound)
+	}
+}
+
+func TestCreateUserMapsErrorKindsToConnectCodes(t *testing.T) {
+	validReq := func() *userv1.CreateUserRequest {
+		return &userv1.CreateUserRequest{
+			Email:    "a@b.com",
+			Username: "alice_01",
+			Password: "longenough",
+		}
+	}
+
+	t.Run("validation error maps to InvalidArgument", func(t *testing.T) {
+		client, _ := newUserTestServer(t)
+
+		req := validReq()
+		req.Email = "not-an-email"
+		_, err := client.CreateUser(context.Background(), connect.NewRequest(req))
+		if code := connect.CodeOf(err); code != connect.CodeInvalidArgument {
+			t.Errorf("CodeOf(err) = %v, want %v", code, connect.CodeInvalidArgument)
+		}
+	})
+
+	t.Run("duplicate email maps to AlreadyExists", func(t *testing.T) {
+		client, mock := newUserTestServer(t)
+		expectCreateUserEmailExists(mock, "a@b.com")
+
+		_, err := client.CreateUser(context.Background(), connect.NewRequest(validReq()))
+		if code := connect.CodeOf(err); code != connect.CodeAlreadyExists {
+			t.Errorf("CodeOf(err) = %v, want %v", code, connect.CodeAlreadyExists)
+		}
+	})
+
+	t.Run("database outage maps to Internal", func(t *testing.T) {
+		client, mock := newUserTestServer(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery("-- name: GetUserByEmail").WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery("-- name: GetUserByUsername").WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery("-- name: CreateUser").WillReturnError(errors.New("connection refused"))
+		mock.ExpectRollback()
+
+		_, err := client.CreateUser(context.Background(), connect.NewRequest(validReq()))
+		if code := connect.CodeOf(err); code != connect.CodeInternal {
+			t.Errorf("CodeOf(err) = %v, want %v", code, connect.CodeInternal)
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"micro-holtye/internal/pkg/metrics"
+	userdb "micro-holtye/internal/service/user/db"
+
+	"github.com/google/uuid"
+)
+
+// instrumentedQuerier 包装 userdb.Querier，按查询名和结果计数
+type instrumentedQuerier struct {
+	q userdb.Querier
+}
+
+// 确保 instrumentedQuerier 实现 userdb.Querier 接口
+var _ userdb.Querier = (*instrumentedQuerier)(nil)
+
+func newInstrumentedQuerier(q userdb.Querier) *instrumentedQuerier {
+	return &instrumentedQuerier{q: q}
+}
+
+// countQuery 记录一次数据库查询的结果计数
+// sql.ErrNoRows 单独归为 not_found，避免污染错误率
+func countQuery(query string, err error) {
+	outcome := "ok"
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		outcome = "not_found"
+	case err != nil:
+		outcome = "error"
+	}
+
+	metrics.Default().Counter("db_queries_total", map[string]string{
+		"service": "user",
+		"query":   query,
+		"outcome": outcome,
+	}).Inc()
+}
+
+func (i *instrumentedQuerier) CountUsersFiltered(ctx context.Context, arg userdb.CountUsersFilteredParams) (int64, error) {
+	count, err := i.q.CountUsersFiltered(ctx, arg)
+	countQuery("CountUsersFiltered", err)
+	return count, err
+}
+
+func (i *instrumentedQuerier) CreateUser(ctx context.Context, arg userdb.CreateUserParams) (*userdb.User, error) {
+	user, err := i.q.CreateUser(ctx, arg)
+	countQuery("CreateUser", err)
+	return user, err
+}
+
+func (i *instrumentedQuerier) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	err := i.q.DeleteUser(ctx, id)
+	countQuery("DeleteUser", err)
+	return err
+}
+
+func (i *instrumentedQuerier) GetUser(ctx context.Context, id uuid.UUID) (*userdb.User, error) {
+	user, err := i.q.GetUser(ctx, id)
+	countQuery("GetUser", err)
+	return user, err
+}
+
+func (i *instrumentedQuerier) GetUserByEmail(ctx context.Context, email string) (*userdb.User, error) {
+	user, err := i.q.GetUserByEmail(ctx, email)
+	countQuery("GetUserByEmail", err)
+	return user, err
+}
+
+func (i *instrumentedQuerier) GetUserByUsername(ctx context.Context, username string) (*userdb.User, error) {
+	user, err := i.q.GetUserByUsername(ctx, username)
+	countQuery("GetUserByUsername", err)
+	return user, err
+}
+
+func (i *instrumentedQuerier) GetUsersByIDs(ctx context.Context, ids []uuid.UUID) ([]*userdb.User, error) {
+	users, err := i.q.GetUsersByIDs(ctx, ids)
+	countQuery("GetUsersByIDs", err)
+	return users, err
+}
+
+func (i *instrumentedQuerier) ListUsers(ctx context.Context, arg userdb.ListUsersParams) ([]*userdb.User, error) {
+	users, err := i.q.ListUsers(ctx, arg)
+	countQuery("ListUsers", err)
+	return users, err
+}
+
+func (i *instrumentedQuerier) ListUsersFiltered(ctx context.Context, arg userdb.ListUsersFilteredParams) ([]*userdb.User, error) {
+	users, err := i.q.ListUsersFiltered(ctx, arg)
+	countQuery("ListUsersFiltered", err)
+	return users, err
+}
+
+func (i *instrumentedQuerier) ListUsersIncludeDeleted(ctx context.Context, arg userdb.ListUsersIncludeDeletedParams) ([]*userdb.User, error) {
+	users, err := i.q.ListUsersIncludeDeleted(ctx, arg)
+	countQuery("ListUsersIncludeDeleted", err)
+	return users, err
+}
+
+func (i *instrumentedQuerier) RestoreUser(ctx context.Context, id uuid.UUID) (*userdb.User, error) {
+	user, err := i.q.RestoreUser(ctx, id)
+	countQuery("RestoreUser", err)
+	return user, err
+}
+
+func (i *instrumentedQuerier) UpdateUser(ctx context.Context, arg userdb.UpdateUserParams) (*userdb.User, error) {
+	user, err := i.q.UpdateUser(ctx, arg)
+	countQuery("UpdateUser", err)
+	return user, err
+}
+
+func (i *instrumentedQuerier) UpdateUserPassword(ctx context.Context, arg userdb.UpdateUserPasswordParams) error {
+	err := i.q.UpdateUserPassword(ctx, arg)
+	countQuery("UpdateUserPassword", err)
+	return err
+}
@@ -0,0 +1,60 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"micro-holtye/internal/pkg/metrics"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// queryCounter 读取指定查询与结果的全局计数器当前值
+func queryCounter(query, outcome string) uint64 {
+	return metrics.Default().Counter("db_queries_total", map[string]string{
+		"service": "user",
+		"query":   query,
+		"outcome": outcome,
+	}).Value()
+}
+
+func TestInstrumentedQuerierCountsQueries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	store := NewStore(db)
+	ctx := context.Background()
+
+	okBefore := queryCounter("GetUserByEmail", "ok")
+	notFoundBefore := queryCounter("GetUserByEmail", "not_found")
+	errorBefore := queryCounter("GetUserByEmail", "error")
+
+	mock.ExpectQuery("-- name: GetUserByEmail").WillReturnRows(userRow("a@b.c", "alice"))
+	mock.ExpectQuery("-- name: GetUserByEmail").WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("-- name: GetUserByEmail").WillReturnError(sql.ErrConnDone)
+
+	if _, err := store.GetUserByEmail(ctx, "a@b.c"); err != nil {
+		t.Fatalf("GetUserByEmail: %v", err)
+	}
+	if _, err := store.GetUserByEmail(ctx, "a@b.c"); err == nil {
+		t.Fatal("GetUserByEmail succeeded, want ErrNoRows")
+	}
+	if _, err := store.GetUserByEmail(ctx, "a@b.c"); err == nil {
+		t.Fatal("GetUserByEmail succeeded, want connection error")
+	}
+
+	if got := queryCounter("GetUserByEmail", "ok") - okBefore; got != 1 {
+		t.Errorf("ok counter moved by %d, want 1", got)
+	}
+	// sql.ErrNoRows 单独归为 not_found，不污染错误率
+	if got := queryCounter("GetUserByEmail", "not_found") - notFoundBefore; got != 1 {
+		t.Errorf("not_found counter moved by %d, want 1", got)
+	}
+	if got := queryCounter("GetUserByEmail", "error") - errorBefore; got != 1 {
+		t.Errorf("error counter moved by %d, want 1", got)
+	}
+}
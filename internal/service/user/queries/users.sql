@@ -7,15 +7,15 @@ INSERT INTO users (
 
 -- name: GetUser :one
 SELECT * FROM users
-WHERE id = $1 LIMIT 1;
+WHERE id = $1 AND deleted_at IS NULL LIMIT 1;
 
 -- name: GetUserByEmail :one
 SELECT * FROM users
-WHERE email = $1 LIMIT 1;
+WHERE email = $1 AND deleted_at IS NULL LIMIT 1;
 
 -- name: GetUserByUsername :one
 SELECT * FROM users
-WHERE username = $1 LIMIT 1;
+WHERE username = $1 AND deleted_at IS NULL LIMIT 1;
 
 -- name: UpdateUser :one
 UPDATE users
@@ -28,10 +28,59 @@ WHERE id = $1
 RETURNING *;
 
 -- name: DeleteUser :exec
-DELETE FROM users
-WHERE id = $1;
+UPDATE users
+SET
+    deleted_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: RestoreUser :one
+UPDATE users
+SET
+    deleted_at = NULL,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: GetUsersByIDs :many
+SELECT * FROM users
+WHERE id = ANY(@ids::uuid[]) AND deleted_at IS NULL;
 
 -- name: ListUsers :many
 SELECT * FROM users
+WHERE deleted_at IS NULL
+ORDER BY created_at DESC
+LIMIT $1 OFFSET $2;
+
+-- name: ListUsersFiltered :many
+SELECT * FROM users
+WHERE (@include_deleted::boolean OR deleted_at IS NULL)
+  AND (@email_contains::text = '' OR email ILIKE '%' || @email_contains || '%')
+  AND (@username_prefix::text = '' OR username LIKE @username_prefix || '%')
+  AND (sqlc.narg('created_after')::timestamptz IS NULL OR created_at >= sqlc.narg('created_after'))
+  AND (sqlc.narg('created_before')::timestamptz IS NULL OR created_at <= sqlc.narg('created_before'))
+ORDER BY
+  CASE WHEN @sort_order::text = 'created_at_asc' THEN created_at END ASC,
+  CASE WHEN @sort_order::text = 'username_asc' THEN username END ASC,
+  CASE WHEN @sort_order::text = 'username_desc' THEN username END DESC,
+  created_at DESC
+LIMIT @page_limit OFFSET @page_offset;
+
+-- name: CountUsersFiltered :one
+SELECT COUNT(*) FROM users
+WHERE (@include_deleted::boolean OR deleted_at IS NULL)
+  AND (@email_contains::text = '' OR email ILIKE '%' || @email_contains || '%')
+  AND (@username_prefix::text = '' OR username LIKE @username_prefix || '%')
+  AND (sqlc.narg('created_after')::timestamptz IS NULL OR created_at >= sqlc.narg('created_after'))
+  AND (sqlc.narg('created_before')::timestamptz IS NULL OR created_at <= sqlc.narg('created_before'));
+
+-- name: ListUsersIncludeDeleted :many
+SELECT * FROM users
 ORDER BY created_at DESC
-LIMIT $1 OFFSET $2;
\ No newline at end of file
+LIMIT $1 OFFSET $2;
+-- name: UpdateUserPassword :exec
+UPDATE users
+SET
+    password_hash = $2,
+    updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL;
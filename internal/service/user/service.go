@@ -5,18 +5,74 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"regexp"
+	"strings"
+	"time"
 
-	userdb "micro-holtye/internal/service/user/db"
 	"micro-holtye/internal/pkg/logger"
+	userdb "micro-holtye/internal/service/user/db"
+
+	"micro-holtye/internal/pkg/apierror"
+	"micro-holtye/internal/pkg/audit"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// ErrNotFound 表示用户不存在，处理器用 errors.Is 映射为 CodeNotFound
+var ErrNotFound = errors.New("user not found")
+
+// ErrInvalidID 表示传入的用户 ID 不是合法的 UUID
+var ErrInvalidID = errors.New("invalid ID")
+
+// ErrEmailExists 表示邮箱已被占用
+var ErrEmailExists = errors.New("user with this email already exists")
+
+// ErrUsernameExists 表示用户名已被占用
+var ErrUsernameExists = errors.New("user with this username already exists")
+
+// ErrInvalidCredentials 表示认证失败；不区分账号不存在与密码错误，避免泄露账号存在性
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// dummyPasswordHash 用于账号不存在时的空比较，保持认证耗时恒定
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password-for-constant-time"), bcrypt.DefaultCost)
+
+// 用户输入校验的默认规则，UserValidationConfig 零值字段回退到这些值
+const (
+	defaultMinUsernameLen = 3
+	defaultMaxUsernameLen = 32
+	defaultMinPasswordLen = 8
+)
+
+// emailPattern 宽松的 RFC 风格邮箱格式：非空本地部分 @ 非空域名（含点）
+var emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+
+// usernamePattern 用户名仅允许字母、数字和下划线
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+
+// UserValidationConfig 用户输入校验规则，零值字段使用默认值
+type UserValidationConfig struct {
+	MinUsernameLen int
+	MaxUsernameLen int
+	MinPasswordLen int
+}
+
+// ValidationError 聚合 CreateUser 的全部校验违规，一次性返回给调用方
+type ValidationError struct {
+	Violations []apierror.Violation
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("user validation failed with %d violation(s)", len(e.Violations))
+}
+
 type Service struct {
 	store  *Store
 	logger logger.Logger
+	// validation 输入校验规则，零值字段在校验时回退到默认值
+	validation UserValidationConfig
 }
 
 func NewService(store *Store, logger logger.Logger) *Service {
@@ -26,7 +82,70 @@ func NewService(store *Store, logger logger.Logger) *Service {
 	}
 }
 
+// SetValidationConfig 配置 CreateUser 的输入校验规则（server.user_validation）
+func (s *Service) SetValidationConfig(config UserValidationConfig) {
+	s.validation = config
+}
+
+// validateCreateUser 收集全部输入校验违规，不在首个问题处提前返回
+func (s *Service) validateCreateUser(email, username, password string) *ValidationError {
+	minUsername := s.validation.MinUsernameLen
+	if minUsername <= 0 {
+		minUsername = defaultMinUsernameLen
+	}
+	maxUsername := s.validation.MaxUsernameLen
+	if maxUsername <= 0 {
+		maxUsername = defaultMaxUsernameLen
+	}
+	minPassword := s.validation.MinPasswordLen
+	if minPassword <= 0 {
+		minPassword = defaultMinPasswordLen
+	}
+
+	var violations []apierror.Violation
+
+	if !emailPattern.MatchString(email) {
+		violations = append(violations, apierror.Violation{
+			Field:       "email",
+			Description: "must be a valid email address",
+		})
+	}
+
+	if len(username) < minUsername || len(username) > maxUsername {
+		violations = append(violations, apierror.Violation{
+			Field:       "username",
+			Description: fmt.Sprintf("length must be between %d and %d characters", minUsername, maxUsername),
+		})
+	} else if !usernamePattern.MatchString(username) {
+		violations = append(violations, apierror.Violation{
+			Field:       "username",
+			Description: "may only contain letters, digits and underscores",
+		})
+	}
+
+	if len(password) < minPassword {
+		violations = append(violations, apierror.Violation{
+			Field:       "password",
+			Description: fmt.Sprintf("must be at least %d characters", minPassword),
+		})
+	}
+
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+	return nil
+}
+
 func (s *Service) CreateUser(ctx context.Context, email, username, fullName, password string) (*userdb.User, error) {
+	if verr := s.validateCreateUser(email, username, password); verr != nil {
+		s.logger.WarnContext(ctx, "CreateUser rejected by input validation",
+			zap.String("email", email),
+			zap.String("username", username),
+			logger.ErrorCode("INVALID_ARGUMENT"),
+		)
+		return nil, verr
+	}
+
 	s.logger.InfoContext(ctx, "CreateUser request started",
 		zap.String("email", email),
 		zap.String("username", username),
@@ -34,24 +153,6 @@ func (s *Service) CreateUser(ctx context.Context, email, username, fullName, pas
 		logger.Component("user-service"),
 	)
 
-	existingUser, _ := s.store.GetUserByEmail(ctx, email)
-	if existingUser != nil {
-		s.logger.WarnContext(ctx, "User creation failed: email already exists",
-			zap.String("email", email),
-			logger.ErrorCode("EMAIL_EXISTS"),
-		)
-		return nil, errors.New("user with this email already exists")
-	}
-
-	existingUser, _ = s.store.GetUserByUsername(ctx, username)
-	if existingUser != nil {
-		s.logger.WarnContext(ctx, "User creation failed: username already exists",
-			zap.String("username", username),
-			logger.ErrorCode("USERNAME_EXISTS"),
-		)
-		return nil, errors.New("user with this username already exists")
-	}
-
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		s.logger.ErrorContext(ctx, "Failed to hash password",
@@ -61,20 +162,57 @@ func (s *Service) CreateUser(ctx context.Context, email, username, fullName, pas
 		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
 
-	user, err := s.store.CreateUser(ctx, userdb.CreateUserParams{
-		Email:        email,
-		Username:     username,
-		FullName:     sql.NullString{String: fullName, Valid: fullName != ""},
-		PasswordHash: string(hashedPassword),
+	// 存在性检查和插入放进同一事务；并发注册同一邮箱时
+	// 预检查可能同时通过，唯一约束冲突是最终防线
+	var user *userdb.User
+	err = s.store.WithTx(ctx, func(txStore *Store) error {
+		existingUser, _ := txStore.GetUserByEmail(ctx, email)
+		if existingUser != nil {
+			s.logger.WarnContext(ctx, "User creation failed: email already exists",
+				zap.String("email", email),
+				logger.ErrorCode("EMAIL_EXISTS"),
+			)
+			return ErrEmailExists
+		}
+
+		existingUser, _ = txStore.GetUserByUsername(ctx, username)
+		if existingUser != nil {
+			s.logger.WarnContext(ctx, "User creation failed: username already exists",
+				zap.String("username", username),
+				logger.ErrorCode("USERNAME_EXISTS"),
+			)
+			return ErrUsernameExists
+		}
+
+		created, err := txStore.CreateUser(ctx, userdb.CreateUserParams{
+			Email:        email,
+			Username:     username,
+			FullName:     sql.NullString{String: fullName, Valid: fullName != ""},
+			PasswordHash: string(hashedPassword),
+		})
+		if err != nil {
+			if dupErr := uniqueViolationError(err); dupErr != nil {
+				s.logger.WarnContext(ctx, "User creation lost a concurrent race",
+					zap.String("email", email),
+					zap.String("username", username),
+					logger.ErrorCode("UNIQUE_VIOLATION"),
+				)
+				return dupErr
+			}
+			s.logger.ErrorContext(ctx, "Failed to create user in database",
+				zap.String("email", email),
+				zap.String("username", username),
+				logger.ErrorCode("DB_CREATE_FAILURE"),
+				zap.Error(err),
+			)
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+
+		user = created
+		return nil
 	})
 	if err != nil {
-		s.logger.ErrorContext(ctx, "Failed to create user in database",
-			zap.String("email", email),
-			zap.String("username", username),
-			logger.ErrorCode("DB_CREATE_FAILURE"),
-			zap.Error(err),
-		)
-		return nil, fmt.Errorf("failed to create user: %w", err)
+		return nil, err
 	}
 
 	s.logger.InfoContext(ctx, "User created successfully",
@@ -82,25 +220,206 @@ func (s *Service) CreateUser(ctx context.Context, email, username, fullName, pas
 		zap.String("username", user.Username),
 		logger.StatusCode(201),
 	)
+	audit.Record(ctx, audit.Event{
+		Action: audit.ActionUserCreated,
+		Target: user.ID.String(),
+	})
 
 	return user, nil
 }
 
+// Authenticate 按邮箱或用户名加载用户并校验密码。
+// 账号不存在时仍执行一次 bcrypt 比较，避免通过响应耗时探测账号存在性；
+// 所有失败均返回 ErrInvalidCredentials，不泄露具体原因。
+func (s *Service) Authenticate(ctx context.Context, identifier, password string) (*userdb.User, error) {
+	var user *userdb.User
+	if strings.Contains(identifier, "@") {
+		user, _ = s.store.GetUserByEmail(ctx, identifier)
+	} else {
+		user, _ = s.store.GetUserByUsername(ctx, identifier)
+	}
+
+	if user == nil {
+		_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		s.logger.WarnContext(ctx, "Authentication failed",
+			logger.ErrorCode("INVALID_CREDENTIALS"),
+		)
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		s.logger.WarnContext(ctx, "Authentication failed",
+			logger.UserID(user.ID.String()),
+			logger.ErrorCode("INVALID_CREDENTIALS"),
+		)
+		return nil, ErrInvalidCredentials
+	}
+
+	s.logger.InfoContext(ctx, "User authenticated",
+		logger.UserID(user.ID.String()),
+		zap.String("username", user.Username),
+	)
+	return user, nil
+}
+
+// maxBatchGetUsers 单次批量查询允许的最大 ID 数
+const maxBatchGetUsers = 100
+
+// BatchGetUsersResult 批量查询中单个 ID 的结果，未找到时 User 为 nil
+type BatchGetUsersResult struct {
+	ID   string
+	User *userdb.User
+}
+
+// BatchGetUsers 按请求顺序批量返回用户，底层用单条 ANY 查询避免 N+1。
+// 重复 ID 会得到各自的结果项；非法 UUID 和超限请求返回 ValidationError。
+func (s *Service) BatchGetUsers(ctx context.Context, ids []string) ([]BatchGetUsersResult, error) {
+	if len(ids) > maxBatchGetUsers {
+		return nil, &ValidationError{Violations: []apierror.Violation{{
+			Field:       "ids",
+			Description: fmt.Sprintf("at most %d ids per request", maxBatchGetUsers),
+		}}}
+	}
+
+	var violations []apierror.Violation
+	seen := make(map[uuid.UUID]bool, len(ids))
+	unique := make([]uuid.UUID, 0, len(ids))
+	for i, id := range ids {
+		userUUID, err := uuid.Parse(id)
+		if err != nil {
+			violations = append(violations, apierror.Violation{
+				Field:       fmt.Sprintf("ids[%d]", i),
+				Description: "invalid user ID",
+			})
+			continue
+		}
+		if !seen[userUUID] {
+			seen[userUUID] = true
+			unique = append(unique, userUUID)
+		}
+	}
+	if len(violations) > 0 {
+		return nil, &ValidationError{Violations: violations}
+	}
+	if len(unique) == 0 {
+		return []BatchGetUsersResult{}, nil
+	}
+
+	users, err := s.store.GetUsersByIDs(ctx, unique)
+	if err != nil {
+		return nil, fmt.Errorf("failed to batch get users: %w", err)
+	}
+
+	byID := make(map[uuid.UUID]*userdb.User, len(users))
+	for _, user := range users {
+		byID[user.ID] = user
+	}
+
+	results := make([]BatchGetUsersResult, 0, len(ids))
+	for _, id := range ids {
+		userUUID, _ := uuid.Parse(id)
+		results = append(results, BatchGetUsersResult{
+			ID:   id,
+			User: byID[userUUID],
+		})
+	}
+	return results, nil
+}
+
+// ChangePassword 校验旧密码后按密码策略更新为新密码。
+// 旧密码不匹配返回 ErrInvalidCredentials，新密码不达标返回 ValidationError。
+func (s *Service) ChangePassword(ctx context.Context, id, oldPassword, newPassword string) error {
+	userUUID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid user ID: %w", ErrInvalidID)
+	}
+
+	minPassword := s.validation.MinPasswordLen
+	if minPassword <= 0 {
+		minPassword = defaultMinPasswordLen
+	}
+	if len(newPassword) < minPassword {
+		return &ValidationError{Violations: []apierror.Violation{{
+			Field:       "new_password",
+			Description: fmt.Sprintf("must be at least %d characters", minPassword),
+		}}}
+	}
+
+	user, err := s.store.GetUser(ctx, userUUID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(oldPassword)); err != nil {
+		s.logger.WarnContext(ctx, "Password change rejected: old password mismatch",
+			logger.UserID(user.ID.String()),
+			logger.ErrorCode("INVALID_CREDENTIALS"),
+		)
+		return ErrInvalidCredentials
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	if err := s.store.UpdateUserPassword(ctx, userUUID, string(hashedPassword)); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Password changed",
+		logger.UserID(user.ID.String()),
+		zap.String("username", user.Username),
+	)
+	audit.Record(ctx, audit.Event{
+		Action: audit.ActionPasswordChanged,
+		Target: user.ID.String(),
+	})
+	return nil
+}
+
+// uniqueViolationError 把唯一约束冲突（SQLSTATE 23505）翻译为"已存在"错误，
+// 约束名区分 email / username，其余错误返回 nil 由调用方继续处理
+func uniqueViolationError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != "23505" {
+		return nil
+	}
+	if strings.Contains(pqErr.Constraint, "username") {
+		return ErrUsernameExists
+	}
+	return ErrEmailExists
+}
+
 func (s *Service) GetUser(ctx context.Context, id string) (*userdb.User, error) {
 	user, err := s.store.GetUser(ctx, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("user not found")
+			return nil, ErrNotFound
 		}
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 	return user, nil
 }
 
+func (s *Service) GetUserByEmail(ctx context.Context, email string) (*userdb.User, error) {
+	user, err := s.store.GetUserByEmail(ctx, email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get user by email: %w", err)
+	}
+	return user, nil
+}
+
 func (s *Service) UpdateUser(ctx context.Context, id string, email, username, fullName *string) (*userdb.User, error) {
 	userID, err := uuid.Parse(id)
 	if err != nil {
-		return nil, fmt.Errorf("invalid user ID: %w", err)
+		return nil, fmt.Errorf("invalid user ID: %w", ErrInvalidID)
 	}
 
 	params := userdb.UpdateUserParams{
@@ -125,17 +444,90 @@ func (s *Service) UpdateUser(ctx context.Context, id string, email, username, fu
 	return user, nil
 }
 
+// DeleteUser 软删除用户，仅标记 deleted_at，保留记录用于审计
 func (s *Service) DeleteUser(ctx context.Context, id string) error {
 	if err := s.store.DeleteUser(ctx, id); err != nil {
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
+	audit.Record(ctx, audit.Event{
+		Action: audit.ActionUserDeleted,
+		Target: id,
+	})
 	return nil
 }
 
-func (s *Service) ListUsers(ctx context.Context, pageSize int32, offset int32) ([]*userdb.User, error) {
-	users, err := s.store.ListUsers(ctx, pageSize, offset)
+// RestoreUser 恢复软删除的用户，清除 deleted_at 标记
+func (s *Service) RestoreUser(ctx context.Context, id string) (*userdb.User, error) {
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID: %w", ErrInvalidID)
+	}
+
+	user, err := s.store.RestoreUser(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to restore user: %w", err)
+	}
+	audit.Record(ctx, audit.Event{
+		Action: audit.ActionUserRestored,
+		Target: user.ID.String(),
+	})
+	return user, nil
+}
+
+// 用户列表的排序方式，与数据库查询中的排序键一致；空字符串表示默认的创建时间倒序
+const (
+	SortCreatedAtDesc = "created_at_desc"
+	SortCreatedAtAsc  = "created_at_asc"
+	SortUsernameAsc   = "username_asc"
+	SortUsernameDesc  = "username_desc"
+)
+
+// ListUsersFilter ListUsers 的过滤与排序条件，零值字段表示不过滤
+type ListUsersFilter struct {
+	// EmailContains 邮箱子串匹配（不区分大小写）
+	EmailContains string
+	// UsernamePrefix 用户名前缀匹配
+	UsernamePrefix string
+	// CreatedAfter / CreatedBefore 创建时间范围（闭区间）
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	// SortOrder 排序方式，见 Sort* 常量
+	SortOrder string
+	// IncludeDeleted 为 true 时包含已软删除的用户
+	IncludeDeleted bool
+}
+
+// ListUsers 按过滤条件分页列出用户，同时返回满足条件的总数供界面分页。
+// 时间范围颠倒（created_after 晚于 created_before）返回 ValidationError。
+func (s *Service) ListUsers(ctx context.Context, pageSize int32, offset int32, filter ListUsersFilter) ([]*userdb.User, int64, error) {
+	if filter.CreatedAfter != nil && filter.CreatedBefore != nil && filter.CreatedAfter.After(*filter.CreatedBefore) {
+		return nil, 0, &ValidationError{Violations: []apierror.Violation{{
+			Field:       "created_after",
+			Description: "created_after must not be later than created_before",
+		}}}
+	}
+
+	arg := userdb.ListUsersFilteredParams{
+		IncludeDeleted: filter.IncludeDeleted,
+		EmailContains:  filter.EmailContains,
+		UsernamePrefix: filter.UsernamePrefix,
+		SortOrder:      filter.SortOrder,
+		PageLimit:      pageSize,
+		PageOffset:     offset,
+	}
+	if filter.CreatedAfter != nil {
+		arg.CreatedAfter = sql.NullTime{Time: *filter.CreatedAfter, Valid: true}
+	}
+	if filter.CreatedBefore != nil {
+		arg.CreatedBefore = sql.NullTime{Time: *filter.CreatedBefore, Valid: true}
+	}
+
+	users, total, err := s.store.ListUsersFiltered(ctx, arg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list users: %w", err)
+		return nil, 0, fmt.Errorf("failed to list users: %w", err)
 	}
-	return users, nil
+	return users, total, nil
 }
@@ -0,0 +1,151 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"micro-holtye/internal/pkg/logger"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+// newUserTestService 用 sqlmock 支撑的用户服务，返回服务和 sqlmock 句柄
+func newUserTestService(t *testing.T) (*Service, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return NewService(NewStore(db), logger.NewMemoryLogger()), mock
+}
+
+func TestBatchGetUsersHandlesDuplicateAndUnknownIDs(t *testing.T) {
+	service, mock := newUserTestService(t)
+
+	knownID := uuid.New()
+	unknownID := uuid.New()
+	// 重复的已知 ID 与一个不存在的 ID：底层只查一次，结果按请求顺序返回
+	ids := []string{knownID.String(), unknownID.String(), knownID.String()}
+
+	now := time.Now()
+	mock.ExpectQuery("-- name: GetUsersByIDs").
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(
+			knownID, "a@b.c", "alice", sql.NullString{}, "hash", now, now, sql.NullTime{},
+		))
+
+	results, err := service.BatchGetUsers(context.Background(), ids)
+	if err != nil {
+		t.Fatalf("BatchGetUsers: %v", err)
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want one per requested id", len(results))
+	}
+	if results[0].ID != knownID.String() || results[0].User == nil {
+		t.Errorf("results[0] = %+v, want found user for %s", results[0], knownID)
+	}
+	if results[1].User != nil {
+		t.Errorf("results[1].User = %+v, want nil for unknown id", results[1].User)
+	}
+	if results[2].User == nil {
+		t.Error("results[2].User = nil, want duplicate id resolved to the same user")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expected a single ANY query: %v", err)
+	}
+}
+
+func TestBatchGetUsersRejectsOversizedAndMalformedInput(t *testing.T) {
+	service, _ := newUserTestService(t)
+	ctx := context.Background()
+
+	oversized := make([]string, maxBatchGetUsers+1)
+	for i := range oversized {
+		oversized[i] = uuid.NewString()
+	}
+	var verr *ValidationError
+	if _, err := service.BatchGetUsers(ctx, oversized); !errors.As(err, &verr) {
+		t.Errorf("oversized batch error = %v, want ValidationError", err)
+	}
+
+	if _, err := service.BatchGetUsers(ctx, []string{"not-a-uuid"}); !errors.As(err, &verr) {
+		t.Errorf("malformed id error = %v, want ValidationError", err)
+	}
+}
+
+func TestListUsersCombinedFiltersReachTheQuery(t *testing.T) {
+	service, mock := newUserTestService(t)
+
+	after := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	before := time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC)
+	filter := ListUsersFilter{
+		EmailContains:  "example.com",
+		UsernamePrefix: "ali",
+		CreatedAfter:   &after,
+		CreatedBefore:  &before,
+		SortOrder:      SortUsernameAsc,
+	}
+
+	now := time.Now()
+	mock.ExpectQuery("-- name: ListUsersFiltered").
+		WithArgs(false, "example.com", "ali",
+			sql.NullTime{Time: after, Valid: true}, sql.NullTime{Time: before, Valid: true},
+			SortUsernameAsc, int32(10), int32(20)).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(
+			uuid.New(), "alice@example.com", "alice", sql.NullString{}, "hash", now, now, sql.NullTime{},
+		))
+	mock.ExpectQuery("-- name: CountUsersFiltered").
+		WithArgs(false, "example.com", "ali",
+			sql.NullTime{Time: after, Valid: true}, sql.NullTime{Time: before, Valid: true}).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(int64(42)))
+
+	users, total, err := service.ListUsers(context.Background(), 10, 20, filter)
+	if err != nil {
+		t.Fatalf("ListUsers: %v", err)
+	}
+	if len(users) != 1 || users[0].Username != "alice" {
+		t.Errorf("users = %+v, want the single filtered row", users)
+	}
+	if total != 42 {
+		t.Errorf("total = %d, want the unpaginated count 42", total)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("filters did not reach the parameterized query: %v", err)
+	}
+}
+
+func TestListUsersEmptyResultAndInvertedRange(t *testing.T) {
+	service, mock := newUserTestService(t)
+
+	mock.ExpectQuery("-- name: ListUsersFiltered").
+		WillReturnRows(sqlmock.NewRows(userColumns))
+	mock.ExpectQuery("-- name: CountUsersFiltered").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(int64(0)))
+
+	users, total, err := service.ListUsers(context.Background(), 10, 0, ListUsersFilter{EmailContains: "nobody"})
+	if err != nil {
+		t.Fatalf("ListUsers with no matches: %v", err)
+	}
+	if len(users) != 0 || total != 0 {
+		t.Errorf("got %d users / total %d, want empty result", len(users), total)
+	}
+
+	// 时间范围颠倒不触达数据库，直接返回校验错误
+	after := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	before := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var verr *ValidationError
+	_, _, err = service.ListUsers(context.Background(), 10, 0, ListUsersFilter{
+		CreatedAfter:  &after,
+		CreatedBefore: &before,
+	})
+	if !errors.As(err, &verr) {
+		t.Errorf("inverted range error = %v, want ValidationError", err)
+	}
+}
@@ -3,21 +3,35 @@ package user
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
 
+	"micro-holtye/internal/pkg/cache"
 	userdb "micro-holtye/internal/service/user/db"
 
 	"github.com/google/uuid"
 )
 
+// userCacheTTL 用户缓存的保留时长，写操作会主动失效，TTL 仅作兜底
+const userCacheTTL = time.Minute
+
 type Store struct {
 	queries userdb.Querier
 	db      *sql.DB
+	cache   *cache.Cache
 }
 
 func NewStore(database *sql.DB) *Store {
+	return NewStoreWithCache(database, nil)
+}
+
+// NewStoreWithCache 创建带 Redis 缓存的 Store，cache 为 nil 时直连数据库
+func NewStoreWithCache(database *sql.DB, userCache *cache.Cache) *Store {
 	return &Store{
-		queries: userdb.New(database),
+		queries: newInstrumentedQuerier(userdb.New(database)),
 		db:      database,
+		cache:   userCache,
 	}
 }
 
@@ -30,19 +44,86 @@ func (s *Store) GetUser(ctx context.Context, id any) (*userdb.User, error) {
 	if !ok {
 		return nil, sql.ErrNoRows
 	}
-	return s.queries.GetUser(ctx, userID)
+
+	if user, ok := s.cachedUser(ctx, userID); ok {
+		return user, nil
+	}
+
+	user, err := s.queries.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.cacheUser(ctx, user)
+	return user, nil
+}
+
+// cachedUser 尝试从缓存读取用户，未命中或缓存不可用时返回 false
+func (s *Store) cachedUser(ctx context.Context, userID uuid.UUID) (*userdb.User, bool) {
+	if s.cache == nil {
+		return nil, false
+	}
+	data, err := s.cache.Get(ctx, userID.String())
+	if err != nil {
+		// 未命中走正常回源；传输错误同样降级为回源，不影响请求
+		return nil, false
+	}
+	var user userdb.User
+	if err := json.Unmarshal(data, &user); err != nil {
+		return nil, false
+	}
+	return &user, true
+}
+
+// cacheUser 把用户写入缓存，失败仅降级不报错
+func (s *Store) cacheUser(ctx context.Context, user *userdb.User) {
+	if s.cache == nil || user == nil {
+		return
+	}
+	data, err := json.Marshal(user)
+	if err != nil {
+		return
+	}
+	_ = s.cache.Set(ctx, user.ID.String(), data, userCacheTTL)
+}
+
+// invalidateUser 使指定用户的缓存失效
+func (s *Store) invalidateUser(ctx context.Context, userID uuid.UUID) {
+	if s.cache == nil {
+		return
+	}
+	_ = s.cache.Delete(ctx, userID.String())
 }
 
 func (s *Store) GetUserByEmail(ctx context.Context, email string) (*userdb.User, error) {
-	return s.queries.GetUserByEmail(ctx, email)
+	// sqlc 生成的 :one 查询在出错时仍返回非 nil 的零值结构体，
+	// 这里统一归一为 nil，调用方可直接用 nil 判断用户是否存在
+	user, err := s.queries.GetUserByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
 }
 
 func (s *Store) GetUserByUsername(ctx context.Context, username string) (*userdb.User, error) {
-	return s.queries.GetUserByUsername(ctx, username)
+	user, err := s.queries.GetUserByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetUsersByIDs 单条查询批量加载用户，结果顺序不保证与入参一致
+func (s *Store) GetUsersByIDs(ctx context.Context, ids []uuid.UUID) ([]*userdb.User, error) {
+	return s.queries.GetUsersByIDs(ctx, ids)
 }
 
 func (s *Store) UpdateUser(ctx context.Context, params userdb.UpdateUserParams) (*userdb.User, error) {
-	return s.queries.UpdateUser(ctx, params)
+	user, err := s.queries.UpdateUser(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidateUser(ctx, params.ID)
+	return user, nil
 }
 
 func (s *Store) DeleteUser(ctx context.Context, id any) error {
@@ -50,10 +131,87 @@ func (s *Store) DeleteUser(ctx context.Context, id any) error {
 	if !ok {
 		return sql.ErrNoRows
 	}
-	return s.queries.DeleteUser(ctx, userID)
+	if err := s.queries.DeleteUser(ctx, userID); err != nil {
+		return err
+	}
+	s.invalidateUser(ctx, userID)
+	return nil
 }
 
-func (s *Store) ListUsers(ctx context.Context, limit, offset int32) ([]*userdb.User, error) {
+func (s *Store) RestoreUser(ctx context.Context, id any) (*userdb.User, error) {
+	userID, ok := id.(uuid.UUID)
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	user, err := s.queries.RestoreUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidateUser(ctx, userID)
+	return user, nil
+}
+
+// UpdateUserPassword 更新用户密码哈希并使缓存失效
+func (s *Store) UpdateUserPassword(ctx context.Context, userID uuid.UUID, passwordHash string) error {
+	if err := s.queries.UpdateUserPassword(ctx, userdb.UpdateUserPasswordParams{
+		ID:           userID,
+		PasswordHash: passwordHash,
+	}); err != nil {
+		return err
+	}
+	s.invalidateUser(ctx, userID)
+	return nil
+}
+
+func (s *Store) WithTx(ctx context.Context, fn func(*Store) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	txStore := &Store{
+		queries: newInstrumentedQuerier(userdb.New(tx)),
+		db:      s.db,
+		cache:   s.cache,
+	}
+
+	if err := fn(txStore); err != nil {
+		// 回滚失败时保留原始错误，避免被回滚错误掩盖
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ListUsersFiltered 按过滤条件分页列出用户，并返回满足条件的总数（不受分页影响）
+func (s *Store) ListUsersFiltered(ctx context.Context, arg userdb.ListUsersFilteredParams) ([]*userdb.User, int64, error) {
+	users, err := s.queries.ListUsersFiltered(ctx, arg)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.queries.CountUsersFiltered(ctx, userdb.CountUsersFilteredParams{
+		IncludeDeleted: arg.IncludeDeleted,
+		EmailContains:  arg.EmailContains,
+		UsernamePrefix: arg.UsernamePrefix,
+		CreatedAfter:   arg.CreatedAfter,
+		CreatedBefore:  arg.CreatedBefore,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+func (s *Store) ListUsers(ctx context.Context, limit, offset int32, includeDeleted bool) ([]*userdb.User, error) {
+	if includeDeleted {
+		return s.queries.ListUsersIncludeDeleted(ctx, userdb.ListUsersIncludeDeletedParams{
+			Limit:  limit,
+			Offset: offset,
+		})
+	}
 	return s.queries.ListUsers(ctx, userdb.ListUsersParams{
 		Limit:  limit,
 		Offset: offset,
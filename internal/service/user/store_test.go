@@ -0,0 +1,236 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"micro-holtye/internal/pkg/cache"
+	"micro-holtye/internal/pkg/logger"
+	userdb "micro-holtye/internal/service/user/db"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestWithTxRollsBackAndSurfacesOriginalError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("-- name: GetUserByEmail").WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+
+	store := NewStore(db)
+	stepErr := errors.New("profile step failed")
+
+	err = store.WithTx(context.Background(), func(txStore *Store) error {
+		// 事务内的查询走 tx 绑定的 querier
+		if _, lookupErr := txStore.GetUserByEmail(context.Background(), "a@b.c"); lookupErr == nil {
+			t.Error("GetUserByEmail succeeded, want error from tx connection")
+		}
+		return stepErr
+	})
+
+	if !errors.Is(err, stepErr) {
+		t.Errorf("WithTx error = %v, want original step error", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet sqlmock expectations: %v", err)
+	}
+}
+
+func TestWithTxKeepsOriginalErrorWhenRollbackFails(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	rollbackErr := errors.New("rollback exploded")
+	mock.ExpectBegin()
+	mock.ExpectRollback().WillReturnError(rollbackErr)
+
+	store := NewStore(db)
+	stepErr := errors.New("step failed")
+
+	err = store.WithTx(context.Background(), func(*Store) error { return stepErr })
+
+	// 回滚失败不能掩盖业务错误，同时回滚错误也要出现在消息里
+	if !errors.Is(err, stepErr) {
+		t.Errorf("WithTx error = %v, want wrapped step error", err)
+	}
+	if err == nil || !strings.Contains(err.Error(), rollbackErr.Error()) {
+		t.Errorf("WithTx error %q does not mention rollback failure", err)
+	}
+}
+
+func TestWithTxCommitsOnSuccess(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	store := NewStore(db)
+	if err := store.WithTx(context.Background(), func(*Store) error { return nil }); err != nil {
+		t.Fatalf("WithTx: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet sqlmock expectations: %v", err)
+	}
+}
+
+// newCachedStore 用 miniredis 缓存和 sqlmock 数据库构建 Store
+func newCachedStore(t *testing.T) (*Store, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return NewStoreWithCache(db, cache.New(client, "user:")), mock
+}
+
+func TestGetUserServesSecondReadFromCache(t *testing.T) {
+	store, mock := newCachedStore(t)
+	ctx := context.Background()
+	userID := uuid.New()
+	now := time.Now()
+
+	// 仅期望一次数据库查询：第二次读取必须命中缓存
+	mock.ExpectQuery("-- name: GetUser :one").
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(
+			userID, "a@b.c", "alice", sql.NullString{}, "hash", now, now, sql.NullTime{},
+		))
+
+	first, err := store.GetUser(ctx, userID)
+	if err != nil {
+		t.Fatalf("first GetUser: %v", err)
+	}
+	second, err := store.GetUser(ctx, userID)
+	if err != nil {
+		t.Fatalf("second GetUser: %v", err)
+	}
+	if second.Email != first.Email || second.ID != first.ID {
+		t.Errorf("cached user = %+v, want same as first read %+v", second, first)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("second read went to the database: %v", err)
+	}
+}
+
+func TestUpdateUserInvalidatesCache(t *testing.T) {
+	store, mock := newCachedStore(t)
+	ctx := context.Background()
+	userID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery("-- name: GetUser :one").
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(
+			userID, "a@b.c", "alice", sql.NullString{}, "hash", now, now, sql.NullTime{},
+		))
+	if _, err := store.GetUser(ctx, userID); err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+
+	mock.ExpectQuery("-- name: UpdateUser :one").
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(
+			userID, "new@b.c", "alice", sql.NullString{}, "hash", now, now, sql.NullTime{},
+		))
+	if _, err := store.UpdateUser(ctx, userdb.UpdateUserParams{
+		ID: userID, Email: "new@b.c", Username: "alice",
+	}); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+
+	// 缓存已失效，下一次读取应回源数据库拿到新值
+	mock.ExpectQuery("-- name: GetUser :one").
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(
+			userID, "new@b.c", "alice", sql.NullString{}, "hash", now, now, sql.NullTime{},
+		))
+	user, err := store.GetUser(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetUser after update: %v", err)
+	}
+	if user.Email != "new@b.c" {
+		t.Errorf("Email after invalidation = %q, want new@b.c", user.Email)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("read after invalidation did not reach the database: %v", err)
+	}
+}
+
+func TestConcurrentCreateUserOneWinsOneGetsAlreadyExists(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	// 两个事务并发交错，期望不按固定顺序匹配
+	mock.MatchExpectationsInOrder(false)
+
+	service := NewService(NewStore(db), logger.NewMemoryLogger())
+	now := time.Now()
+	winnerID := uuid.New()
+
+	for i := 0; i < 2; i++ {
+		mock.ExpectBegin()
+		mock.ExpectQuery("-- name: GetUserByEmail").WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery("-- name: GetUserByUsername").WillReturnError(sql.ErrNoRows)
+	}
+	// 预检查同时通过后，唯一约束只放行一个插入
+	mock.ExpectQuery("-- name: CreateUser :one").
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(
+			winnerID, "a@b.c", "alice", sql.NullString{}, "hash", now, now, sql.NullTime{},
+		))
+	mock.ExpectQuery("-- name: CreateUser :one").
+		WillReturnError(&pq.Error{Code: "23505", Constraint: "users_email_key"})
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	results := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := service.CreateUser(context.Background(), "a@b.c", "alice", "", "secret-password")
+			results <- err
+		}()
+	}
+
+	var successes, conflicts int
+	for i := 0; i < 2; i++ {
+		switch err := <-results; {
+		case err == nil:
+			successes++
+		case errors.Is(err, ErrEmailExists):
+			conflicts++
+		default:
+			t.Errorf("unexpected CreateUser error: %v", err)
+		}
+	}
+
+	if successes != 1 || conflicts != 1 {
+		t.Errorf("got %d successes and %d conflicts, want exactly 1 of each", successes, conflicts)
+	}
+}
@@ -0,0 +1,69 @@
+package user
+
+import (
+	"testing"
+
+	"micro-holtye/internal/pkg/logger"
+)
+
+func TestValidateCreateUserRules(t *testing.T) {
+	service := NewService(nil, logger.NewMemoryLogger())
+
+	cases := []struct {
+		name          string
+		email         string
+		username      string
+		password      string
+		wantViolation string // 为空表示全部通过
+	}{
+		{"valid input", "a@b.com", "alice_01", "longenough", ""},
+		{"empty email", "", "alice_01", "longenough", "email"},
+		{"email without at", "not-an-email", "alice_01", "longenough", "email"},
+		{"email without domain dot", "a@localhost", "alice_01", "longenough", "email"},
+		{"username too short", "a@b.com", "ab", "longenough", "username"},
+		{"username too long", "a@b.com", "a_very_long_username_over_32_chars_x", "longenough", "username"},
+		{"username with invalid chars", "a@b.com", "alice-01!", "longenough", "username"},
+		{"password too short", "a@b.com", "alice_01", "short", "password"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			verr := service.validateCreateUser(tc.email, tc.username, tc.password)
+			if tc.wantViolation == "" {
+				if verr != nil {
+					t.Fatalf("valid input rejected: %v", verr.Violations)
+				}
+				return
+			}
+			if verr == nil {
+				t.Fatalf("invalid %s accepted", tc.wantViolation)
+			}
+			for _, v := range verr.Violations {
+				if v.Field == tc.wantViolation {
+					return
+				}
+			}
+			t.Errorf("violations %v do not mention field %q", verr.Violations, tc.wantViolation)
+		})
+	}
+}
+
+func TestValidateCreateUserHonorsConfiguredRules(t *testing.T) {
+	service := NewService(nil, logger.NewMemoryLogger())
+	service.SetValidationConfig(UserValidationConfig{
+		MinUsernameLen: 2,
+		MinPasswordLen: 12,
+	})
+
+	// 放宽的用户名下限放行双字符名，收紧的密码下限拒绝 10 字符密码
+	if verr := service.validateCreateUser("a@b.com", "ab", "twelve-chars"); verr != nil {
+		t.Errorf("configured rules rejected valid input: %v", verr.Violations)
+	}
+	verr := service.validateCreateUser("a@b.com", "alice_01", "ten-chars-")
+	if verr == nil {
+		t.Fatal("password below the configured minimum accepted")
+	}
+	if verr.Violations[0].Field != "password" {
+		t.Errorf("violation field = %q, want password", verr.Violations[0].Field)
+	}
+}
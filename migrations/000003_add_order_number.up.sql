@@ -0,0 +1,7 @@
+CREATE SEQUENCE order_number_seq;
+
+ALTER TABLE orders
+    ADD COLUMN order_number VARCHAR(20) NOT NULL
+    DEFAULT ('ORD-' || lpad(nextval('order_number_seq')::text, 10, '0'));
+
+CREATE UNIQUE INDEX idx_orders_order_number ON orders(order_number);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_users_username_active;
+DROP INDEX IF EXISTS idx_users_email_active;
+
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
+ALTER TABLE users ADD CONSTRAINT users_username_key UNIQUE (username);
+
+DROP INDEX IF EXISTS idx_users_deleted_at;
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,7 @@
+// Package migrations 内嵌数据库迁移脚本，供启动时的迁移运行器使用
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS